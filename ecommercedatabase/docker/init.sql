@@ -7,6 +7,7 @@ WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = 'ecommerce');
 
 -- สร้าง Extension สำหรับ UUID (เฉพาะ PostgreSQL)
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+CREATE EXTENSION IF NOT EXISTS btree_gist; -- EXCLUDE ของ product_sales
 
 -- สร้าง ENUM สำหรับ status และ product_type
 DO $$
@@ -130,13 +131,22 @@ CREATE TABLE IF NOT EXISTS product_images (
 CREATE TABLE IF NOT EXISTS product_options (
     option_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     product_id UUID NOT NULL,
-    name VARCHAR(255) NOT NULL,
+    optname VARCHAR(255) NOT NULL,
     values JSONB NOT NULL, -- ใช้ JSONB สำหรับเก็บค่าตัวเลือก
     created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE
 );
 
+-- ฐานข้อมูลเดิมสร้างคอลัมน์ชื่อ name แต่โค้ดอ่านและเขียน optname จึงเปลี่ยนชื่อให้ตรงกัน
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'product_options' AND column_name = 'name') THEN
+        ALTER TABLE product_options RENAME COLUMN name TO optname;
+    END IF;
+END$$;
+
 -- สร้างตาราง inventory
 CREATE TABLE IF NOT EXISTS inventory (
     product_id UUID PRIMARY KEY,
@@ -291,4 +301,910 @@ CREATE INDEX idx_users_email ON users(email);
 CREATE INDEX idx_users_google_id ON users(google_id);
 CREATE INDEX idx_user_sessions_user_id ON user_sessions(user_id);
 CREATE INDEX idx_user_login_history_user_id ON user_login_history(user_id);
-CREATE INDEX idx_api_keys_api_key ON api_keys(api_key);
\ No newline at end of file
+CREATE INDEX idx_api_keys_api_key ON api_keys(api_key);
+
+-- ============================================================
+-- ขั้นตอนการสมัครและยืนยันตัวตนผู้ขาย (Seller onboarding)
+-- ============================================================
+DO $$
+BEGIN
+    -- applied -> documents_submitted -> in_review -> approved / rejected
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'seller_verification_status') THEN
+        CREATE TYPE seller_verification_status AS ENUM ('applied', 'documents_submitted', 'in_review', 'approved', 'rejected');
+    END IF;
+END$$;
+
+ALTER TABLE sellers
+    ADD COLUMN IF NOT EXISTS logo VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS description TEXT,
+    ADD COLUMN IF NOT EXISTS address VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS phone VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS email VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS owner_user_id UUID REFERENCES users(user_id) ON DELETE SET NULL,
+    -- ร้านค้าที่มีอยู่เดิมถือว่ายืนยันแล้ว
+    ADD COLUMN IF NOT EXISTS verification_status seller_verification_status NOT NULL DEFAULT 'approved',
+    ADD COLUMN IF NOT EXISTS rejection_reason TEXT,
+    ADD COLUMN IF NOT EXISTS submitted_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS reviewed_by UUID REFERENCES users(user_id) ON DELETE SET NULL;
+
+-- ร้านค้าที่สมัครใหม่ต้องเริ่มจากสถานะ 'applied'
+ALTER TABLE sellers ALTER COLUMN verification_status SET DEFAULT 'applied';
+
+-- สร้างตาราง seller_documents สำหรับเอกสารประกอบการสมัคร
+CREATE TABLE IF NOT EXISTS seller_documents (
+    document_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    seller_id UUID NOT NULL,
+    document_type VARCHAR(50) NOT NULL, -- เช่น 'id_card', 'business_registration', 'bank_book'
+    document_url VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (seller_id) REFERENCES sellers(seller_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sellers_verification_status ON sellers(verification_status);
+CREATE INDEX IF NOT EXISTS idx_sellers_owner_user_id ON sellers(owner_user_id);
+CREATE INDEX IF NOT EXISTS idx_seller_documents_seller_id ON seller_documents(seller_id);
+
+
+-- ============================================================
+-- คำสั่งซื้อ (Orders) และเขตเวลาของร้านค้าสำหรับรายงานยอดขาย
+-- ============================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'order_status') THEN
+        CREATE TYPE order_status AS ENUM ('pending', 'paid', 'shipped', 'delivered', 'cancelled', 'refunded');
+    END IF;
+END$$;
+
+-- เขตเวลาของร้านค้า ใช้ในการแบ่งช่วงวัน/สัปดาห์/เดือนของรายงานยอดขาย
+ALTER TABLE sellers ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Bangkok';
+
+-- สร้างตาราง orders
+CREATE TABLE IF NOT EXISTS orders (
+    order_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID,
+    status order_status NOT NULL DEFAULT 'pending',
+    total_amount NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (total_amount >= 0),
+    delivered_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+-- สร้างตาราง order_items (หนึ่งคำสั่งซื้ออาจมีสินค้าจากหลายร้าน)
+CREATE TABLE IF NOT EXISTS order_items (
+    order_item_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL,
+    product_id UUID,
+    seller_id UUID NOT NULL,
+    product_name VARCHAR(255) NOT NULL, -- เก็บชื่อสินค้า ณ เวลาที่สั่งซื้อ
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price NUMERIC(10, 2) NOT NULL CHECK (unit_price >= 0),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE SET NULL,
+    FOREIGN KEY (seller_id) REFERENCES sellers(seller_id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_orders_updated_at BEFORE UPDATE ON orders
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders(user_id);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_seller_id ON order_items(seller_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items(product_id);
+
+
+-- ============================================================
+-- รีวิวและคะแนนสินค้า (Product reviews)
+-- ============================================================
+-- เก็บคะแนนเฉลี่ยไว้ในตาราง products เพื่อใช้กรองและเรียงลำดับได้โดยไม่ต้อง aggregate ทุกครั้ง
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS rating_average NUMERIC(3, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rating_count INTEGER NOT NULL DEFAULT 0;
+
+-- สร้างตาราง product_reviews (ผู้ใช้หนึ่งคนรีวิวสินค้าหนึ่งชิ้นได้ครั้งเดียว)
+CREATE TABLE IF NOT EXISTS product_reviews (
+    review_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body TEXT,
+    seller_reply TEXT,
+    seller_replied_at TIMESTAMPTZ,
+    helpful_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, user_id),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- สร้างตาราง review_images
+CREATE TABLE IF NOT EXISTS review_images (
+    image_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    review_id UUID NOT NULL,
+    image_url VARCHAR(255) NOT NULL,
+    sort_order INTEGER DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (review_id) REFERENCES product_reviews(review_id) ON DELETE CASCADE
+);
+
+-- สร้างตาราง review_helpful_votes (โหวตว่ารีวิวมีประโยชน์ได้คนละหนึ่งครั้ง)
+CREATE TABLE IF NOT EXISTS review_helpful_votes (
+    review_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (review_id, user_id),
+    FOREIGN KEY (review_id) REFERENCES product_reviews(review_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_product_reviews_updated_at BEFORE UPDATE ON product_reviews
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE INDEX IF NOT EXISTS idx_product_reviews_product_id ON product_reviews(product_id);
+CREATE INDEX IF NOT EXISTS idx_review_images_review_id ON review_images(review_id);
+CREATE INDEX IF NOT EXISTS idx_products_rating_average ON products(rating_average);
+
+
+-- ============================================================
+-- รายการสินค้าที่อยากได้ (Wishlists)
+-- ============================================================
+-- สร้างตาราง wishlists (share_token เป็น NULL เมื่อไม่ได้แชร์)
+CREATE TABLE IF NOT EXISTS wishlists (
+    wishlist_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    share_token VARCHAR(64) UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name),
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- สร้างตาราง wishlist_items
+CREATE TABLE IF NOT EXISTS wishlist_items (
+    wishlist_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    added_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (wishlist_id, product_id),
+    FOREIGN KEY (wishlist_id) REFERENCES wishlists(wishlist_id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_wishlists_updated_at BEFORE UPDATE ON wishlists
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE INDEX IF NOT EXISTS idx_wishlists_user_id ON wishlists(user_id);
+
+
+-- ============================================================
+-- ประวัติการดูสินค้า ใช้สำหรับระบบแนะนำสินค้า (Recommendations)
+-- ============================================================
+CREATE TABLE IF NOT EXISTS product_views (
+    view_id BIGSERIAL PRIMARY KEY,
+    product_id UUID NOT NULL,
+    user_id UUID,
+    session_id VARCHAR(64),
+    viewed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CHECK (user_id IS NOT NULL OR session_id IS NOT NULL),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_views_user_id ON product_views(user_id, viewed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_product_views_session_id ON product_views(session_id, viewed_at DESC);
+
+
+-- ============================================================
+-- รูปสินค้าหลายขนาด (thumbnail, card, zoom) และรูปเบลอระหว่างโหลด
+-- ============================================================
+ALTER TABLE product_images ADD COLUMN IF NOT EXISTS variants JSONB NOT NULL DEFAULT '{}'::jsonb;
+ALTER TABLE product_images ADD COLUMN IF NOT EXISTS placeholder TEXT;
+
+
+-- ============================================================
+-- ลำดับรูปสินค้าไม่ซ้ำกัน และมีรูปหลักได้เพียงรูปเดียวต่อสินค้า
+-- ============================================================
+-- จัดลำดับข้อมูลเดิมให้เป็น 0, 1, 2, ... และเก็บรูปหลักไว้เพียงรูปแรกก่อนเพิ่ม constraint
+WITH ordered AS (
+    SELECT image_id,
+           ROW_NUMBER() OVER (PARTITION BY product_id ORDER BY sort_order, created_at) - 1 AS new_order,
+           ROW_NUMBER() OVER (PARTITION BY product_id ORDER BY is_primary DESC, sort_order, created_at) AS primary_rank
+    FROM product_images
+)
+UPDATE product_images p
+SET sort_order = o.new_order,
+    is_primary = (p.is_primary AND o.primary_rank = 1)
+FROM ordered o
+WHERE p.image_id = o.image_id;
+
+DO $$
+BEGIN
+    -- DEFERRABLE เพื่อให้สลับลำดับหลายรูปได้ในคำสั่ง UPDATE เดียว
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'product_images_sort_order_key') THEN
+        ALTER TABLE product_images ADD CONSTRAINT product_images_sort_order_key
+            UNIQUE (product_id, sort_order) DEFERRABLE INITIALLY IMMEDIATE;
+    END IF;
+END$$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_images_one_primary ON product_images(product_id) WHERE is_primary;
+
+
+-- ============================================================
+-- ตัวเลือกสินค้า (Variants) เช่น ถุง 2kg กับ 10kg ที่มี SKU ราคา และสต็อกแยกกัน
+-- ============================================================
+-- แกนของตัวเลือก เช่น "น้ำหนัก" มีค่า ["2kg", "10kg"]
+CREATE TABLE IF NOT EXISTS product_option_axes (
+    axis_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    option_values TEXT[] NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, name),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    variant_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL,
+    sku VARCHAR(100) UNIQUE NOT NULL,
+    price NUMERIC(10, 2) CHECK (price >= 0), -- NULL หมายถึงใช้ราคาของสินค้าหลัก
+    option_values JSONB NOT NULL, -- เช่น {"น้ำหนัก": "2kg"}
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, option_values),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS variant_inventory (
+    variant_id UUID PRIMARY KEY,
+    quantity INTEGER NOT NULL CHECK (quantity >= 0),
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (variant_id) REFERENCES product_variants(variant_id) ON DELETE CASCADE
+);
+
+-- รูปของสินค้าสามารถผูกกับตัวเลือกใดตัวเลือกหนึ่งได้
+ALTER TABLE product_images ADD COLUMN IF NOT EXISTS variant_id UUID REFERENCES product_variants(variant_id) ON DELETE SET NULL;
+
+CREATE TRIGGER update_product_option_axes_updated_at BEFORE UPDATE ON product_option_axes
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE TRIGGER update_product_variants_updated_at BEFORE UPDATE ON product_variants
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE TRIGGER update_variant_inventory_updated_at BEFORE UPDATE ON variant_inventory
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants(product_id, sort_order);
+
+-- ตะกร้าสินค้าของผู้ใช้ที่เข้าสู่ระบบ
+CREATE TABLE IF NOT EXISTS carts (
+    cart_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    cart_item_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    cart_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    variant_id UUID,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    added_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE NULLS NOT DISTINCT (cart_id, product_id, variant_id),
+    FOREIGN KEY (cart_id) REFERENCES carts(cart_id) ON DELETE CASCADE,
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    FOREIGN KEY (variant_id) REFERENCES product_variants(variant_id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_carts_updated_at BEFORE UPDATE ON carts
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE TRIGGER update_cart_items_updated_at BEFORE UPDATE ON cart_items
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+-- รายการสั่งซื้ออ้างอิงตัวเลือกที่ซื้อ และเก็บชื่อตัวเลือก ณ เวลาที่สั่งซื้อ
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS variant_id UUID REFERENCES product_variants(variant_id) ON DELETE SET NULL;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS variant_sku VARCHAR(100);
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS variant_name VARCHAR(255);
+
+-- ============================================================
+-- นำเข้าสินค้าจากไฟล์ CSV หรือ NDJSON ในเบื้องหลัง
+-- ============================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'import_job_status') THEN
+        CREATE TYPE import_job_status AS ENUM ('queued', 'running', 'completed', 'failed');
+    END IF;
+END$$;
+
+CREATE TABLE IF NOT EXISTS product_import_jobs (
+    job_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    seller_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    format VARCHAR(20) NOT NULL,
+    dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+    upsert BOOLEAN NOT NULL DEFAULT FALSE,
+    status import_job_status NOT NULL DEFAULT 'queued',
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    created_count INTEGER NOT NULL DEFAULT 0,
+    updated_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    FOREIGN KEY (seller_id) REFERENCES sellers(seller_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- ข้อผิดพลาดรายแถว ใช้สร้างรายงานให้ผู้ขายดาวน์โหลด
+CREATE TABLE IF NOT EXISTS product_import_errors (
+    error_id BIGSERIAL PRIMARY KEY,
+    job_id UUID NOT NULL,
+    row_number INTEGER NOT NULL,
+    sku VARCHAR(100),
+    message TEXT NOT NULL,
+    FOREIGN KEY (job_id) REFERENCES product_import_jobs(job_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_import_jobs_status ON product_import_jobs(status);
+CREATE INDEX IF NOT EXISTS idx_product_import_errors_job_id ON product_import_errors(job_id, row_number);
+
+-- ============================================================
+-- timezone ของผู้ใช้ ใช้แสดงเวลาใน response เมื่อ request ไม่ได้ระบุ tz หรือ X-Timezone
+-- ============================================================
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64);
+
+-- ============================================================
+-- คำแปลเนื้อหาแคตตาล็อก ข้อความในตารางหลักเป็นภาษาไทย ภาษาอื่นเก็บแยกตาม locale
+-- ช่องที่เป็น NULL จะใช้ข้อความในตารางหลักแทน
+-- ============================================================
+CREATE TABLE IF NOT EXISTS product_translations (
+    product_id UUID NOT NULL,
+    locale VARCHAR(10) NOT NULL,
+    name VARCHAR(255),
+    description TEXT,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, locale),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS category_translations (
+    category_id INTEGER NOT NULL,
+    locale VARCHAR(10) NOT NULL,
+    name VARCHAR(255),
+    description TEXT,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (category_id, locale),
+    FOREIGN KEY (category_id) REFERENCES categories(category_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS seller_translations (
+    seller_id UUID NOT NULL,
+    locale VARCHAR(10) NOT NULL,
+    description TEXT,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (seller_id, locale),
+    FOREIGN KEY (seller_id) REFERENCES sellers(seller_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_translations_locale ON product_translations(locale);
+
+-- ============================================================
+-- อัตราแลกเปลี่ยนสำหรับแสดงราคาเป็นสกุลเงินอื่น การสั่งซื้อยังชำระเป็นบาทเสมอ
+-- rate คือจำนวนบาทต่อ 1 หน่วยของสกุลเงินนั้น
+-- ============================================================
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency CHAR(3) PRIMARY KEY CHECK (currency ~ '^[A-Z]{3}$' AND currency <> 'THB'),
+    rate NUMERIC(18, 6) NOT NULL CHECK (rate > 0),
+    updated_by UUID,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (updated_by) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+-- ============================================================
+-- โปรโมชันและโค้ดส่วนลด
+-- ============================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'promotion_type') THEN
+        CREATE TYPE promotion_type AS ENUM ('percentage', 'fixed_amount', 'free_shipping', 'buy_x_get_y');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'promotion_scope') THEN
+        CREATE TYPE promotion_scope AS ENUM ('all', 'product', 'category', 'seller', 'product_type');
+    END IF;
+END$$;
+
+CREATE TABLE IF NOT EXISTS promotions (
+    promotion_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    code VARCHAR(50) UNIQUE, -- ตัวพิมพ์ใหญ่เสมอ NULL คือโปรโมชันอัตโนมัติ
+    type promotion_type NOT NULL,
+    discount_percent NUMERIC(5, 2) CHECK (discount_percent > 0 AND discount_percent <= 100),
+    discount_amount NUMERIC(10, 2) CHECK (discount_amount > 0),
+    max_discount NUMERIC(10, 2) CHECK (max_discount > 0),
+    buy_quantity INTEGER CHECK (buy_quantity > 0),
+    get_quantity INTEGER CHECK (get_quantity > 0),
+    min_spend NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (min_spend >= 0),
+    scope promotion_scope NOT NULL DEFAULT 'all',
+    scope_values TEXT[] NOT NULL DEFAULT '{}', -- รหัสสินค้า หมวดหมู่ ร้านค้า หรือประเภทสินค้าตาม scope
+    usage_limit INTEGER CHECK (usage_limit > 0),
+    per_user_limit INTEGER CHECK (per_user_limit > 0),
+    starts_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ends_at TIMESTAMPTZ,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+-- การใช้โปรโมชันแต่ละครั้ง ใช้นับ usage_limit และ per_user_limit
+-- โปรโมชันที่เคยถูกใช้แล้วจึงลบไม่ได้ ให้ปิดด้วย is_active แทน
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    redemption_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    promotion_id UUID NOT NULL,
+    user_id UUID,
+    order_id UUID,
+    discount NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    redeemed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (promotion_id) REFERENCES promotions(promotion_id) ON DELETE RESTRICT,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE SET NULL,
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE
+);
+
+-- โค้ดที่ผู้ใช้ใส่ไว้ในตะกร้า
+CREATE TABLE IF NOT EXISTS cart_promotion_codes (
+    cart_id UUID NOT NULL,
+    promotion_id UUID NOT NULL,
+    added_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cart_id, promotion_id),
+    FOREIGN KEY (cart_id) REFERENCES carts(cart_id) ON DELETE CASCADE,
+    FOREIGN KEY (promotion_id) REFERENCES promotions(promotion_id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_promotions_updated_at BEFORE UPDATE ON promotions
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE INDEX IF NOT EXISTS idx_promotions_active ON promotions(starts_at, ends_at) WHERE is_active AND code IS NULL;
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_promotion_id ON promotion_redemptions(promotion_id, user_id);
+
+-- ============================================================
+-- ราคาลดตามช่วงเวลาและ flash sale
+-- ราคาลดมีผลเฉพาะระหว่าง starts_at ถึง ends_at จึงเริ่มและหมดอายุเองโดยไม่ต้องมีงานเบื้องหลัง
+-- flash sale คือราคาลดที่มี quantity_limit และจะหยุดเมื่อขายครบจำนวน
+-- ============================================================
+CREATE TABLE IF NOT EXISTS product_sales (
+    sale_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL,
+    sale_price NUMERIC(10, 2) NOT NULL CHECK (sale_price > 0),
+    starts_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ends_at TIMESTAMPTZ NOT NULL,
+    quantity_limit INTEGER CHECK (quantity_limit > 0), -- NULL คือราคาลดทั่วไปที่ไม่จำกัดจำนวน
+    sold_quantity INTEGER NOT NULL DEFAULT 0 CHECK (sold_quantity >= 0),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at),
+    CHECK (quantity_limit IS NULL OR sold_quantity <= quantity_limit),
+    FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+    -- สินค้าหนึ่งชิ้นมีราคาลดได้ครั้งละหนึ่งช่วงเวลา
+    EXCLUDE USING gist (product_id WITH =, tstzrange(starts_at, ends_at) WITH &&)
+);
+
+CREATE TRIGGER update_product_sales_updated_at BEFORE UPDATE ON product_sales
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE INDEX IF NOT EXISTS idx_product_sales_ends_at ON product_sales(ends_at);
+
+-- ราคาลดที่มีผล ณ เวลาที่ query ราคาลดที่ไม่ต่ำกว่าราคาปกติ (เช่น ผู้ขายลดราคาปกติลงภายหลัง) จะไม่แสดง
+CREATE OR REPLACE VIEW active_product_sales AS
+SELECT ps.sale_id, ps.product_id, ps.sale_price, ps.starts_at, ps.ends_at, ps.quantity_limit, ps.sold_quantity
+FROM product_sales ps
+JOIN products p ON p.product_id = ps.product_id
+WHERE ps.starts_at <= NOW() AND ps.ends_at > NOW()
+  AND (ps.quantity_limit IS NULL OR ps.sold_quantity < ps.quantity_limit)
+  AND ps.sale_price < p.price;
+
+-- ============================================================
+-- ค่าจัดส่งของแต่ละร้าน คำสั่งซื้อหนึ่งรายการอาจแยกส่งจากหลายร้าน
+-- ============================================================
+-- น้ำหนักและขนาดของสินค้า ใช้คำนวณค่าจัดส่ง NULL คือไม่ได้ระบุ
+ALTER TABLE products ADD COLUMN IF NOT EXISTS weight_grams INTEGER CHECK (weight_grams > 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS length_cm INTEGER CHECK (length_cm > 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS width_cm INTEGER CHECK (width_cm > 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS height_cm INTEGER CHECK (height_cm > 0);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'shipping_rate_type') THEN
+        CREATE TYPE shipping_rate_type AS ENUM ('flat', 'weight_tiers');
+    END IF;
+END$$;
+
+-- ร้านที่ยังไม่ตั้งค่าจะใช้ค่าจัดส่งแบบเหมาจ่ายตามค่าเริ่มต้นของระบบ
+CREATE TABLE IF NOT EXISTS shipping_profiles (
+    seller_id UUID PRIMARY KEY,
+    rate_type shipping_rate_type NOT NULL DEFAULT 'flat',
+    flat_rate NUMERIC(10, 2) CHECK (flat_rate >= 0),
+    additional_kg_rate NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (additional_kg_rate >= 0), -- ต่อกิโลกรัมที่เกินขั้นสูงสุด
+    free_shipping_threshold NUMERIC(10, 2) CHECK (free_shipping_threshold > 0), -- ส่งฟรีเมื่อยอดของร้านถึงเกณฑ์
+    dimensional_divisor INTEGER NOT NULL DEFAULT 5000 CHECK (dimensional_divisor > 0), -- น้ำหนักตามปริมาตร (กก.) = กว้าง x ยาว x สูง (ซม.) / ค่านี้
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CHECK (rate_type <> 'flat' OR flat_rate IS NOT NULL),
+    FOREIGN KEY (seller_id) REFERENCES sellers(seller_id) ON DELETE CASCADE
+);
+
+-- ขั้นน้ำหนักของ rate_type 'weight_tiers' ใช้ขั้นแรกที่ max_weight_grams ไม่น้อยกว่าน้ำหนักที่คิดค่าส่ง
+CREATE TABLE IF NOT EXISTS shipping_weight_tiers (
+    seller_id UUID NOT NULL,
+    max_weight_grams INTEGER NOT NULL CHECK (max_weight_grams > 0),
+    rate NUMERIC(10, 2) NOT NULL CHECK (rate >= 0),
+    PRIMARY KEY (seller_id, max_weight_grams),
+    FOREIGN KEY (seller_id) REFERENCES shipping_profiles(seller_id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_shipping_profiles_updated_at BEFORE UPDATE ON shipping_profiles
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+-- ============================================================
+-- สมุดที่อยู่ของผู้ใช้และการสั่งซื้อจากตะกร้า
+-- ============================================================
+CREATE TABLE IF NOT EXISTS user_addresses (
+    address_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    label VARCHAR(100), -- เช่น บ้าน ที่ทำงาน
+    recipient_name VARCHAR(255) NOT NULL,
+    phone VARCHAR(20) NOT NULL,
+    line1 VARCHAR(255) NOT NULL, -- บ้านเลขที่ หมู่ ซอย ถนน
+    line2 VARCHAR(255),
+    subdistrict VARCHAR(100) NOT NULL, -- ตำบล/แขวง
+    district VARCHAR(100) NOT NULL, -- อำเภอ/เขต
+    province VARCHAR(100) NOT NULL,
+    postcode CHAR(5) NOT NULL,
+    is_default_shipping BOOLEAN NOT NULL DEFAULT FALSE,
+    is_default_billing BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER update_user_addresses_updated_at BEFORE UPDATE ON user_addresses
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE INDEX IF NOT EXISTS idx_user_addresses_user_id ON user_addresses(user_id);
+-- ที่อยู่เริ่มต้นมีได้อย่างละหนึ่งรายการต่อผู้ใช้
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_addresses_default_shipping ON user_addresses(user_id) WHERE is_default_shipping;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_addresses_default_billing ON user_addresses(user_id) WHERE is_default_billing;
+
+-- คำสั่งซื้อเก็บสำเนาที่อยู่ ณ เวลาที่สั่งซื้อ การแก้ไขหรือลบที่อยู่ภายหลังจึงไม่กระทบคำสั่งซื้อเดิม
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_address JSONB;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS billing_address JSONB;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS subtotal NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (subtotal >= 0);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (discount_amount >= 0);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_amount NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (shipping_amount >= 0);
+
+-- คำสั่งซื้อเริ่มต้นเป็น pending และเปลี่ยนเป็น paid เมื่อยืนยันการชำระเงิน payment_reference ใช้กันการยืนยันซ้ำ
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS paid_at TIMESTAMPTZ;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS payment_reference VARCHAR(100);
+
+-- ค่าจัดส่งของแต่ละร้านในคำสั่งซื้อ
+CREATE TABLE IF NOT EXISTS order_shipments (
+    order_id UUID NOT NULL,
+    seller_id UUID NOT NULL,
+    weight_grams INTEGER NOT NULL DEFAULT 0,
+    shipping_cost NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (shipping_cost >= 0),
+    free_shipping_reason VARCHAR(20),
+    PRIMARY KEY (order_id, seller_id),
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE,
+    FOREIGN KEY (seller_id) REFERENCES sellers(seller_id) ON DELETE CASCADE
+);
+
+-- ============================================================
+-- ภาษีมูลค่าเพิ่ม (VAT) และใบกำกับภาษี
+-- ============================================================
+-- ราคาสินค้าและค่าจัดส่งเป็นราคารวม VAT แล้ว ร้านที่จดทะเบียน VAT จะแยกภาษีออกจากราคาในคำสั่งซื้อ
+ALTER TABLE sellers
+    ADD COLUMN IF NOT EXISTS vat_registered BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS tax_id CHAR(13), -- เลขประจำตัวผู้เสียภาษี 13 หลัก
+    ADD COLUMN IF NOT EXISTS tax_branch CHAR(5) NOT NULL DEFAULT '00000'; -- 00000 คือสำนักงานใหญ่
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'sellers_vat_tax_id_check') THEN
+        ALTER TABLE sellers ADD CONSTRAINT sellers_vat_tax_id_check CHECK (NOT vat_registered OR tax_id IS NOT NULL);
+    END IF;
+END$$;
+
+-- แยกส่วนลดและภาษีของแต่ละรายการ ณ เวลาที่สั่งซื้อ ยอดก่อนภาษี = unit_price * quantity - discount_amount - vat_amount
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (discount_amount >= 0),
+    ADD COLUMN IF NOT EXISTS vat_rate NUMERIC(5, 2) NOT NULL DEFAULT 0 CHECK (vat_rate >= 0),
+    ADD COLUMN IF NOT EXISTS vat_amount NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (vat_amount >= 0);
+ALTER TABLE order_shipments
+    ADD COLUMN IF NOT EXISTS vat_rate NUMERIC(5, 2) NOT NULL DEFAULT 0 CHECK (vat_rate >= 0),
+    ADD COLUMN IF NOT EXISTS vat_amount NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (vat_amount >= 0);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS vat_amount NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (vat_amount >= 0);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'invoice_document_type') THEN
+        CREATE TYPE invoice_document_type AS ENUM ('tax_invoice', 'receipt');
+    END IF;
+END$$;
+
+-- เลขที่เอกสารล่าสุดของแต่ละร้าน แยกตามประเภทเอกสารและปี (ตามเขตเวลาของร้าน) เพื่อให้เลขเรียงต่อกันไม่ข้าม
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    seller_id UUID NOT NULL,
+    document_type invoice_document_type NOT NULL,
+    year INTEGER NOT NULL,
+    last_number INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (seller_id, document_type, year),
+    FOREIGN KEY (seller_id) REFERENCES sellers(seller_id) ON DELETE CASCADE
+);
+
+-- ใบกำกับภาษี/ใบเสร็จรับเงิน หนึ่งใบต่อร้านต่อคำสั่งซื้อ เก็บสำเนาข้อมูลผู้ขาย ณ เวลาที่ออกเอกสาร
+CREATE TABLE IF NOT EXISTS tax_invoices (
+    invoice_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL,
+    seller_id UUID NOT NULL,
+    document_type invoice_document_type NOT NULL,
+    invoice_number VARCHAR(30) NOT NULL,
+    seller_name VARCHAR(255) NOT NULL,
+    seller_address VARCHAR(255),
+    seller_tax_id CHAR(13),
+    seller_tax_branch CHAR(5),
+    issued_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (order_id, seller_id),
+    UNIQUE (seller_id, invoice_number),
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE RESTRICT,
+    FOREIGN KEY (seller_id) REFERENCES sellers(seller_id) ON DELETE RESTRICT
+);
+
+-- ============================================================
+-- การคืนสินค้าและคืนเงิน (Returns / RMA)
+-- ============================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'return_status') THEN
+        CREATE TYPE return_status AS ENUM ('requested', 'approved', 'rejected', 'cancelled', 'received',
+                                           'refund_pending', 'refund_failed', 'refunded');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'return_reason') THEN
+        CREATE TYPE return_reason AS ENUM ('damaged', 'wrong_item', 'not_as_described', 'missing_parts', 'changed_mind', 'other');
+    END IF;
+END$$;
+
+-- คำขอคืนสินค้าหนึ่งรายการต่อหนึ่งบรรทัดของคำสั่งซื้อ ยอดคืนเงินคิดจากราคาหลังหักส่วนลด ณ เวลาที่ขอคืน
+CREATE TABLE IF NOT EXISTS return_requests (
+    return_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    order_id UUID NOT NULL,
+    order_item_id UUID NOT NULL,
+    user_id UUID,
+    seller_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    reason return_reason NOT NULL,
+    details TEXT,
+    status return_status NOT NULL DEFAULT 'requested',
+    refund_amount NUMERIC(12, 2) NOT NULL CHECK (refund_amount >= 0),
+    refund_reference VARCHAR(255), -- เลขอ้างอิงการคืนเงินจากผู้ให้บริการชำระเงิน
+    seller_note TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_id) REFERENCES orders(order_id) ON DELETE CASCADE,
+    FOREIGN KEY (order_item_id) REFERENCES order_items(order_item_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE SET NULL,
+    FOREIGN KEY (seller_id) REFERENCES sellers(seller_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS return_photos (
+    photo_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    return_id UUID NOT NULL,
+    photo_url VARCHAR(255) NOT NULL,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (return_id) REFERENCES return_requests(return_id) ON DELETE CASCADE
+);
+
+-- ประวัติการเปลี่ยนสถานะทุกครั้ง from_status เป็น NULL สำหรับการสร้างคำขอ
+CREATE TABLE IF NOT EXISTS return_status_history (
+    history_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    return_id UUID NOT NULL,
+    from_status return_status,
+    to_status return_status NOT NULL,
+    actor_user_id UUID,
+    note TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (return_id) REFERENCES return_requests(return_id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_user_id) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+CREATE TRIGGER update_return_requests_updated_at BEFORE UPDATE ON return_requests
+FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
+
+CREATE INDEX IF NOT EXISTS idx_return_requests_user_id ON return_requests(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_return_requests_seller_id ON return_requests(seller_id, status);
+CREATE INDEX IF NOT EXISTS idx_return_requests_order_item_id ON return_requests(order_item_id);
+CREATE INDEX IF NOT EXISTS idx_return_photos_return_id ON return_photos(return_id, sort_order);
+CREATE INDEX IF NOT EXISTS idx_return_status_history_return_id ON return_status_history(return_id, created_at);
+
+-- ============================================================
+-- การจัดส่งของแต่ละร้านและอีเมลแจ้งเตือน (transactional outbox)
+-- ============================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'shipment_status') THEN
+        CREATE TYPE shipment_status AS ENUM ('pending', 'shipped', 'delivered');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'email_status') THEN
+        CREATE TYPE email_status AS ENUM ('pending', 'sent', 'failed');
+    END IF;
+END$$;
+
+ALTER TABLE order_shipments
+    ADD COLUMN IF NOT EXISTS status shipment_status NOT NULL DEFAULT 'pending',
+    ADD COLUMN IF NOT EXISTS carrier VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS tracking_number VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS shipped_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMPTZ;
+
+-- ภาษาของอีเมลที่ผู้ใช้เลือกไว้ NULL คือยังไม่ได้เลือก อีเมลที่เกิดจาก request จะใช้ภาษาของ request ส่วน trigger ใช้ภาษาไทย
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(10);
+
+-- อีเมลที่รอส่ง เขียนใน transaction เดียวกับการเปลี่ยนแปลงที่เป็นต้นเหตุ แล้ว worker จะส่งออกไปภายหลัง
+-- payload คือข้อมูลสำหรับ template ของแต่ละประเภท
+CREATE TABLE IF NOT EXISTS email_outbox (
+    email_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    template VARCHAR(50) NOT NULL, -- เช่น 'order_confirmation', 'shipping_update', 'login_notice', 'low_stock'
+    locale VARCHAR(10) NOT NULL DEFAULT 'th',
+    recipient_email VARCHAR(255) NOT NULL,
+    recipient_name VARCHAR(255),
+    payload JSONB NOT NULL DEFAULT '{}',
+    status email_status NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_outbox_pending ON email_outbox(next_attempt_at) WHERE status = 'pending';
+
+-- แจ้งเตือนการเข้าสู่ระบบ ระบบยืนยันตัวตนบันทึก user_login_history เอง จึงเขียน outbox ด้วย trigger
+-- เพื่อให้อยู่ใน transaction เดียวกัน
+CREATE OR REPLACE FUNCTION enqueue_login_notice()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO email_outbox (template, locale, recipient_email, recipient_name, payload)
+    SELECT 'login_notice', COALESCE(u.locale, 'th'), u.email, u.full_name,
+           jsonb_build_object('name', u.full_name, 'signed_in_at', NEW.login_timestamp,
+                              'ip_address', host(NEW.ip_address), 'user_agent', NEW.user_agent)
+    FROM users u
+    WHERE u.user_id = NEW.user_id;
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE TRIGGER enqueue_login_notice AFTER INSERT ON user_login_history
+FOR EACH ROW WHEN (NEW.success) EXECUTE PROCEDURE enqueue_login_notice();
+
+-- แจ้งเตือนร้านเมื่อสต็อกลดลงถึงเกณฑ์ (ครั้งเดียวต่อการลดผ่านเกณฑ์ ไม่แจ้งซ้ำทุกคำสั่งซื้อ)
+-- ส่งถึงอีเมลของร้าน ถ้าไม่มีจะส่งถึงเจ้าของร้าน
+CREATE OR REPLACE FUNCTION enqueue_low_stock_warning()
+RETURNS TRIGGER AS $$
+DECLARE
+    low_stock_threshold CONSTANT INTEGER := 5;
+    target_product_id UUID;
+    target_sku VARCHAR(100);
+BEGIN
+    IF NEW.quantity > low_stock_threshold OR OLD.quantity <= low_stock_threshold THEN
+        RETURN NEW;
+    END IF;
+
+    IF TG_TABLE_NAME = 'variant_inventory' THEN
+        SELECT product_id, sku INTO target_product_id, target_sku
+        FROM product_variants WHERE variant_id = NEW.variant_id;
+    ELSE
+        target_product_id := NEW.product_id;
+    END IF;
+
+    INSERT INTO email_outbox (template, locale, recipient_email, recipient_name, payload)
+    SELECT 'low_stock', COALESCE(u.locale, 'th'), COALESCE(NULLIF(s.email, ''), u.email), s.name,
+           jsonb_build_object('shop_name', s.name, 'product_id', p.product_id, 'product_name', p.name,
+                              'sku', COALESCE(target_sku, p.sku), 'quantity', NEW.quantity, 'threshold', low_stock_threshold)
+    FROM products p
+    JOIN sellers s ON s.seller_id = p.seller_id
+    LEFT JOIN users u ON u.user_id = s.owner_user_id
+    WHERE p.product_id = target_product_id
+      AND COALESCE(NULLIF(s.email, ''), u.email) IS NOT NULL;
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE TRIGGER enqueue_inventory_low_stock AFTER UPDATE OF quantity ON inventory
+FOR EACH ROW EXECUTE PROCEDURE enqueue_low_stock_warning();
+
+CREATE TRIGGER enqueue_variant_inventory_low_stock AFTER UPDATE OF quantity ON variant_inventory
+FOR EACH ROW EXECUTE PROCEDURE enqueue_low_stock_warning();
+
+-- ============================================================
+-- Domain events (durable queue สำหรับ subscriber ภายในระบบ)
+-- ============================================================
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'domain_event_status') THEN
+        CREATE TYPE domain_event_status AS ENUM ('pending', 'processed', 'failed');
+    END IF;
+END$$;
+
+-- event ถูกเขียนใน transaction เดียวกับการเปลี่ยนแปลง แล้ว worker จะส่งต่อให้ subscriber ตามลำดับ occurred_at
+-- occurred_at ใช้ clock_timestamp() เพื่อให้หลาย event ใน transaction เดียวกันยังเรียงตามลำดับที่เกิด
+CREATE TABLE IF NOT EXISTS domain_events (
+    event_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_type VARCHAR(100) NOT NULL, -- เช่น 'product.price_changed', 'inventory.stock_depleted'
+    aggregate_id UUID NOT NULL, -- สินค้าหรือคำสั่งซื้อที่เกี่ยวข้อง
+    payload JSONB NOT NULL,
+    status domain_event_status NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp(),
+    processed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_events_pending ON domain_events(next_attempt_at, occurred_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_domain_events_aggregate_id ON domain_events(aggregate_id, occurred_at);
+
+-- สต็อกถูกเปลี่ยนจากหลายที่ (สั่งซื้อ คืนสินค้า นำเข้า แก้ไขตัวเลือก) จึงเขียน event ด้วย trigger
+-- ชื่อ event และ field ใน payload ตรงกับ StockChanged และ StockDepleted ใน internal/product/events.go
+CREATE OR REPLACE FUNCTION publish_stock_events()
+RETURNS TRIGGER AS $$
+DECLARE
+    old_quantity INTEGER := 0;
+    target_product_id UUID;
+    target_variant_id UUID;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_quantity := OLD.quantity;
+    END IF;
+    IF NEW.quantity = old_quantity THEN
+        RETURN NEW;
+    END IF;
+
+    IF TG_TABLE_NAME = 'variant_inventory' THEN
+        target_variant_id := NEW.variant_id;
+        SELECT product_id INTO target_product_id FROM product_variants WHERE variant_id = NEW.variant_id;
+    ELSE
+        target_product_id := NEW.product_id;
+    END IF;
+
+    INSERT INTO domain_events (event_type, aggregate_id, payload)
+    VALUES ('inventory.stock_changed', target_product_id,
+            jsonb_build_object('product_id', target_product_id, 'variant_id', target_variant_id,
+                               'old_quantity', old_quantity, 'new_quantity', NEW.quantity));
+
+    IF NEW.quantity = 0 THEN
+        INSERT INTO domain_events (event_type, aggregate_id, payload)
+        VALUES ('inventory.stock_depleted', target_product_id,
+                jsonb_build_object('product_id', target_product_id, 'variant_id', target_variant_id));
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE 'plpgsql';
+
+CREATE TRIGGER publish_inventory_events AFTER INSERT OR UPDATE OF quantity ON inventory
+FOR EACH ROW EXECUTE PROCEDURE publish_stock_events();
+
+CREATE TRIGGER publish_variant_inventory_events AFTER INSERT OR UPDATE OF quantity ON variant_inventory
+FOR EACH ROW EXECUTE PROCEDURE publish_stock_events();
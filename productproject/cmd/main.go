@@ -5,12 +5,19 @@ package main
 import (
 	"context"
 	"log"
+	"productproject/internal/catalog"
 	"productproject/internal/config"
+	"productproject/internal/events"
 	"productproject/internal/handlers"
+	"productproject/internal/imaging"
+	"productproject/internal/notification"
+	"productproject/internal/payment"
+	"productproject/internal/storage"
 
 	product "productproject/internal/product"
 
 	"time"
+	_ "time/tzdata" // ฝังฐานข้อมูล timezone ไว้ในไบนารี เพราะ image ขนาดเล็กอาจไม่มี /usr/share/zoneinfo
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -41,7 +48,75 @@ func main() {
 	}
 
 	store := product.NewStore(db)
-	h := handlers.NewProductHandlers(store)
+
+	defaultLocation, err := time.LoadLocation(cfg.AppTimezone)
+	if err != nil {
+		log.Fatalf("Invalid APP.TIMEZONE %q: %v", cfg.AppTimezone, err)
+	}
+
+	files, err := storage.New(storage.Config{
+		Driver:      cfg.StorageDriver,
+		LocalDir:    cfg.StorageLocalDir,
+		PublicURL:   cfg.StoragePublicURL,
+		S3Endpoint:  cfg.S3Endpoint,
+		S3Region:    cfg.S3Region,
+		S3Bucket:    cfg.S3Bucket,
+		S3AccessKey: cfg.S3AccessKey,
+		S3SecretKey: cfg.S3SecretKey,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
+	// ย่อรูปสินค้าที่อัปโหลดในเบื้องหลัง
+	images := imaging.NewProcessor(files, store, cfg.ImageWorkers, cfg.ImageQueueSize)
+	images.Start()
+	defer images.Close()
+
+	// นำเข้าสินค้าจากไฟล์ในเบื้องหลัง งานที่ค้างจากการรันครั้งก่อนจะถูกปิดเป็น failed
+	if db != nil {
+		if err := store.FailInterruptedImportJobs(context.Background()); err != nil {
+			log.Printf("Failed to close interrupted import jobs: %v", err)
+		}
+	}
+	importer := catalog.NewImporter(store, cfg.ImportQueueSize)
+	importer.Start()
+	defer importer.Close()
+
+	// ผู้ให้บริการชำระเงินสำหรับคืนเงิน
+	payments, err := payment.New(payment.Config{Driver: cfg.PaymentDriver})
+	if err != nil {
+		log.Fatalf("Failed to initialize payment gateway: %v", err)
+	}
+
+	// ส่งอีเมลจาก outbox ในเบื้องหลัง
+	sender, err := notification.NewSender(notification.Config{
+		Driver:   cfg.EmailDriver,
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize email sender: %v", err)
+	}
+	if db != nil {
+		mailer := notification.NewWorker(store, sender, defaultLocation)
+		mailer.Start()
+		defer mailer.Close()
+	}
+
+	// ส่ง domain event ให้ subscriber ในเบื้องหลัง ลงทะเบียน subscriber (เช่น index ค้นหา cache หรือ webhook)
+	// กับ dispatcher ด้วย dispatcher.Subscribe หรือ events.On ก่อนเริ่ม worker
+	dispatcher := events.NewDispatcher()
+	if db != nil {
+		relay := events.NewWorker(store, dispatcher)
+		relay.Start()
+		defer relay.Close()
+	}
+
+	h := handlers.NewProductHandlers(store, files, images, importer, payments)
 
 	go func() {
 		for {
@@ -65,7 +140,7 @@ func main() {
 	configCors := cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:4000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Session-ID", "X-Timezone", "Accept-Language"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -76,52 +151,183 @@ func main() {
 
 	r.GET("/health", h.HealthCheck)
 
-	// API v1
-	v1 := r.Group("/api/v1")
+	// ให้บริการไฟล์ที่อัปโหลดเมื่อใช้ storage แบบ local
+	if cfg.StorageDriver == "" || cfg.StorageDriver == "local" {
+		r.Static("/uploads", cfg.StorageLocalDir)
+	}
+
+	// API v1 ตรวจ path parameter ที่เป็น id ว่าเป็น UUID และเลือก timezone ของ response ก่อนทุกเส้นทาง
+	v1 := r.Group("/api/v1", handlers.ValidateUUIDParams(), handlers.Timezone(defaultLocation), handlers.Locale())
 	{
 		products := v1.Group("/products")
 		{
-			products.GET("", h.GetProducts)
+			products.GET("", h.OptionalAuth(), h.DisplayCurrency(), h.GetProducts)
 			products.POST("", h.AddProduct)
-			products.GET("/:id", h.GetProduct)
-			products.PUT("/:id", h.UpdateProduct)
+			products.GET("/:id", h.OptionalAuth(), h.DisplayCurrency(), h.GetProduct)
+			products.PUT("/:id", h.AuthRequired(), h.UpdateProduct)
 			products.DELETE("/:id", h.DeleteProduct)
 
+			// นำเข้าสินค้าจากไฟล์ CSV หรือ NDJSON
+			productImport := products.Group("/import", h.AuthRequired())
+			{
+				productImport.POST("", h.ImportProducts)
+				productImport.GET("/:job_id", h.GetImportJob)
+				productImport.GET("/:job_id/errors", h.GetImportJobErrors)
+			}
+			// ส่งออกแคตตาล็อกเป็น CSV, NDJSON หรือ XLSX
+			products.GET("/export", h.AuthRequired(), h.ExportProducts)
+
 			// แก้ไขเส้นทางสำหรับแนะนำสินค้า
 			recommendedProducts := products.Group("/Recommendproducts")
 			{
-				recommendedProducts.GET("", h.GetRecommendedProduct)
+				recommendedProducts.GET("", h.OptionalAuth(), h.DisplayCurrency(), h.GetRecommendedProduct)
 			}
 			// เส้นทางสำหรับแสดงสินค้าล่าสุดจากแต่ละ Seller
 			newProducts := products.Group("/Newproducts")
 			{
-				newProducts.GET("", h.GetNewProductSeller)
+				newProducts.GET("", h.OptionalAuth(), h.DisplayCurrency(), h.GetNewProductSeller)
 			}
 			// เส้นทางสำหรับแสดงสินค้าของผู้ขาย
 			sellerProducts := products.Group("/seller/:sellerID")
 			{
-				sellerProducts.GET("", h.GetDetailProductSeller)
+				sellerProducts.GET("", h.OptionalAuth(), h.DisplayCurrency(), h.GetDetailProductSeller)
 			}
 
-			// Nested resources - Images
+			// Nested resources - Images ดูได้ทุกคน แต่แก้ไขได้เฉพาะเจ้าของร้าน
 			images := products.Group("/:id/images")
 			{
-				images.GET("", h.GetProductImages)
-				images.POST("", h.AddProductImage)
-				images.PUT("/:image_id", h.UpdateProductImage)
-				images.DELETE("/:image_id", h.DeleteProductImage)
+				images.GET("", h.OptionalAuth(), h.GetProductImages)
+				images.POST("", h.AuthRequired(), h.AddProductImage)
+				images.PUT("/order", h.AuthRequired(), h.ReorderProductImages)
+				images.PUT("/:image_id", h.AuthRequired(), h.UpdateProductImage)
+				images.DELETE("/:image_id", h.AuthRequired(), h.DeleteProductImage)
+			}
+
+			// Nested resources - Variants ดูได้ทุกคน แต่แก้ไขได้เฉพาะเจ้าของร้าน
+			variants := products.Group("/:id/variants")
+			{
+				variants.GET("", h.OptionalAuth(), h.DisplayCurrency(), h.GetProductVariants)
+				variants.POST("", h.AuthRequired(), h.AddProductVariant)
+				variants.PUT("/options", h.AuthRequired(), h.SetVariantOptions)
+				variants.PUT("/:variant_id", h.AuthRequired(), h.UpdateProductVariant)
+				variants.DELETE("/:variant_id", h.AuthRequired(), h.DeleteProductVariant)
+			}
+
+			// ราคาลดตามช่วงเวลาและ flash sale ของสินค้า จัดการได้เฉพาะเจ้าของร้าน
+			sales := products.Group("/:id/sales", h.AuthRequired())
+			{
+				sales.GET("", h.GetProductSales)
+				sales.POST("", h.CreateProductSale)
+				sales.DELETE("/:sale_id", h.DeleteProductSale)
+			}
+
+			// Nested resources - Reviews
+			reviews := products.Group("/:id/reviews")
+			{
+				reviews.GET("", h.OptionalAuth(), h.GetProductReviews)
+				reviews.POST("", h.AuthRequired(), h.AddReview)
+				reviews.POST("/:review_id/reply", h.AuthRequired(), h.ReplyToReview)
+				reviews.POST("/:review_id/helpful", h.AuthRequired(), h.VoteReviewHelpful)
+				reviews.DELETE("/:review_id/helpful", h.AuthRequired(), h.UnvoteReviewHelpful)
 			}
 
 		}
-		v1.GET("/shops", h.GetAllShops)
+		v1.GET("/shops", h.OptionalAuth(), h.GetAllShops)
 		// เพิ่มเส้นทางสำหรับแสดงรายละเอียดของร้านค้า (Shop Detail)
-		v1.GET("/shops/:id", h.GetShopDetail)
+		v1.GET("/shops/:id", h.OptionalAuth(), h.GetShopDetail)
+		v1.GET("/shops/:id/shipping-profile", h.OptionalAuth(), h.GetShippingProfile)
+
+		// การสมัครเปิดร้านและส่งเอกสารยืนยันตัวตน
+		v1.POST("/seller-applications", h.AuthRequired(), h.ApplySeller)
+		shopOwner := v1.Group("/shops/:id", h.AuthRequired())
+		{
+			shopOwner.GET("/application", h.GetSellerApplication)
+			shopOwner.POST("/documents", h.SubmitSellerDocuments)
+			shopOwner.GET("/analytics", h.GetShopAnalytics)
+			shopOwner.PUT("/shipping-profile", h.SetShippingProfile)
+			shopOwner.GET("/tax-settings", h.GetSellerTaxSettings)
+			shopOwner.PUT("/tax-settings", h.SetSellerTaxSettings)
+			shopOwner.PUT("/orders/:order_id/shipment", h.UpdateShipment)
+			shopOwner.GET("/returns", h.GetShopReturnRequests)
+			shopOwner.GET("/returns/:return_id", h.GetShopReturnRequest)
+			shopOwner.POST("/returns/:return_id/review", h.ReviewReturnRequest)
+			shopOwner.POST("/returns/:return_id/refund", h.RefundReturnRequest)
+		}
+
+		// รายการสินค้าที่อยากได้ของผู้ใช้ที่เข้าสู่ระบบ
+		wishlists := v1.Group("/me/wishlists", h.AuthRequired())
+		{
+			wishlists.GET("", h.GetWishlists)
+			wishlists.POST("", h.CreateWishlist)
+			wishlists.GET("/:wishlist_id", h.GetWishlist)
+			wishlists.DELETE("/:wishlist_id", h.DeleteWishlist)
+			wishlists.POST("/:wishlist_id/items", h.AddWishlistItem)
+			wishlists.DELETE("/:wishlist_id/items/:product_id", h.RemoveWishlistItem)
+			wishlists.POST("/:wishlist_id/share", h.ShareWishlist)
+			wishlists.DELETE("/:wishlist_id/share", h.UnshareWishlist)
+		}
+		// ตะกร้าสินค้าของผู้ใช้ที่เข้าสู่ระบบ
+		cart := v1.Group("/me/cart", h.AuthRequired())
+		{
+			cart.GET("", h.GetCart)
+			cart.POST("/items", h.AddCartItem)
+			cart.PUT("/items/:item_id", h.UpdateCartItem)
+			cart.DELETE("/items/:item_id", h.RemoveCartItem)
+			cart.POST("/promotions", h.ApplyPromotionCode)
+			cart.DELETE("/promotions/:code", h.RemovePromotionCode)
+			cart.GET("/shipping", h.QuoteShipping)
+		}
+		// สมุดที่อยู่ การสั่งซื้อ และประวัติคำสั่งซื้อ
+		addresses := v1.Group("/me/addresses", h.AuthRequired())
+		{
+			addresses.GET("", h.GetAddresses)
+			addresses.POST("", h.CreateAddress)
+			addresses.GET("/:address_id", h.GetAddress)
+			addresses.PUT("/:address_id", h.UpdateAddress)
+			addresses.DELETE("/:address_id", h.DeleteAddress)
+		}
+		v1.POST("/me/checkout", h.AuthRequired(), h.Checkout)
+		orders := v1.Group("/me/orders", h.AuthRequired())
+		{
+			orders.GET("", h.GetOrders)
+			orders.GET("/:order_id", h.GetOrder)
+			orders.GET("/:order_id/invoices/:seller_id", h.GetTaxInvoice)
+			orders.POST("/:order_id/returns", h.CreateReturnRequest)
+		}
+		// คำขอคืนสินค้าของผู้ซื้อ
+		returns := v1.Group("/me/returns", h.AuthRequired())
+		{
+			returns.GET("", h.GetReturnRequests)
+			returns.GET("/:return_id", h.GetReturnRequest)
+			returns.POST("/:return_id/cancel", h.CancelReturnRequest)
+		}
+		v1.GET("/postcodes/:postcode", h.OptionalAuth(), h.LookupPostcode)
+
+		// ลิงก์แชร์แบบอ่านอย่างเดียว
+		v1.GET("/wishlists/shared/:token", h.OptionalAuth(), h.GetSharedWishlist)
+
+		// ผู้ดูแลระบบ
+		admin := v1.Group("/admin", h.AuthRequired(), h.AdminRequired())
+		{
+			admin.GET("/seller-applications", h.GetSellerReviewQueue)
+			admin.POST("/seller-applications/:id/review", h.ReviewSeller)
+			admin.PUT("/exchange-rates", h.SetExchangeRates)
+			admin.POST("/orders/:order_id/payment", h.ConfirmPayment)
+			admin.GET("/promotions", h.GetPromotions)
+			admin.POST("/promotions", h.CreatePromotion)
+			admin.GET("/promotions/:id", h.GetPromotion)
+			admin.PUT("/promotions/:id", h.UpdatePromotion)
+			admin.DELETE("/promotions/:id", h.DeletePromotion)
+		}
+
+		// อัตราแลกเปลี่ยนสำหรับแสดงราคา (query currency บนเส้นทางสินค้า)
+		v1.GET("/exchange-rates", h.OptionalAuth(), h.GetExchangeRates)
 
-		v1.GET("/images", h.GetAllProductImages)
+		v1.GET("/images", h.OptionalAuth(), h.GetAllProductImages)
 		// Categories
 		categories := v1.Group("/categories")
 		{
-			categories.GET("", h.GetCategories)
+			categories.GET("", h.OptionalAuth(), h.DisplayCurrency(), h.GetCategories)
 		}
 	}
 
@@ -0,0 +1,176 @@
+// export.go
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	product "productproject/internal/product"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// FormatXLSX ใช้ได้เฉพาะตอนส่งออก สำหรับเปิดดูใน Excel
+const FormatXLSX = "xlsx"
+
+// ExportColumns ขึ้นต้นด้วยคอลัมน์ของไฟล์นำเข้า แล้วตามด้วยคอลัมน์ที่มีไว้อ่านอย่างเดียว
+var ExportColumns = append(append([]string{}, ImportColumns...), "product_id", "category_name", "updated_at")
+
+var exportContentTypes = map[string]string{
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatNDJSON: "application/x-ndjson",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportContentType คืน Content-Type ของรูปแบบไฟล์ หรือสตริงว่างถ้าไม่รองรับ
+func ExportContentType(format string) string {
+	return exportContentTypes[format]
+}
+
+// Writer เขียนไฟล์ส่งออกทีละแถว ต้องเรียก Close เมื่อเขียนครบเพื่อ flush ข้อมูลที่เหลือ
+type Writer interface {
+	Write(p product.ExportProduct) error
+	Close() error
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(ExportColumns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer: cw}, nil
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return &ndjsonWriter{encoder: encoder}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// exportRecord เรียงค่าตาม ExportColumns ตัวเลขยังเป็นชนิดเดิมเพื่อให้ Excel คำนวณได้
+func exportRecord(p product.ExportProduct) []interface{} {
+	return []interface{}{
+		p.SKU, p.Name, p.Description, p.Brand, p.ModelNumber, p.Price, p.Availability, p.Recommendation,
+		p.ProductType, p.CategoryID, p.SellerID, p.Quantity, p.OptName, string(p.Values),
+		p.Dimensions.WeightGrams, p.Dimensions.LengthCm, p.Dimensions.WidthCm, p.Dimensions.HeightCm,
+		strings.Join(p.ImageURLs, ImageURLSeparator),
+		jsonCell(p.VariantOptions, len(p.VariantOptions)), jsonCell(p.Variants, len(p.Variants)),
+		jsonCell(p.Translations, len(p.Translations)),
+		p.ID, p.CategoryName, p.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// jsonCell เขียนค่าเป็น JSON ในเซลล์เดียว และเว้นว่างเมื่อไม่มีข้อมูลเพื่อให้อ่านไฟล์ได้ง่าย
+// สินค้าที่ไม่มีตัวเลือกจึงถูกนำเข้ากลับโดยไม่แตะตัวเลือกเดิม ซึ่งให้ผลเหมือนกันเมื่อนำเข้าไฟล์ที่เพิ่งส่งออก
+func jsonCell(v interface{}, n int) string {
+	if n == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvWriter) Write(p product.ExportProduct) error {
+	values := exportRecord(p)
+	record := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case string:
+			record[i] = v
+		case product.Money:
+			record[i] = v.String()
+		case int:
+			record[i] = strconv.Itoa(v)
+		}
+	}
+	return w.writer.Write(record)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(p product.ExportProduct) error {
+	return w.encoder.Encode(p)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
+
+// xlsxWriter ใช้ StreamWriter ของ excelize ซึ่งพักแถวไว้ในไฟล์ชั่วคราวแทนหน่วยความจำ
+// ไฟล์ xlsx เป็น zip จึงเริ่มส่งให้ผู้ใช้ได้ตอน Close เท่านั้น
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+const xlsxSheet = "Sheet1"
+
+func newXLSXWriter(out io.Writer) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to create xlsx sheet: %v", err)
+	}
+
+	header := make([]interface{}, len(ExportColumns))
+	for i, name := range ExportColumns {
+		header[i] = name
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write xlsx header: %v", err)
+	}
+
+	return &xlsxWriter{out: out, file: file, stream: stream, row: 1}, nil
+}
+
+func (w *xlsxWriter) Write(p product.ExportProduct) error {
+	w.row++
+	cell, err := excelize.CoordinatesToCellName(1, w.row)
+	if err != nil {
+		return err
+	}
+	values := exportRecord(p)
+	for i, v := range values {
+		// Excel เก็บตัวเลขเป็น double อยู่แล้ว จึงแปลงราคาเป็นตัวเลขเพื่อให้คำนวณในชีตได้
+		if m, ok := v.(product.Money); ok {
+			values[i], _ = strconv.ParseFloat(m.String(), 64)
+		}
+	}
+	return w.stream.SetRow(cell, values)
+}
+
+func (w *xlsxWriter) Close() error {
+	defer w.file.Close()
+	if err := w.stream.Flush(); err != nil {
+		return fmt.Errorf("failed to flush xlsx sheet: %v", err)
+	}
+	if _, err := w.file.WriteTo(w.out); err != nil {
+		return fmt.Errorf("failed to write xlsx file: %v", err)
+	}
+	return nil
+}
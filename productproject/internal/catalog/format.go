@@ -0,0 +1,227 @@
+// format.go
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	product "productproject/internal/product"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// Row คือสินค้าหนึ่งแถวในไฟล์นำเข้าและส่งออก ชื่อคอลัมน์ของ CSV ตรงกับ json tag ของ NDJSON
+// ยกเว้นน้ำหนักและขนาดที่ NDJSON อยู่ใน object "dimensions"
+type Row = product.CatalogProduct
+
+// ImportColumns คือคอลัมน์ที่ใช้ตอนนำเข้า คอลัมน์อื่นในไฟล์ (เช่นที่ได้จากการส่งออก) จะถูกข้ามไป
+// values, variant_options, variants และ translations เป็น JSON ในรูปแบบเดียวกับ NDJSON
+var ImportColumns = []string{
+	"sku", "name", "description", "brand", "model_number", "price", "availability", "recommendation",
+	"product_type", "category_id", "seller_id", "quantity", "optname", "values",
+	"weight_grams", "length_cm", "width_cm", "height_cm", "image_urls",
+	"variant_options", "variants", "translations",
+}
+
+// ImageURLSeparator ใช้คั่น URL หลายรูปในคอลัมน์ image_urls ของ CSV
+const ImageURLSeparator = "|"
+
+const maxNDJSONLine = 1 << 20
+
+// RowError คือข้อผิดพลาดของแถวเดียว ผู้อ่านยังอ่านแถวถัดไปต่อได้
+type RowError struct {
+	Err error
+}
+
+func (e *RowError) Error() string { return e.Err.Error() }
+
+// Reader อ่านไฟล์ทีละแถว คืน io.EOF เมื่อหมดไฟล์ และคืน *RowError เมื่อแถวนั้นอ่านไม่ได้
+type Reader interface {
+	Read() (Row, error)
+}
+
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxNDJSONLine)
+		return &ndjsonReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// CountRows นับจำนวนแถวข้อมูลเพื่อใช้แสดงความคืบหน้า
+func CountRows(format string, data []byte) (int, error) {
+	reader, err := NewReader(format, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for {
+		_, err := reader.Read()
+		if err == io.EOF {
+			return count, nil
+		}
+		var rowErr *RowError
+		if err != nil && !errors.As(err, &rowErr) {
+			return 0, err
+		}
+		count++
+	}
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("csv file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// ตัด BOM ที่ Excel ใส่ไว้หน้าคอลัมน์แรก
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	if _, ok := columns["sku"]; !ok {
+		return nil, fmt.Errorf("csv header must include a sku column")
+	}
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvReader) Read() (Row, error) {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return Row{}, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return Row{}, &RowError{Err: err}
+	}
+	if err != nil {
+		return Row{}, err
+	}
+
+	field := func(name string) string {
+		if i, ok := r.columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row := Row{NewProduct: product.NewProduct{
+		SKU:            field("sku"),
+		Name:           field("name"),
+		Description:    field("description"),
+		Brand:          field("brand"),
+		ModelNumber:    field("model_number"),
+		Availability:   field("availability"),
+		Recommendation: field("recommendation"),
+		ProductType:    field("product_type"),
+		SellerID:       field("seller_id"),
+		OptName:        field("optname"),
+	}}
+
+	if v := field("price"); v != "" {
+		if row.Price, err = product.ParseMoney(v, product.DefaultCurrency); err != nil {
+			return row, &RowError{Err: fmt.Errorf("invalid price %q", v)}
+		}
+	}
+	if v := field("category_id"); v != "" {
+		if row.CategoryID, err = strconv.Atoi(v); err != nil {
+			return row, &RowError{Err: fmt.Errorf("invalid category_id %q", v)}
+		}
+	}
+	for _, column := range []struct {
+		name string
+		dest *int
+	}{
+		{"quantity", &row.Quantity},
+		{"weight_grams", &row.Dimensions.WeightGrams},
+		{"length_cm", &row.Dimensions.LengthCm},
+		{"width_cm", &row.Dimensions.WidthCm},
+		{"height_cm", &row.Dimensions.HeightCm},
+	} {
+		if v := field(column.name); v != "" {
+			if *column.dest, err = strconv.Atoi(v); err != nil {
+				return row, &RowError{Err: fmt.Errorf("invalid %s %q", column.name, v)}
+			}
+		}
+	}
+	if v := field("values"); v != "" {
+		row.Values = json.RawMessage(v)
+	}
+	for _, column := range []struct {
+		name string
+		dest interface{}
+	}{
+		{"variant_options", &row.VariantOptions},
+		{"variants", &row.Variants},
+		{"translations", &row.Translations},
+	} {
+		if v := field(column.name); v != "" {
+			if err := json.Unmarshal([]byte(v), column.dest); err != nil {
+				return row, &RowError{Err: fmt.Errorf("invalid %s: %v", column.name, err)}
+			}
+		}
+	}
+	for _, url := range strings.Split(field("image_urls"), ImageURLSeparator) {
+		if url = strings.TrimSpace(url); url != "" {
+			row.ImageURLs = append(row.ImageURLs, url)
+		}
+	}
+
+	return row, nil
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *ndjsonReader) Read() (Row, error) {
+	for r.scanner.Scan() {
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var row Row
+		if err := json.Unmarshal(line, &row); err != nil {
+			return Row{}, &RowError{Err: fmt.Errorf("invalid json: %v", err)}
+		}
+		if string(row.Values) == "null" {
+			row.Values = nil
+		}
+		return row, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return Row{}, fmt.Errorf("failed to read ndjson: %v", err)
+	}
+	return Row{}, io.EOF
+}
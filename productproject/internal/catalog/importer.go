@@ -0,0 +1,184 @@
+// importer.go
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	product "productproject/internal/product"
+)
+
+const (
+	rowTimeout    = 10 * time.Second
+	flushInterval = 50 // บันทึกความคืบหน้าทุกๆ กี่แถว
+)
+
+// Job คือไฟล์ที่อัปโหลดแล้วและรอนำเข้า
+type Job struct {
+	ID       string
+	SellerID string
+	Format   string
+	DryRun   bool
+	Upsert   bool
+	Data     []byte
+}
+
+type ImportStore interface {
+	StartImportJob(ctx context.Context, jobID string, totalRows int) error
+	UpdateImportJobProgress(ctx context.Context, jobID string, progress product.ImportProgress, rowErrors []product.ImportJobError) error
+	FinishImportJob(ctx context.Context, jobID string, jobErr string) error
+	ImportProduct(ctx context.Context, row product.CatalogProduct, upsert, dryRun bool) (string, error)
+}
+
+// Importer นำเข้าไฟล์ทีละงานในเบื้องหลัง งานเดียวอาจมีหลายพันแถวจึงไม่ทำพร้อมกันหลายงาน
+type Importer struct {
+	store ImportStore
+	jobs  chan Job
+	wg    sync.WaitGroup
+}
+
+func NewImporter(store ImportStore, queueSize int) *Importer {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	return &Importer{
+		store: store,
+		jobs:  make(chan Job, queueSize),
+	}
+}
+
+func (im *Importer) Start() {
+	im.wg.Add(1)
+	go func() {
+		defer im.wg.Done()
+		for job := range im.jobs {
+			jobErr := ""
+			if err := im.run(job); err != nil {
+				log.Printf("failed to import job %s: %v", job.ID, err)
+				jobErr = err.Error()
+			}
+			if err := im.store.FinishImportJob(context.Background(), job.ID, jobErr); err != nil {
+				log.Printf("failed to finish import job %s: %v", job.ID, err)
+			}
+		}
+	}()
+}
+
+// Enqueue ไม่บล็อกผู้เรียก ถ้าคิวเต็มจะคืน false
+func (im *Importer) Enqueue(job Job) bool {
+	select {
+	case im.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close รอให้งานที่ค้างอยู่ในคิวทำเสร็จ ห้ามเรียก Enqueue หลังจากนี้
+func (im *Importer) Close() {
+	close(im.jobs)
+	im.wg.Wait()
+}
+
+func (im *Importer) run(job Job) error {
+	ctx := context.Background()
+
+	total, err := CountRows(job.Format, job.Data)
+	if err != nil {
+		return err
+	}
+	if err := im.store.StartImportJob(ctx, job.ID, total); err != nil {
+		return err
+	}
+
+	reader, err := NewReader(job.Format, bytes.NewReader(job.Data))
+	if err != nil {
+		return err
+	}
+
+	var progress product.ImportProgress
+	var rowErrors []product.ImportJobError
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *RowError
+		if err != nil && !errors.As(err, &rowErr) {
+			return err
+		}
+
+		progress.ProcessedRows++
+		action := ""
+		if err == nil {
+			action, err = im.importRow(ctx, job, row)
+		}
+		switch {
+		case err != nil:
+			progress.FailedCount++
+			rowErrors = append(rowErrors, product.ImportJobError{
+				Row:     progress.ProcessedRows,
+				SKU:     row.SKU,
+				Message: rowMessage(err),
+			})
+		case action == product.ImportActionUpdated:
+			progress.UpdatedCount++
+		default:
+			progress.CreatedCount++
+		}
+
+		if progress.ProcessedRows%flushInterval == 0 {
+			if err := im.store.UpdateImportJobProgress(ctx, job.ID, progress, rowErrors); err != nil {
+				return err
+			}
+			rowErrors = nil
+		}
+	}
+
+	return im.store.UpdateImportJobProgress(ctx, job.ID, progress, rowErrors)
+}
+
+// importRow ตรวจแถวด้วยกฎเดียวกับการเพิ่มสินค้าทีละชิ้น แล้วบันทึกลงฐานข้อมูล
+func (im *Importer) importRow(ctx context.Context, job Job, row Row) (string, error) {
+	if row.SellerID == "" {
+		row.SellerID = job.SellerID
+	}
+	if row.SellerID != job.SellerID {
+		return "", &product.Error{Kind: product.ErrValidation, Message: fmt.Sprintf("seller_id must be %s", job.SellerID)}
+	}
+	if err := row.Validate(); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rowTimeout)
+	defer cancel()
+	return im.store.ImportProduct(ctx, row, job.Upsert, job.DryRun)
+}
+
+// rowMessage คืนข้อความที่เขียนลงรายงานได้ ข้อผิดพลาดภายในระบบจะถูกบันทึกลง log แทน
+// เพราะผู้ขายดาวน์โหลดรายงานนี้ไปได้
+func rowMessage(err error) string {
+	var rowErr *RowError
+	if errors.As(err, &rowErr) {
+		return err.Error()
+	}
+	if e := product.Classify(err); e != nil {
+		if len(e.Fields) > 1 {
+			messages := make([]string, len(e.Fields))
+			for i, f := range e.Fields {
+				messages[i] = f.Field + " " + f.Message
+			}
+			return strings.Join(messages, "; ")
+		}
+		return e.Message
+	}
+	log.Printf("failed to import row: %v", err)
+	return "internal error"
+}
@@ -11,12 +11,36 @@ import (
 
 type Config struct {
 	AppPort          string
+	AppTimezone      string
 	DatabaseHost     string
 	DatabasePort     int
 	DatabaseUser     string
 	DatabasePassword string
 	DatabaseName     string
 	DatabaseSSLMode  string
+
+	StorageDriver    string
+	StorageLocalDir  string
+	StoragePublicURL string
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string
+
+	ImageWorkers   int
+	ImageQueueSize int
+
+	ImportQueueSize int
+
+	PaymentDriver string
+
+	EmailDriver  string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
 }
 
 func LoadConfig() (Config, error) {
@@ -25,22 +49,58 @@ func LoadConfig() (Config, error) {
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	// Set default values
+	viper.SetDefault("APP.TIMEZONE", "Asia/Bangkok")
 	viper.SetDefault("POSTGRES.HOST", "localhost")
 	viper.SetDefault("POSTGRES.PORT", 5432)
 	viper.SetDefault("POSTGRES.USER", "postgres")
 	viper.SetDefault("POSTGRES.PASSWORD", "")
 	viper.SetDefault("POSTGRES.DBNAME", "bookstore")
 	viper.SetDefault("POSTGRES.SSLMODE", "disable")
+	viper.SetDefault("STORAGE.DRIVER", "local")
+	viper.SetDefault("STORAGE.LOCAL_DIR", "./uploads")
+	viper.SetDefault("S3.REGION", "us-east-1")
+	viper.SetDefault("IMAGE.WORKERS", 2)
+	viper.SetDefault("IMAGE.QUEUE_SIZE", 100)
+	viper.SetDefault("IMPORT.QUEUE_SIZE", 10)
+	viper.SetDefault("PAYMENT.DRIVER", "manual")
+	viper.SetDefault("EMAIL.DRIVER", "smtp")
+	viper.SetDefault("SMTP.HOST", "localhost")
+	viper.SetDefault("SMTP.PORT", 1025)
+	viper.SetDefault("SMTP.FROM", "no-reply@localhost")
 
 	// Set config values
 	config := Config{
 		AppPort:          viper.GetString("APP.PORT"),
+		AppTimezone:      viper.GetString("APP.TIMEZONE"),
 		DatabaseHost:     viper.GetString("POSTGRES.HOST"),
 		DatabasePort:     viper.GetInt("POSTGRES.PORT"),
 		DatabaseUser:     viper.GetString("POSTGRES.USER"),
 		DatabasePassword: viper.GetString("POSTGRES.PASSWORD"),
 		DatabaseName:     viper.GetString("POSTGRES.DBNAME"),
 		DatabaseSSLMode:  viper.GetString("POSTGRES.SSLMODE"),
+
+		StorageDriver:    viper.GetString("STORAGE.DRIVER"),
+		StorageLocalDir:  viper.GetString("STORAGE.LOCAL_DIR"),
+		StoragePublicURL: viper.GetString("STORAGE.PUBLIC_URL"),
+		S3Endpoint:       viper.GetString("S3.ENDPOINT"),
+		S3Region:         viper.GetString("S3.REGION"),
+		S3Bucket:         viper.GetString("S3.BUCKET"),
+		S3AccessKey:      viper.GetString("S3.ACCESS_KEY"),
+		S3SecretKey:      viper.GetString("S3.SECRET_KEY"),
+
+		ImageWorkers:   viper.GetInt("IMAGE.WORKERS"),
+		ImageQueueSize: viper.GetInt("IMAGE.QUEUE_SIZE"),
+
+		ImportQueueSize: viper.GetInt("IMPORT.QUEUE_SIZE"),
+
+		PaymentDriver: viper.GetString("PAYMENT.DRIVER"),
+
+		EmailDriver:  viper.GetString("EMAIL.DRIVER"),
+		SMTPHost:     viper.GetString("SMTP.HOST"),
+		SMTPPort:     viper.GetInt("SMTP.PORT"),
+		SMTPUsername: viper.GetString("SMTP.USERNAME"),
+		SMTPPassword: viper.GetString("SMTP.PASSWORD"),
+		SMTPFrom:     viper.GetString("SMTP.FROM"),
 	}
 
 	return config, nil
@@ -0,0 +1,71 @@
+// dispatcher.go
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	product "productproject/internal/product"
+)
+
+// Handler จัดการ event หนึ่งรายการ ถ้าคืน error ทั้ง event จะถูกส่งใหม่ภายหลัง
+// รวมถึงให้ handler อื่นที่ทำสำเร็จไปแล้ว handler จึงต้องรับ event ซ้ำได้
+type Handler func(ctx context.Context, event product.Event) error
+
+// Dispatcher ส่ง event ให้ handler ที่ลงทะเบียนไว้ภายใน process เดียวกัน
+// ลงทะเบียนได้ทั้งตามชนิดของ event และแบบรับทุกชนิด (เช่น webhook หรือ audit log)
+type Dispatcher struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	all      []Handler
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe ลงทะเบียน handler ของ event ชนิด eventType เช่น product.EventStockDepleted
+func (d *Dispatcher) Subscribe(eventType string, handler Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[eventType] = append(d.handlers[eventType], handler)
+}
+
+// SubscribeAll ลงทะเบียน handler ที่รับ event ทุกชนิด
+func (d *Dispatcher) SubscribeAll(handler Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.all = append(d.all, handler)
+}
+
+// On ลงทะเบียน handler ที่รับ event เป็น type ที่ระบุ เช่น
+//
+//	events.On(d, func(ctx context.Context, e product.ProductPriceChanged) error { ... })
+func On[T product.Event](d *Dispatcher, handler func(ctx context.Context, event T) error) {
+	var zero T
+	d.Subscribe(zero.EventType(), func(ctx context.Context, event product.Event) error {
+		typed, ok := event.(T)
+		if !ok {
+			return fmt.Errorf("unexpected event %T for %s", event, zero.EventType())
+		}
+		return handler(ctx, typed)
+	})
+}
+
+// Dispatch เรียกทุก handler ของ event ตามลำดับที่ลงทะเบียน handler ที่ผิดพลาดไม่หยุด handler ถัดไป
+// error ที่คืนรวมข้อผิดพลาดของทุก handler
+func (d *Dispatcher) Dispatch(ctx context.Context, event product.Event) error {
+	d.mu.RLock()
+	handlers := append(append([]Handler(nil), d.handlers[event.EventType()]...), d.all...)
+	d.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
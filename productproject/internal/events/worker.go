@@ -0,0 +1,117 @@
+// worker.go
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	product "productproject/internal/product"
+)
+
+const (
+	pollInterval    = 2 * time.Second
+	batchSize       = 50
+	dispatchTimeout = 30 * time.Second
+	// claimLease ต้องนานกว่าเวลาส่งทั้ง batch ไม่เช่นนั้น event ที่กำลังส่งอาจถูก worker อื่นจองซ้ำ
+	claimLease = batchSize*dispatchTimeout + time.Minute
+)
+
+type Store interface {
+	ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]product.QueuedEvent, error)
+	MarkEventProcessed(ctx context.Context, eventID string) error
+	RetryEvent(ctx context.Context, eventID, lastError string, retryAfter time.Duration) error
+}
+
+// Worker อ่าน event จาก domain_events ตามลำดับที่เกิดแล้วส่งให้ Dispatcher ทีละรายการ
+// event ที่ส่งไม่สำเร็จจะถูกเลื่อนไปส่งใหม่ จนครบ product.MaxEventAttempts ครั้ง
+// event ที่ไม่มี subscriber จะถูกบันทึกว่าส่งแล้วทันที ลำดับของ event ที่ถูกส่งใหม่อาจมาหลัง event ที่เกิดทีหลัง
+type Worker struct {
+	store      Store
+	dispatcher *Dispatcher
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+func NewWorker(store Store, dispatcher *Dispatcher) *Worker {
+	return &Worker{
+		store:      store,
+		dispatcher: dispatcher,
+		stop:       make(chan struct{}),
+	}
+}
+
+func (w *Worker) Start() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			// ส่งต่อทันทีถ้า batch เต็ม เพราะอาจยังมี event ค้างอยู่
+			for w.dispatchBatch() == batchSize {
+				select {
+				case <-w.stop:
+					return
+				default:
+				}
+			}
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close รอให้ batch ที่กำลังส่งเสร็จก่อน event ที่เหลือจะถูกส่งเมื่อเริ่มระบบครั้งถัดไป
+func (w *Worker) Close() {
+	close(w.stop)
+	w.wg.Wait()
+}
+
+func (w *Worker) dispatchBatch() int {
+	queued, err := w.store.ClaimEvents(context.Background(), batchSize, claimLease)
+	if err != nil {
+		log.Printf("failed to claim events: %v", err)
+		return 0
+	}
+	for _, q := range queued {
+		w.dispatch(q)
+	}
+	return len(queued)
+}
+
+func (w *Worker) dispatch(q product.QueuedEvent) {
+	event, err := q.Event()
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), dispatchTimeout)
+		err = w.dispatcher.Dispatch(ctx, event)
+		cancel()
+	}
+
+	if err != nil {
+		log.Printf("failed to dispatch %s event %s (attempt %d): %v", q.Type, q.ID, q.Attempts, err)
+		if err := w.store.RetryEvent(context.Background(), q.ID, err.Error(), retryDelay(q.Attempts)); err != nil {
+			log.Printf("failed to reschedule event %s: %v", q.ID, err)
+		}
+		return
+	}
+	if err := w.store.MarkEventProcessed(context.Background(), q.ID); err != nil {
+		log.Printf("failed to mark event %s as processed: %v", q.ID, err)
+	}
+}
+
+// retryDelay เริ่มที่ 10 วินาทีและเพิ่มขึ้นเท่าตัวทุกครั้ง ไม่เกิน 1 ชั่วโมง
+func retryDelay(attempts int) time.Duration {
+	delay := 10 * time.Second
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *ProductHandlers) GetAddresses(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	addresses, err := h.store.GetAddresses(c.Request.Context(), user.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, addresses)
+}
+
+func (h *ProductHandlers) GetAddress(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	address, err := h.store.GetAddress(c.Request.Context(), user.UserID, c.Param("address_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, address)
+}
+
+func (h *ProductHandlers) CreateAddress(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	var address product.NewAddress
+	if !bindJSON(c, &address) {
+		return
+	}
+	if err := address.Validate(); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	created, err := h.store.CreateAddress(c.Request.Context(), user.UserID, address)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusCreated, created)
+}
+
+func (h *ProductHandlers) UpdateAddress(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	var address product.NewAddress
+	if !bindJSON(c, &address) {
+		return
+	}
+	if err := address.Validate(); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	updated, err := h.store.UpdateAddress(c.Request.Context(), user.UserID, c.Param("address_id"), address)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, updated)
+}
+
+func (h *ProductHandlers) DeleteAddress(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	if err := h.store.DeleteAddress(c.Request.Context(), user.UserID, c.Param("address_id")); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{"message": "address deleted successfully"})
+}
+
+// LookupPostcode คืนตำบล/แขวง อำเภอ/เขต และจังหวัดที่ใช้รหัสไปรษณีย์นี้ สำหรับเติมฟอร์มที่อยู่
+func (h *ProductHandlers) LookupPostcode(c *gin.Context) {
+	locations := product.LookupPostcode(c.Param("postcode"))
+	if len(locations) == 0 {
+		writeProblem(c, http.StatusNotFound, "postcode not found")
+		return
+	}
+
+	writeJSON(c, http.StatusOK, locations)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	product "productproject/internal/product"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const userContextKey = "user"
+
+// AuthRequired ตรวจสอบ Bearer token จาก header Authorization และเก็บข้อมูลผู้ใช้ไว้ใน context
+func (h *ProductHandlers) AuthRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c)
+		if token == "" {
+			writeProblem(c, http.StatusUnauthorized, "missing access token")
+			return
+		}
+
+		user, err := h.store.GetUserBySessionToken(c.Request.Context(), token)
+		if errors.Is(err, product.ErrNotFound) {
+			writeProblem(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		c.Set(userContextKey, user)
+		c.Next()
+	}
+}
+
+// OptionalAuth เก็บข้อมูลผู้ใช้ไว้ใน context ถ้ามี token ที่ถูกต้อง แต่ไม่บังคับให้เข้าสู่ระบบ
+func (h *ProductHandlers) OptionalAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := bearerToken(c); token != "" {
+			if user, err := h.store.GetUserBySessionToken(c.Request.Context(), token); err == nil {
+				c.Set(userContextKey, user)
+			}
+		}
+		c.Next()
+	}
+}
+
+// AdminRequired ต้องใช้หลัง AuthRequired เสมอ
+func (h *ProductHandlers) AdminRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := currentUser(c)
+		if !ok || user.Role != product.RoleAdmin {
+			writeProblem(c, http.StatusForbidden, "admin access required")
+			return
+		}
+		c.Next()
+	}
+}
+
+func currentUser(c *gin.Context) (product.User, bool) {
+	value, ok := c.Get(userContextKey)
+	if !ok {
+		return product.User{}, false
+	}
+	user, ok := value.(product.User)
+	return user, ok
+}
+
+// sessionID ใช้ระบุผู้เยี่ยมชมที่ยังไม่ได้เข้าสู่ระบบ
+func sessionID(c *gin.Context) string {
+	if id := c.GetHeader("X-Session-ID"); id != "" {
+		return id
+	}
+	return c.Query("session_id")
+}
+
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *ProductHandlers) GetCart(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	cart, err := h.store.GetCart(c.Request.Context(), user.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, cart)
+}
+
+func (h *ProductHandlers) AddCartItem(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	var item product.NewCartItem
+	if !bindJSON(c, &item) {
+		return
+	}
+	if item.ProductID == "" {
+		writeProblem(c, http.StatusBadRequest, "product_id is required")
+		return
+	}
+	if item.Quantity == 0 {
+		item.Quantity = 1
+	}
+	if item.Quantity < 0 {
+		writeProblem(c, http.StatusBadRequest, "quantity must be positive")
+		return
+	}
+
+	cart, err := h.store.AddCartItem(c.Request.Context(), user.UserID, item)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, cart)
+}
+
+func (h *ProductHandlers) UpdateCartItem(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	var update product.UpdateCartItem
+	if !bindJSON(c, &update) {
+		return
+	}
+	if update.Quantity <= 0 {
+		writeProblem(c, http.StatusBadRequest, "quantity must be positive")
+		return
+	}
+
+	cart, err := h.store.UpdateCartItem(c.Request.Context(), user.UserID, c.Param("item_id"), update)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, cart)
+}
+
+func (h *ProductHandlers) RemoveCartItem(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	cart, err := h.store.RemoveCartItem(c.Request.Context(), user.UserID, c.Param("item_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, cart)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	product "productproject/internal/product"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const currencyContextKey = "currency"
+
+// maxExchangeRateFileSize จำกัดขนาดไฟล์อัตราแลกเปลี่ยน ซึ่งมีเพียงไม่กี่สิบบรรทัด
+const maxExchangeRateFileSize = 1 << 20
+
+// DisplayCurrency อ่าน query currency และเก็บอัตราแลกเปลี่ยนไว้ใน context เพื่อให้ writeJSON แปลงราคา
+// ถ้าไม่ระบุหรือเป็นสกุลบาทจะแสดงราคาตามเดิม สกุลที่ยังไม่มีอัตราแลกเปลี่ยนจะตอบ 400
+func (h *ProductHandlers) DisplayCurrency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currency := strings.ToUpper(strings.TrimSpace(c.Query("currency")))
+		if currency == "" || currency == product.DefaultCurrency {
+			c.Next()
+			return
+		}
+
+		rate, err := h.store.GetExchangeRate(c.Request.Context(), currency)
+		if errors.Is(err, product.ErrNotFound) {
+			writeFieldProblem(c, http.StatusBadRequest, err.Error(), []product.FieldError{
+				{Field: "currency", Message: "is not supported"},
+			})
+			return
+		}
+		if err != nil {
+			writeError(c, err)
+			return
+		}
+
+		c.Set(currencyContextKey, rate)
+		c.Next()
+	}
+}
+
+func displayRate(c *gin.Context) (product.ExchangeRate, bool) {
+	value, ok := c.Get(currencyContextKey)
+	if !ok {
+		return product.ExchangeRate{}, false
+	}
+	rate, ok := value.(product.ExchangeRate)
+	return rate, ok
+}
+
+func (h *ProductHandlers) GetExchangeRates(c *gin.Context) {
+	rates, err := h.store.GetExchangeRates(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{"base_currency": product.DefaultCurrency, "rates": rates})
+}
+
+// SetExchangeRates รับ JSON {"rates": [...]} หรือไฟล์ CSV ที่มีคอลัมน์ currency และ rate
+// (ส่งเป็น body แบบ text/csv หรือ multipart field "file")
+func (h *ProductHandlers) SetExchangeRates(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	var request product.SetExchangeRates
+	if c.ContentType() == "application/json" {
+		if !bindJSON(c, &request) {
+			return
+		}
+	} else {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxExchangeRateFileSize)
+		data, _, _, err := readImportFile(c)
+		if err != nil {
+			writeProblem(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		request.Rates, err = readExchangeRatesCSV(data)
+		if err != nil {
+			writeProblem(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := product.ValidateStruct(request); err != nil {
+			writeError(c, err)
+			return
+		}
+	}
+
+	rates, err := h.store.SetExchangeRates(c.Request.Context(), request.Rates, user.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{"base_currency": product.DefaultCurrency, "rates": rates})
+}
+
+func readExchangeRatesCSV(data []byte) ([]product.ExchangeRate, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv header must include currency and rate columns")
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	currencyColumn, hasCurrency := columns["currency"]
+	rateColumn, hasRate := columns["rate"]
+	if !hasCurrency || !hasRate {
+		return nil, fmt.Errorf("csv header must include currency and rate columns")
+	}
+
+	var rates []product.ExchangeRate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %v", err)
+		}
+		if currencyColumn >= len(record) || rateColumn >= len(record) {
+			return nil, fmt.Errorf("line %d: missing currency or rate", line)
+		}
+		rates = append(rates, product.ExchangeRate{
+			Currency: strings.ToUpper(strings.TrimSpace(record[currencyColumn])),
+			Rate:     strings.TrimSpace(record[rateColumn]),
+		})
+	}
+	return rates, nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	product "productproject/internal/product"
+
+	"github.com/gin-gonic/gin"
+)
+
+// problem คือ body ของ error ตาม RFC 7807 (application/problem+json)
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Errors เป็น extension member สำหรับข้อผิดพลาดราย field
+	Errors []product.FieldError `json:"errors,omitempty"`
+}
+
+// errorStatuses จับคู่ชนิดของ error จาก store กับ HTTP status
+var errorStatuses = map[error]int{
+	product.ErrNotFound:   http.StatusNotFound,
+	product.ErrConflict:   http.StatusConflict,
+	product.ErrValidation: http.StatusBadRequest,
+	product.ErrForeignKey: http.StatusUnprocessableEntity,
+}
+
+// writeProblem ตอบ error และหยุด handler ที่เหลือในสาย
+func writeProblem(c *gin.Context, status int, detail string) {
+	writeFieldProblem(c, status, detail, nil)
+}
+
+func writeFieldProblem(c *gin.Context, status int, detail string, fields []product.FieldError) {
+	c.Header("Content-Type", "application/problem+json")
+	c.AbortWithStatusJSON(status, problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Errors:   fields,
+	})
+}
+
+// writeError แปลง error จาก store เป็น HTTP status ข้อผิดพลาดภายในระบบจะถูกบันทึกลง log
+// และตอบเพียง 500 โดยไม่ส่งรายละเอียดกลับไป
+func writeError(c *gin.Context, err error) {
+	if e := product.Classify(err); e != nil {
+		writeFieldProblem(c, errorStatuses[e.Kind], e.Message, e.Fields)
+		return
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		writeProblem(c, http.StatusGatewayTimeout, "request timed out")
+		return
+	}
+
+	log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	writeProblem(c, http.StatusInternalServerError, "")
+}
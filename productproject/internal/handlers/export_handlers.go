@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"productproject/internal/catalog"
+	product "productproject/internal/product"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// แคตตาล็อกขนาดใหญ่ใช้เวลาส่งออกนานกว่า timeout ของ request ทั่วไป
+const exportTimeout = 10 * time.Minute
+
+// ExportProducts ส่งออกแคตตาล็อกเป็นไฟล์ CSV, NDJSON หรือ XLSX โดยกรองได้เหมือน GET /products
+// ผู้ขายต้องระบุ seller_id ของร้านตัวเอง ส่วนผู้ดูแลระบบส่งออกทุกร้านได้
+func (h *ProductHandlers) ExportProducts(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	params, ok := productQueryParams(c)
+	if !ok {
+		return
+	}
+	if params.SellerID == "" && user.Role != product.RoleAdmin {
+		writeProblem(c, http.StatusBadRequest, "seller_id is required")
+		return
+	}
+	if params.SellerID != "" {
+		if _, ok := h.authorizeShopOwner(c, params.SellerID); !ok {
+			return
+		}
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", catalog.FormatCSV))
+	contentType := catalog.ExportContentType(format)
+	if contentType == "" {
+		writeProblem(c, http.StatusBadRequest, "format must be csv, ndjson or xlsx")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), exportTimeout)
+	defer cancel()
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="products-%s.%s"`, time.Now().Format("20060102"), format))
+	c.Status(http.StatusOK)
+
+	loc := requestLocation(c)
+	writer, err := catalog.NewWriter(format, c.Writer)
+	if err == nil {
+		err = h.store.ExportProducts(ctx, params, func(p product.ExportProduct) error {
+			p.UpdatedAt = p.UpdatedAt.In(loc)
+			return writer.Write(p)
+		})
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		// ถ้ายังไม่ได้ส่งข้อมูลออกไปยังตอบเป็น error ได้ ไม่เช่นนั้นทำได้เพียงตัดการเชื่อมต่อ
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Disposition")
+			c.Writer.Header().Del("Content-Type")
+			writeError(c, err)
+			return
+		}
+		log.Printf("failed to export products: %v", err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"productproject/internal/imaging"
+	product "productproject/internal/product"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxImageUploadSize = 5 << 20 // 5 MB
+
+// ชนิดไฟล์ที่อนุญาต ตรวจจากเนื้อหาไฟล์จริง ไม่ใช่จาก header ที่ผู้ใช้ส่งมา
+var allowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// uploadProductImage รับไฟล์รูปแบบ multipart/form-data (field 'image') แล้วเก็บผ่าน storage
+func (h *ProductHandlers) uploadProductImage(c *gin.Context, productID string) {
+	// เผื่อขนาดของ field อื่นในฟอร์มไว้ 1 MB
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageUploadSize+1<<20)
+
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeProblem(c, http.StatusRequestEntityTooLarge, "image is too large")
+			return
+		}
+		writeProblem(c, http.StatusBadRequest, "image file is required")
+		return
+	}
+	if fileHeader.Size > maxImageUploadSize {
+		writeProblem(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("image must not exceed %d MB", maxImageUploadSize>>20))
+		return
+	}
+
+	image := product.NewProductImage{AltText: c.PostForm("alt_text")}
+	if v := c.PostForm("is_primary"); v != "" {
+		if image.IsPrimary, err = strconv.ParseBool(v); err != nil {
+			writeProblem(c, http.StatusBadRequest, "Invalid is_primary value")
+			return
+		}
+	}
+	if v := c.PostForm("sort_order"); v != "" {
+		sortOrder, err := strconv.Atoi(v)
+		if err != nil {
+			writeProblem(c, http.StatusBadRequest, "Invalid sort_order value")
+			return
+		}
+		image.SortOrder = &sortOrder
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer file.Close()
+
+	// อ่าน 512 ไบต์แรกเพื่อตรวจชนิดไฟล์
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		writeProblem(c, http.StatusBadRequest, "failed to read image")
+		return
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		writeProblem(c, http.StatusUnsupportedMediaType, "image must be JPEG, PNG or WebP")
+		return
+	}
+
+	// เก็บทั้งไฟล์ไว้ในหน่วยความจำเพื่อส่งต่อให้ worker ย่อรูป (ขนาดถูกจำกัดไว้แล้ว)
+	data, err := io.ReadAll(io.MultiReader(bytes.NewReader(head), file))
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, "failed to read image")
+		return
+	}
+	if err := imaging.CheckDimensions(data); err != nil {
+		writeProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key, err := newImageKey(productID, ext)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	image.ImageURL, err = h.files.Put(c.Request.Context(), key, bytes.NewReader(data), int64(len(data)), contentType)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	createdImage, err := h.store.AddProductImage(c.Request.Context(), productID, image)
+	if err != nil {
+		// ลบไฟล์ที่อัปโหลดไปแล้วเมื่อบันทึกลงฐานข้อมูลไม่สำเร็จ
+		if delErr := h.files.Delete(c.Request.Context(), key); delErr != nil {
+			log.Printf("failed to remove orphaned upload %s: %v", key, delErr)
+		}
+		writeError(c, err)
+		return
+	}
+
+	// variants จะถูกเติมภายหลังเมื่อ worker ประมวลผลเสร็จ
+	job := imaging.Job{ProductID: productID, ImageID: createdImage.ID, Key: key, Data: data}
+	if !h.images.Enqueue(job) {
+		log.Printf("image processing queue is full, image %s will use the original file", createdImage.ID)
+	}
+
+	writeJSON(c, http.StatusCreated, createdImage)
+}
+
+type reorderImagesRequest struct {
+	ImageIDs []string `json:"image_ids" binding:"required"`
+}
+
+// ReorderProductImages จัดลำดับรูปทั้งหมดของสินค้าใหม่ตามลำดับใน image_ids
+func (h *ProductHandlers) ReorderProductImages(c *gin.Context) {
+	id := c.Param("id")
+	if !h.authorizeProductOwner(c, id) {
+		return
+	}
+
+	var req reorderImagesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	current, err := h.store.GetProductImages(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if !sameImageIDs(current, req.ImageIDs) {
+		writeProblem(c, http.StatusBadRequest, "image_ids must list every image of the product exactly once")
+		return
+	}
+
+	images, err := h.store.ReorderProductImages(c.Request.Context(), id, req.ImageIDs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, images)
+}
+
+func sameImageIDs(images []product.ProductImage, ids []string) bool {
+	if len(images) != len(ids) {
+		return false
+	}
+	remaining := make(map[string]bool, len(images))
+	for _, image := range images {
+		remaining[image.ID] = true
+	}
+	for _, id := range ids {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
+
+// removeStoredImage ลบไฟล์ต้นฉบับและไฟล์ทุกขนาดออกจาก storage ถ้าเป็นไฟล์ที่อัปโหลดผ่านระบบนี้
+func (h *ProductHandlers) removeStoredImage(c *gin.Context, image product.ProductImage) {
+	urls := []string{image.ImageURL}
+	for _, variant := range image.Variants {
+		urls = append(urls, variant.URL, variant.WebPURL)
+	}
+
+	for _, url := range urls {
+		key, ok := h.files.KeyFromURL(url)
+		if !ok {
+			continue
+		}
+		if err := h.files.Delete(c.Request.Context(), key); err != nil {
+			log.Printf("failed to delete stored image %s: %v", key, err)
+		}
+	}
+}
+
+func newImageKey(productID, ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate image key: %v", err)
+	}
+	return fmt.Sprintf("products/%s/%s%s", productID, hex.EncodeToString(b), ext), nil
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"productproject/internal/catalog"
+	product "productproject/internal/product"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxImportFileSize = 20 << 20 // 20 MB
+
+// ImportProducts รับไฟล์ CSV หรือ NDJSON (field 'file' ของ multipart หรือ body ทั้งก้อน)
+// แล้วสร้างงานนำเข้าในเบื้องหลัง ผู้เรียกดูความคืบหน้าได้จาก GET /products/import/:job_id
+func (h *ProductHandlers) ImportProducts(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	sellerID := c.Query("seller_id")
+	if sellerID == "" {
+		writeProblem(c, http.StatusBadRequest, "seller_id is required")
+		return
+	}
+	if _, ok := h.authorizeShopOwner(c, sellerID); !ok {
+		return
+	}
+
+	var err error
+	job := product.NewImportJob{SellerID: sellerID, UserID: user.UserID}
+	if v := c.Query("dry_run"); v != "" {
+		if job.DryRun, err = strconv.ParseBool(v); err != nil {
+			writeProblem(c, http.StatusBadRequest, "Invalid dry_run value")
+			return
+		}
+	}
+	if v := c.Query("upsert"); v != "" {
+		if job.Upsert, err = strconv.ParseBool(v); err != nil {
+			writeProblem(c, http.StatusBadRequest, "Invalid upsert value")
+			return
+		}
+	}
+
+	// เผื่อขนาดของ field อื่นในฟอร์มไว้ 1 MB
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize+1<<20)
+
+	data, filename, contentType, err := readImportFile(c)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeProblem(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file must not exceed %d MB", maxImportFileSize>>20))
+			return
+		}
+		writeProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(data) > maxImportFileSize {
+		writeProblem(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("file must not exceed %d MB", maxImportFileSize>>20))
+		return
+	}
+	if len(data) == 0 {
+		writeProblem(c, http.StatusBadRequest, "file is empty")
+		return
+	}
+
+	job.Format = importFormat(c.Query("format"), filename, contentType)
+	if job.Format == "" {
+		writeProblem(c, http.StatusBadRequest, "format must be csv or ndjson")
+		return
+	}
+
+	created, err := h.store.CreateImportJob(c.Request.Context(), job)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	queued := h.importer.Enqueue(catalog.Job{
+		ID:       created.ID,
+		SellerID: created.SellerID,
+		Format:   created.Format,
+		DryRun:   created.DryRun,
+		Upsert:   created.Upsert,
+		Data:     data,
+	})
+	if !queued {
+		if err := h.store.FinishImportJob(c.Request.Context(), created.ID, "import queue is full"); err != nil {
+			writeError(c, err)
+			return
+		}
+		writeProblem(c, http.StatusServiceUnavailable, "import queue is full, please try again later")
+		return
+	}
+
+	writeJSON(c, http.StatusAccepted, created)
+}
+
+func (h *ProductHandlers) GetImportJob(c *gin.Context) {
+	job, ok := h.authorizeImportJob(c)
+	if !ok {
+		return
+	}
+
+	writeJSON(c, http.StatusOK, job)
+}
+
+// GetImportJobErrors ส่งรายงานข้อผิดพลาดรายแถวเป็นไฟล์ CSV เพื่อให้แก้ไขแล้วนำเข้าใหม่ได้
+func (h *ProductHandlers) GetImportJobErrors(c *gin.Context) {
+	job, ok := h.authorizeImportJob(c)
+	if !ok {
+		return
+	}
+
+	rowErrors, err := h.store.GetImportJobErrors(c.Request.Context(), job.ID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%s-errors.csv"`, job.ID))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"row", "sku", "message"})
+	for _, e := range rowErrors {
+		w.Write([]string{strconv.Itoa(e.Row), e.SKU, e.Message})
+	}
+	w.Flush()
+}
+
+// authorizeImportJob ให้ดูงานได้เฉพาะผู้ที่สร้างงานหรือผู้ดูแลระบบ และส่ง response เองเมื่อไม่ผ่าน
+func (h *ProductHandlers) authorizeImportJob(c *gin.Context) (product.ImportJob, bool) {
+	user, _ := currentUser(c)
+
+	job, err := h.store.GetImportJob(c.Request.Context(), c.Param("job_id"))
+	if err != nil {
+		writeError(c, err)
+		return product.ImportJob{}, false
+	}
+
+	if user.Role != product.RoleAdmin && job.UserID != user.UserID {
+		writeProblem(c, http.StatusForbidden, "you do not own this import job")
+		return product.ImportJob{}, false
+	}
+
+	return job, true
+}
+
+// readImportFile อ่านไฟล์จาก field 'file' ถ้าเป็น multipart ไม่เช่นนั้นใช้ body ทั้งก้อน
+func readImportFile(c *gin.Context) ([]byte, string, string, error) {
+	contentType := c.ContentType()
+	if contentType != "multipart/form-data" {
+		data, err := io.ReadAll(c.Request.Body)
+		return data, "", contentType, err
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, "", "", err
+		}
+		return nil, "", "", fmt.Errorf("file is required")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to read file")
+	}
+
+	partType, _, _ := mime.ParseMediaType(fileHeader.Header.Get("Content-Type"))
+	return data, fileHeader.Filename, partType, nil
+}
+
+// importFormat เลือกรูปแบบไฟล์จากพารามิเตอร์ format ก่อน แล้วจึงดูจากนามสกุลไฟล์และ Content-Type
+func importFormat(format, filename, contentType string) string {
+	switch strings.ToLower(format) {
+	case catalog.FormatCSV:
+		return catalog.FormatCSV
+	case catalog.FormatNDJSON, "jsonl":
+		return catalog.FormatNDJSON
+	case "":
+	default:
+		return ""
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return catalog.FormatCSV
+	case ".ndjson", ".jsonl":
+		return catalog.FormatNDJSON
+	}
+
+	switch contentType {
+	case "text/csv", "application/csv":
+		return catalog.FormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/json":
+		return catalog.FormatNDJSON
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// localeMatcher เลือกภาษาที่ใกล้ที่สุดจาก Accept-Language ภาษาแรกใน product.Locales เป็นค่าเริ่มต้น
+var localeMatcher = newLocaleMatcher()
+
+func newLocaleMatcher() language.Matcher {
+	tags := make([]language.Tag, len(product.Locales))
+	for i, locale := range product.Locales {
+		tags[i] = language.MustParse(locale)
+	}
+	return language.NewMatcher(tags)
+}
+
+// Locale เลือกภาษาของเนื้อหาจาก query lang แล้วจึง header Accept-Language
+// ภาษาใน Accept-Language ที่ไม่รองรับจะใช้ภาษาเริ่มต้น แต่ lang ที่ไม่รองรับจะตอบ 400
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := product.DefaultLocale
+		if lang := strings.ToLower(c.Query("lang")); lang != "" {
+			if !containsLocale(lang) {
+				writeFieldProblem(c, http.StatusBadRequest, "unsupported language "+lang, []product.FieldError{
+					{Field: "lang", Message: "must be one of " + strings.Join(product.Locales, ", ")},
+				})
+				return
+			}
+			locale = lang
+		} else if header := c.GetHeader("Accept-Language"); header != "" {
+			tags, _, err := language.ParseAcceptLanguage(header)
+			if err == nil && len(tags) > 0 {
+				_, index, confidence := localeMatcher.Match(tags...)
+				if confidence != language.No {
+					locale = product.Locales[index]
+				}
+			}
+		}
+
+		c.Header("Content-Language", locale)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Request = c.Request.WithContext(product.WithLocale(c.Request.Context(), locale))
+		c.Next()
+	}
+}
+
+func containsLocale(locale string) bool {
+	for _, supported := range product.Locales {
+		if supported == locale {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Checkout สร้างคำสั่งซื้อจากตะกร้าของผู้ใช้ไปยังที่อยู่ที่บันทึกไว้
+func (h *ProductHandlers) Checkout(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	var checkout product.NewCheckout
+	if !bindJSON(c, &checkout) {
+		return
+	}
+
+	order, err := h.store.Checkout(c.Request.Context(), user.UserID, checkout)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusCreated, order)
+}
+
+func (h *ProductHandlers) GetOrders(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	orders, err := h.store.GetOrders(c.Request.Context(), user.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, orders)
+}
+
+func (h *ProductHandlers) GetOrder(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	order, err := h.store.GetOrder(c.Request.Context(), user.UserID, c.Param("order_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, order)
+}
+
+// ConfirmPayment ผู้ดูแลระบบยืนยันการชำระเงินของคำสั่งซื้อ เช่น เมื่อฝ่ายบัญชีตรวจยอดโอนแล้ว
+func (h *ProductHandlers) ConfirmPayment(c *gin.Context) {
+	var confirmation product.PaymentConfirmation
+	if !bindJSON(c, &confirmation) {
+		return
+	}
+
+	order, err := h.store.ConfirmPayment(c.Request.Context(), c.Param("order_id"), confirmation)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, order)
+}
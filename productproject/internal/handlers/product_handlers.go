@@ -1,65 +1,90 @@
 package handlers
 
 import (
-	"encoding/base64"
 	"log"
 	"net/http"
+	"productproject/internal/catalog"
+	"productproject/internal/imaging"
+	"productproject/internal/payment"
 	product "productproject/internal/product"
+	"productproject/internal/storage"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ProductHandlers struct {
-	store *product.Store
+	store    *product.Store
+	files    storage.Storage
+	images   *imaging.Processor
+	importer *catalog.Importer
+	payments payment.Gateway
 }
 
-func NewProductHandlers(store *product.Store) *ProductHandlers {
-	return &ProductHandlers{store: store}
-}
-
-func convertTimesToUserTimezone(product *product.ProductItem, loc *time.Location) {
-	product.CreatedAt = product.CreatedAt.In(loc)
-	product.UpdatedAt = product.UpdatedAt.In(loc)
-	product.Inventory.UpdatedAt = product.Inventory.UpdatedAt.In(loc)
-
-	for j := range product.Images {
-		product.Images[j].CreatedAt = product.Images[j].CreatedAt.In(loc)
-	}
+func NewProductHandlers(store *product.Store, files storage.Storage, images *imaging.Processor, importer *catalog.Importer, payments payment.Gateway) *ProductHandlers {
+	return &ProductHandlers{store: store, files: files, images: images, importer: importer, payments: payments}
 }
 
 func (h *ProductHandlers) GetProducts(c *gin.Context) {
 	limitStr := c.DefaultQuery("limit", "20")
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit value"})
+		writeProblem(c, http.StatusBadRequest, "Invalid limit value")
 		return
 	}
 
-	cursor := c.Query("cursor")
-	var decodedCursor string
-	if cursor != "" {
-		decodedCursor, err = decodeCursor(cursor)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
-			return
-		}
+	params, ok := productQueryParams(c)
+	if !ok {
+		return
+	}
+	// cursor เป็นค่าทึบที่ store สร้างให้ใน next_cursor ส่งต่อไปตรงๆ ให้ store ตรวจเอง
+	params.Cursor = c.Query("cursor")
+	params.Limit = limit
+
+	response, err := h.store.GetProducts(c.Request.Context(), params)
+	if err != nil {
+		writeError(c, err)
+		return
 	}
 
+	writeJSON(c, http.StatusOK, response)
+}
+
+// productQueryParams อ่านตัวกรองและการเรียงลำดับจาก query string (ไม่รวม cursor และ limit)
+// และส่ง response เองเมื่อค่าไม่ถูกต้อง
+func productQueryParams(c *gin.Context) (product.ProductQueryParams, bool) {
+	var err error
+
 	categoryIDStr := c.Query("category")
 	var categoryID int
 	if categoryIDStr != "" {
 		categoryID, err = strconv.Atoi(categoryIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
-			return
+			writeProblem(c, http.StatusBadRequest, "Invalid category ID")
+			return product.ProductQueryParams{}, false
+		}
+	}
+
+	var minRating float64
+	if minRatingStr := c.Query("min_rating"); minRatingStr != "" {
+		minRating, err = strconv.ParseFloat(minRatingStr, 64)
+		if err != nil || minRating < 0 || minRating > 5 {
+			writeProblem(c, http.StatusBadRequest, "Invalid min_rating value")
+			return product.ProductQueryParams{}, false
+		}
+	}
+
+	var onSale bool
+	if onSaleStr := c.Query("on_sale"); onSaleStr != "" {
+		onSale, err = strconv.ParseBool(onSaleStr)
+		if err != nil {
+			writeProblem(c, http.StatusBadRequest, "Invalid on_sale value")
+			return product.ProductQueryParams{}, false
 		}
 	}
 
-	params := product.ProductQueryParams{
-		Cursor:         decodedCursor,
-		Limit:          limit,
+	return product.ProductQueryParams{
 		Search:         c.Query("search"),
 		CategoryID:     categoryID,
 		SellerID:       c.Query("seller_id"),
@@ -68,42 +93,9 @@ func (h *ProductHandlers) GetProducts(c *gin.Context) {
 		ProductType:    c.Query("product_type"),
 		Sort:           c.Query("sort"),
 		Order:          c.Query("order"),
-	}
-
-	response, err := h.store.GetProducts(c.Request.Context(), params)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Encode the NextCursor before sending the response
-	if response.NextCursor != "" {
-		response.NextCursor = encodeCursor(response.NextCursor)
-	}
-
-	userTimezone := "Asia/Bangkok"
-	loc, err := time.LoadLocation(userTimezone)
-	if err != nil {
-		log.Fatal("ไม่สามารถโหลด timezone ได้:", err)
-	}
-
-	for i := range response.Items {
-		convertTimesToUserTimezone(&response.Items[i], loc)
-	}
-
-	c.JSON(http.StatusOK, response)
-}
-
-func encodeCursor(cursor string) string {
-	return base64.StdEncoding.EncodeToString([]byte(cursor))
-}
-
-func decodeCursor(s string) (string, error) {
-	b, err := base64.StdEncoding.DecodeString(s)
-	if err != nil {
-		return "", err
-	}
-	return string(b), nil
+		MinRating:      minRating,
+		OnSale:         onSale,
+	}, true
 }
 
 func (h *ProductHandlers) GetProduct(c *gin.Context) {
@@ -111,45 +103,48 @@ func (h *ProductHandlers) GetProduct(c *gin.Context) {
 
 	product, err := h.store.GetProduct(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
-	userTimezone := "Asia/Bangkok"
-	loc, err := time.LoadLocation(userTimezone)
-	if err != nil {
-		log.Fatal("ไม่สามารถโหลด timezone ได้:", err)
+	// บันทึกประวัติการดูสินค้าสำหรับระบบแนะนำสินค้า
+	user, _ := currentUser(c)
+	if user.UserID != "" || sessionID(c) != "" {
+		if err := h.store.RecordProductView(c.Request.Context(), id, user.UserID, sessionID(c)); err != nil {
+			log.Printf("failed to record product view: %v", err)
+		}
 	}
 
-	convertTimesToUserTimezone(&product, loc)
-
-	c.JSON(http.StatusOK, product)
+	writeJSON(c, http.StatusOK, product)
 }
 
 func (h *ProductHandlers) AddProduct(c *gin.Context) {
 	var product product.NewProduct
-	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &product) {
+		return
+	}
+	if err := product.Validate(); err != nil {
+		writeError(c, err)
 		return
 	}
 
 	createdProduct, err := h.store.AddProduct(c.Request.Context(), product)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdProduct)
+	writeJSON(c, http.StatusCreated, createdProduct)
 }
 
 func (h *ProductHandlers) GetAllProductImages(c *gin.Context) {
 	images, err := h.store.GetAllProductImages(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, images)
+	writeJSON(c, http.StatusOK, images)
 }
 
 func (h *ProductHandlers) GetProductImages(c *gin.Context) {
@@ -157,89 +152,118 @@ func (h *ProductHandlers) GetProductImages(c *gin.Context) {
 
 	images, err := h.store.GetProductImages(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, images)
+	writeJSON(c, http.StatusOK, images)
 }
 
 func (h *ProductHandlers) AddProductImage(c *gin.Context) {
 	id := c.Param("id") // รับค่า id เป็น string
+	if !h.authorizeProductOwner(c, id) {
+		return
+	}
+
+	// อัปโหลดไฟล์รูปภาพโดยตรง
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		h.uploadProductImage(c, id)
+		return
+	}
 
 	var image product.NewProductImage
-	if err := c.ShouldBindJSON(&image); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &image) {
 		return
 	}
 
 	createdImage, err := h.store.AddProductImage(c.Request.Context(), id, image)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdImage)
+	writeJSON(c, http.StatusCreated, createdImage)
 }
 
 func (h *ProductHandlers) UpdateProduct(c *gin.Context) {
 	id := c.Param("id")
+	if !h.authorizeProductOwner(c, id) {
+		return
+	}
 
 	var update product.UpdateProduct
-	if err := c.ShouldBindJSON(&update); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &update) {
 		return
 	}
 
 	updatedProduct, err := h.store.UpdateProduct(c.Request.Context(), id, update)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedProduct)
+	writeJSON(c, http.StatusOK, updatedProduct)
 }
 
 func (h *ProductHandlers) DeleteProduct(c *gin.Context) {
 	id := c.Param("id")
 
 	if err := h.store.DeleteProduct(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "product deleted successfully"})
+	writeJSON(c, http.StatusOK, gin.H{"message": "product deleted successfully"})
 }
 
 func (h *ProductHandlers) UpdateProductImage(c *gin.Context) {
 	id := c.Param("id")            // รับค่า id เป็น string
 	imageID := c.Param("image_id") // รับค่า imageID เป็น string
+	if !h.authorizeProductOwner(c, id) {
+		return
+	}
 
 	var update product.UpdateProductImage
-	if err := c.ShouldBindJSON(&update); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &update) {
 		return
 	}
 
 	updatedImage, err := h.store.UpdateProductImage(c.Request.Context(), id, imageID, update)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedImage)
+	writeJSON(c, http.StatusOK, updatedImage)
 }
 
 func (h *ProductHandlers) DeleteProductImage(c *gin.Context) {
 	id := c.Param("id")            // รับค่า id เป็น string
 	imageID := c.Param("image_id") // รับค่า imageID เป็น string
+	if !h.authorizeProductOwner(c, id) {
+		return
+	}
+
+	// เก็บข้อมูลรูปไว้ก่อนลบ เพื่อลบไฟล์ที่อัปโหลดไว้ใน storage ด้วย
+	var stored *product.ProductImage
+	if images, err := h.store.GetProductImages(c.Request.Context(), id); err == nil {
+		for i := range images {
+			if images[i].ID == imageID {
+				stored = &images[i]
+			}
+		}
+	}
 
 	if err := h.store.DeleteProductImage(c.Request.Context(), id, imageID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "ProductImage deleted successfully"})
+	if stored != nil {
+		h.removeStoredImage(c, *stored)
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{"message": "ProductImage deleted successfully"})
 }
 
 func (h *ProductHandlers) GetDetailProductSeller(c *gin.Context) {
@@ -250,39 +274,83 @@ func (h *ProductHandlers) GetDetailProductSeller(c *gin.Context) {
 	products, err := h.store.GetDetailProductSeller(c.Request.Context(), sellerID)
 	if err != nil {
 		// ถ้ามีข้อผิดพลาดเกิดขึ้นให้ส่ง error กลับ
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
 	// ตรวจสอบว่าไม่มีสินค้าสำหรับผู้ขายนี้
 	if len(products) == 0 {
 		// ถ้าไม่มีสินค้า ส่งข้อความว่าไม่มีสินค้า
-		c.JSON(http.StatusOK, gin.H{"message": "No products available for this seller"})
+		writeJSON(c, http.StatusOK, gin.H{"message": "No products available for this seller"})
 		return
 	}
 
 	// ส่งข้อมูลสินค้าของผู้ขายในรูปแบบ JSON
-	c.JSON(http.StatusOK, products)
+	writeJSON(c, http.StatusOK, products)
 }
 
 func (h *ProductHandlers) GetRecommendedProduct(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "3"))
+	if err != nil || limit < 1 || limit > product.MaxRecommendationLimit {
+		writeProblem(c, http.StatusBadRequest, "Invalid limit value")
+		return
+	}
+
+	var categoryID int
+	if categoryIDStr := c.Query("category"); categoryIDStr != "" {
+		categoryID, err = strconv.Atoi(categoryIDStr)
+		if err != nil {
+			writeProblem(c, http.StatusBadRequest, "Invalid category ID")
+			return
+		}
+	}
+
+	productID, ok := uuidQuery(c, "product_id")
+	if !ok {
+		return
+	}
+
+	req := product.RecommendationRequest{
+		SessionID:  sessionID(c),
+		ProductID:  productID,
+		CategoryID: categoryID,
+		Limit:      limit,
+	}
+
+	// user_id ใช้ได้เฉพาะของผู้ใช้ที่เข้าสู่ระบบอยู่เอง (หรือผู้ดูแลระบบ)
+	user, loggedIn := currentUser(c)
+	if loggedIn {
+		req.UserID = user.UserID
+	}
+	userID, ok := uuidQuery(c, "user_id")
+	if !ok {
+		return
+	}
+	if userID != "" {
+		if !loggedIn || (userID != user.UserID && user.Role != product.RoleAdmin) {
+			writeProblem(c, http.StatusForbidden, "cannot get recommendations for another user")
+			return
+		}
+		req.UserID = userID
+	}
+
 	// เรียกใช้ GetRecommendedProduct จาก store
-	products, err := h.store.GetRecommendedProduct(c.Request.Context())
+	products, err := h.store.GetRecommendedProduct(c.Request.Context(), req)
 	if err != nil {
 		// ถ้ามีข้อผิดพลาดเกิดขึ้นให้ส่ง error กลับ
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
 	// ตรวจสอบว่าไม่มีสินค้าแนะนำ
 	if len(products) == 0 {
 		// ถ้าไม่มีสินค้าแนะนำ ส่งข้อความว่าไม่มีสินค้าแนะนำ
-		c.JSON(http.StatusOK, gin.H{"message": "No recommended products available"})
+		writeJSON(c, http.StatusOK, gin.H{"message": "No recommended products available"})
 		return
 	}
 
-	// ส่งข้อมูลสินค้าที่แนะนำ 3 รายการกลับไปในรูปแบบ JSON
-	c.JSON(http.StatusOK, products)
+	// ส่งข้อมูลสินค้าที่แนะนำกลับไปในรูปแบบ JSON
+	writeJSON(c, http.StatusOK, products)
 }
 
 func (h *ProductHandlers) GetShopDetail(c *gin.Context) {
@@ -293,19 +361,19 @@ func (h *ProductHandlers) GetShopDetail(c *gin.Context) {
 	shopDetail, err := h.store.GetShopDetail(c.Request.Context(), sellerID)
 	if err != nil {
 		// ถ้ามีข้อผิดพลาดเกิดขึ้นให้ส่ง error กลับ
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
 	// ตรวจสอบว่าร้านค้านี้ไม่มีข้อมูล
 	if shopDetail.SellerID == "" {
 		// ถ้าไม่มีข้อมูลร้านค้า ส่งข้อความว่าร้านค้าไม่พบ
-		c.JSON(http.StatusNotFound, gin.H{"message": "Shop not found"})
+		writeJSON(c, http.StatusNotFound, gin.H{"message": "Shop not found"})
 		return
 	}
 
 	// ส่งข้อมูลร้านค้ากลับไปในรูปแบบ JSON
-	c.JSON(http.StatusOK, shopDetail)
+	writeJSON(c, http.StatusOK, shopDetail)
 }
 
 func (h *ProductHandlers) GetAllShops(c *gin.Context) {
@@ -313,19 +381,19 @@ func (h *ProductHandlers) GetAllShops(c *gin.Context) {
 	shops, err := h.store.GetAllShops(c.Request.Context())
 	if err != nil {
 		// ถ้ามีข้อผิดพลาดเกิดขึ้นให้ส่ง error กลับ
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
 	// ตรวจสอบว่าไม่มีร้านค้า
 	if len(shops) == 0 {
 		// ถ้าไม่มีร้านค้า ส่งข้อความว่าไม่มีข้อมูลร้านค้า
-		c.JSON(http.StatusNotFound, gin.H{"message": "No shops available"})
+		writeJSON(c, http.StatusNotFound, gin.H{"message": "No shops available"})
 		return
 	}
 
 	// ส่งข้อมูลร้านค้าทั้งหมดกลับไปในรูปแบบ JSON
-	c.JSON(http.StatusOK, shops)
+	writeJSON(c, http.StatusOK, shops)
 }
 
 func (h *ProductHandlers) GetNewProductSeller(c *gin.Context) {
@@ -333,26 +401,26 @@ func (h *ProductHandlers) GetNewProductSeller(c *gin.Context) {
 	products, err := h.store.GetNewProductSeller(c.Request.Context())
 	if err != nil {
 		// ถ้ามีข้อผิดพลาดเกิดขึ้นให้ส่ง error กลับ
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
 	// ตรวจสอบว่าไม่มีสินค้าใหม่จากแต่ละ Seller
 	if len(products) == 0 {
 		// ถ้าไม่มีสินค้าใหม่จากแต่ละ Seller ส่งข้อความว่าไม่มีสินค้า
-		c.JSON(http.StatusOK, gin.H{"message": "No new products available from sellers"})
+		writeJSON(c, http.StatusOK, gin.H{"message": "No new products available from sellers"})
 		return
 	}
 
 	// ส่งข้อมูลสินค้าล่าสุดจากแต่ละ Seller กลับไปในรูปแบบ JSON
-	c.JSON(http.StatusOK, products)
+	writeJSON(c, http.StatusOK, products)
 }
 
 func (h *ProductHandlers) GetCategories(c *gin.Context) {
 	// ดึงรายการหมวดหมู่พร้อมสินค้าจากฐานข้อมูล
 	categoryWithProducts, err := h.store.GetCategories(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
@@ -363,9 +431,9 @@ func (h *ProductHandlers) GetCategories(c *gin.Context) {
 	}
 
 	// ส่งข้อมูลหมวดหมู่พร้อมสินค้ากลับไปในรูปแบบ JSON
-	c.JSON(http.StatusOK, categories)
+	writeJSON(c, http.StatusOK, categories)
 }
 
 func (h *ProductHandlers) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	writeJSON(c, http.StatusOK, gin.H{"status": "healthy"})
 }
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+
+	"github.com/gin-gonic/gin"
+)
+
+type applyPromotionCodeRequest struct {
+	Code string `json:"code" binding:"notblank"`
+}
+
+func (h *ProductHandlers) GetPromotions(c *gin.Context) {
+	promotions, err := h.store.GetPromotions(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, promotions)
+}
+
+func (h *ProductHandlers) GetPromotion(c *gin.Context) {
+	promotion, err := h.store.GetPromotion(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, promotion)
+}
+
+func (h *ProductHandlers) CreatePromotion(c *gin.Context) {
+	var promotion product.NewPromotion
+	if !bindJSON(c, &promotion) {
+		return
+	}
+	if err := promotion.Validate(); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	created, err := h.store.CreatePromotion(c.Request.Context(), promotion)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusCreated, created)
+}
+
+func (h *ProductHandlers) UpdatePromotion(c *gin.Context) {
+	var promotion product.NewPromotion
+	if !bindJSON(c, &promotion) {
+		return
+	}
+	if err := promotion.Validate(); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	updated, err := h.store.UpdatePromotion(c.Request.Context(), c.Param("id"), promotion)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, updated)
+}
+
+func (h *ProductHandlers) DeletePromotion(c *gin.Context) {
+	if err := h.store.DeletePromotion(c.Request.Context(), c.Param("id")); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{"message": "promotion deleted successfully"})
+}
+
+func (h *ProductHandlers) ApplyPromotionCode(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	var request applyPromotionCodeRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	cart, err := h.store.ApplyPromotionCode(c.Request.Context(), user.UserID, request.Code)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, cart)
+}
+
+func (h *ProductHandlers) RemovePromotionCode(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	cart, err := h.store.RemovePromotionCode(c.Request.Context(), user.UserID, c.Param("code"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, cart)
+}
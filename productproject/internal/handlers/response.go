@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	product "productproject/internal/product"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeJSON ตอบ JSON โดยแปลงเวลาทุก field ใน obj เป็น timezone ของ request
+// และแปลงราคาเป็นสกุลเงินที่ขอ เฉพาะเส้นทางที่ใช้ DisplayCurrency
+func writeJSON(c *gin.Context, status int, obj any) {
+	loc := requestLocation(c)
+	rate, convert := displayRate(c)
+
+	c.JSON(status, rewriteValues(obj, func(v reflect.Value) bool {
+		switch v.Type() {
+		case timeType:
+			if t := v.Interface().(time.Time); !t.IsZero() {
+				v.Set(reflect.ValueOf(t.In(loc)))
+			}
+			return true
+		case moneyType:
+			// ราคาที่แปลงไม่ได้ (เช่น ไม่ใช่สกุลบาท) แสดงตามเดิม
+			if convert {
+				if converted, err := v.Interface().(product.Money).Convert(rate); err == nil {
+					v.Set(reflect.ValueOf(converted))
+				}
+			}
+			return true
+		}
+		return false
+	}))
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	moneyType = reflect.TypeOf(product.Money{})
+)
+
+// rewriteValues คืนสำเนาของ obj หลังเรียก rewrite กับทุก struct ที่แก้ไขได้ (รวมถึงใน slice, map และ pointer)
+// rewrite คืน true เมื่อจัดการค่านั้นแล้วเพื่อไม่ให้เดินเข้าไปใน field ของมันอีก
+// field ที่มี tag timezone:"shop" เป็นเวลาตาม timezone ของร้าน (เช่น ช่วงเวลาของรายงานยอดขาย) จะไม่ถูกแก้
+// slice และ pointer ยังชี้ข้อมูลชุดเดิม ผู้เรียกจึงไม่ควรใช้ obj ต่อหลังจากนี้
+func rewriteValues(obj any, rewrite func(reflect.Value) bool) any {
+	if obj == nil {
+		return nil
+	}
+	v := reflect.New(reflect.TypeOf(obj)).Elem()
+	v.Set(reflect.ValueOf(obj))
+	rewriteValue(v, rewrite)
+	return v.Interface()
+}
+
+func rewriteValue(v reflect.Value, rewrite func(reflect.Value) bool) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.CanSet() && rewrite(v) {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Tag.Get("timezone") == "shop" {
+				continue
+			}
+			if field := v.Field(i); field.CanSet() {
+				rewriteValue(field, rewrite)
+			}
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			rewriteValue(v.Elem(), rewrite)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			rewriteValue(v.Index(i), rewrite)
+		}
+	case reflect.Interface, reflect.Map:
+		// ค่าใน interface และ map แก้ตรงๆ ไม่ได้ ต้องคัดลอกออกมาแก้แล้วใส่กลับ
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Interface {
+			if v.CanSet() {
+				v.Set(reflect.ValueOf(rewriteValues(v.Elem().Interface(), rewrite)))
+			}
+			return
+		}
+		for _, key := range v.MapKeys() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(key))
+			rewriteValue(value, rewrite)
+			v.SetMapIndex(key, value)
+		}
+	}
+}
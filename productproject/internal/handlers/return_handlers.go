@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"productproject/internal/payment"
+	product "productproject/internal/product"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateReturnRequest รับ multipart/form-data: order_item_id, quantity, reason, details
+// และรูปประกอบใน field 'photos' ได้หลายไฟล์
+func (h *ProductHandlers) CreateReturnRequest(c *gin.Context) {
+	user, _ := currentUser(c)
+	orderID := c.Param("order_id")
+
+	// เผื่อขนาดของ field อื่นในฟอร์มไว้ 1 MB
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, product.MaxReturnPhotos*maxImageUploadSize+1<<20)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeProblem(c, http.StatusRequestEntityTooLarge, "photos are too large")
+			return
+		}
+		writeProblem(c, http.StatusBadRequest, "request must be multipart/form-data")
+		return
+	}
+
+	request := product.NewReturnRequest{
+		OrderItemID: c.PostForm("order_item_id"),
+		Reason:      c.PostForm("reason"),
+		Details:     c.PostForm("details"),
+	}
+	if request.Quantity, err = strconv.Atoi(c.DefaultPostForm("quantity", "1")); err != nil {
+		writeProblem(c, http.StatusBadRequest, "Invalid quantity value")
+		return
+	}
+	if err := request.Validate(); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	photos := form.File["photos"]
+	if len(photos) > product.MaxReturnPhotos {
+		writeProblem(c, http.StatusBadRequest, fmt.Sprintf("at most %d photos are allowed", product.MaxReturnPhotos))
+		return
+	}
+
+	var keys []string
+	removeUploads := func() {
+		for _, key := range keys {
+			if delErr := h.files.Delete(c.Request.Context(), key); delErr != nil {
+				log.Printf("failed to remove orphaned upload %s: %v", key, delErr)
+			}
+		}
+	}
+	for _, fileHeader := range photos {
+		data, contentType, ext, status, problem := readReturnPhoto(fileHeader)
+		if problem != "" {
+			removeUploads()
+			writeProblem(c, status, problem)
+			return
+		}
+
+		key, err := newReturnPhotoKey(orderID, ext)
+		if err == nil {
+			var url string
+			url, err = h.files.Put(c.Request.Context(), key, bytes.NewReader(data), int64(len(data)), contentType)
+			request.PhotoURLs = append(request.PhotoURLs, url)
+			keys = append(keys, key)
+		}
+		if err != nil {
+			removeUploads()
+			writeError(c, err)
+			return
+		}
+	}
+
+	created, err := h.store.CreateReturnRequest(c.Request.Context(), user.UserID, orderID, request)
+	if err != nil {
+		removeUploads()
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusCreated, created)
+}
+
+// readReturnPhoto ตรวจขนาดและชนิดของรูปจากเนื้อหาไฟล์จริง problem ไม่ว่างเมื่อไฟล์ไม่ผ่าน
+func readReturnPhoto(fileHeader *multipart.FileHeader) (data []byte, contentType, ext string, status int, problem string) {
+	if fileHeader.Size > maxImageUploadSize {
+		return nil, "", "", http.StatusRequestEntityTooLarge, fmt.Sprintf("each photo must not exceed %d MB", maxImageUploadSize>>20)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, "", "", http.StatusBadRequest, "failed to read photo"
+	}
+	defer file.Close()
+
+	data, err = io.ReadAll(file)
+	if err != nil {
+		return nil, "", "", http.StatusBadRequest, "failed to read photo"
+	}
+	contentType = http.DetectContentType(data)
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		return nil, "", "", http.StatusUnsupportedMediaType, "photos must be JPEG, PNG or WebP"
+	}
+	return data, contentType, ext, 0, ""
+}
+
+func newReturnPhotoKey(orderID, ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate photo key: %v", err)
+	}
+	return fmt.Sprintf("returns/%s/%s%s", orderID, hex.EncodeToString(b), ext), nil
+}
+
+func (h *ProductHandlers) GetReturnRequests(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	requests, err := h.store.GetReturnRequests(c.Request.Context(), user.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, requests)
+}
+
+func (h *ProductHandlers) GetReturnRequest(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	request, err := h.store.GetReturnRequest(c.Request.Context(), user.UserID, c.Param("return_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, request)
+}
+
+func (h *ProductHandlers) CancelReturnRequest(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	request, err := h.store.CancelReturnRequest(c.Request.Context(), user.UserID, c.Param("return_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, request)
+}
+
+func (h *ProductHandlers) GetShopReturnRequests(c *gin.Context) {
+	sellerID := c.Param("id")
+	if _, ok := h.authorizeShopOwner(c, sellerID); !ok {
+		return
+	}
+
+	requests, err := h.store.GetShopReturnRequests(c.Request.Context(), sellerID, c.Query("status"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, requests)
+}
+
+func (h *ProductHandlers) GetShopReturnRequest(c *gin.Context) {
+	sellerID := c.Param("id")
+	if _, ok := h.authorizeShopOwner(c, sellerID); !ok {
+		return
+	}
+
+	request, err := h.store.GetShopReturnRequest(c.Request.Context(), sellerID, c.Param("return_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, request)
+}
+
+// ReviewReturnRequest ผู้ขายอนุมัติ ปฏิเสธ หรือยืนยันว่าได้รับสินค้าคืนแล้ว
+func (h *ProductHandlers) ReviewReturnRequest(c *gin.Context) {
+	user, _ := currentUser(c)
+	sellerID := c.Param("id")
+	if _, ok := h.authorizeShopOwner(c, sellerID); !ok {
+		return
+	}
+
+	var action product.ReturnAction
+	if !bindJSON(c, &action) {
+		return
+	}
+	if err := action.Validate(); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	request, err := h.store.ReviewReturnRequest(c.Request.Context(), sellerID, c.Param("return_id"), user.UserID, action)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, request)
+}
+
+// RefundReturnRequest คืนเงินผ่านผู้ให้บริการชำระเงินหลังได้รับสินค้าคืนแล้ว
+// เลขคำขอคืนสินค้าเป็น idempotency key จึงกดซ้ำได้เมื่อการคืนเงินครั้งก่อนล้มเหลวหรือค้างอยู่
+func (h *ProductHandlers) RefundReturnRequest(c *gin.Context) {
+	user, _ := currentUser(c)
+	sellerID := c.Param("id")
+	if _, ok := h.authorizeShopOwner(c, sellerID); !ok {
+		return
+	}
+
+	request, err := h.store.StartReturnRefund(c.Request.Context(), sellerID, c.Param("return_id"), user.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	// ทำต่อให้จบแม้ผู้ใช้ปิดการเชื่อมต่อ เพื่อไม่ให้คืนเงินไปแล้วแต่ไม่ได้บันทึกผล
+	ctx := context.WithoutCancel(c.Request.Context())
+	reference, err := h.payments.Refund(ctx, payment.Refund{
+		ID:      request.ID,
+		OrderID: request.OrderID,
+		Amount:  request.RefundAmount,
+		Reason:  request.Reason,
+	})
+	if err != nil {
+		log.Printf("failed to refund return request %s: %v", request.ID, err)
+		if _, failErr := h.store.FailReturnRefund(ctx, request.ID, user.UserID, "payment provider rejected the refund"); failErr != nil {
+			log.Printf("failed to record refund failure for return request %s: %v", request.ID, failErr)
+		}
+		writeProblem(c, http.StatusBadGateway, "refund could not be issued, please try again")
+		return
+	}
+
+	request, err = h.store.CompleteReturnRefund(ctx, request.ID, user.UserID, reference)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, request)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxReviewImages = 5
+
+func (h *ProductHandlers) GetProductReviews(c *gin.Context) {
+	productID := c.Param("id")
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		writeProblem(c, http.StatusBadRequest, "Invalid page value")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, "Invalid limit value")
+		return
+	}
+
+	params := product.ReviewQueryParams{
+		Page:  page,
+		Limit: limit,
+		Sort:  c.DefaultQuery("sort", product.ReviewSortRecent),
+	}
+
+	reviews, err := h.store.GetProductReviews(c.Request.Context(), productID, params)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, reviews)
+}
+
+func (h *ProductHandlers) AddReview(c *gin.Context) {
+	user, _ := currentUser(c)
+	productID := c.Param("id")
+
+	var review product.NewReview
+	if !bindJSON(c, &review) {
+		return
+	}
+	if review.Rating < 1 || review.Rating > 5 {
+		writeProblem(c, http.StatusBadRequest, "rating must be between 1 and 5")
+		return
+	}
+	if len(review.Images) > maxReviewImages {
+		writeProblem(c, http.StatusBadRequest, "too many review images")
+		return
+	}
+
+	// รีวิวได้เฉพาะผู้ที่ได้รับสินค้าจากคำสั่งซื้อที่จัดส่งสำเร็จแล้ว
+	delivered, err := h.store.HasDeliveredOrder(c.Request.Context(), user.UserID, productID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if !delivered {
+		writeProblem(c, http.StatusForbidden, "only customers with a delivered order can review this product")
+		return
+	}
+
+	createdReview, err := h.store.AddReview(c.Request.Context(), productID, user.UserID, review)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusCreated, createdReview)
+}
+
+func (h *ProductHandlers) ReplyToReview(c *gin.Context) {
+	productID := c.Param("id")
+	reviewID := c.Param("review_id")
+
+	// ตอบกลับได้เฉพาะเจ้าของร้านที่ขายสินค้านี้
+	item, err := h.store.GetProduct(c.Request.Context(), productID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	if _, ok := h.authorizeShopOwner(c, item.SellerID); !ok {
+		return
+	}
+
+	var reply product.ReviewReply
+	if !bindJSON(c, &reply) {
+		return
+	}
+	if reply.Reply == "" {
+		writeProblem(c, http.StatusBadRequest, "reply is required")
+		return
+	}
+
+	review, err := h.store.ReplyToReview(c.Request.Context(), productID, reviewID, reply)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, review)
+}
+
+func (h *ProductHandlers) VoteReviewHelpful(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	review, err := h.store.VoteReviewHelpful(c.Request.Context(), c.Param("id"), c.Param("review_id"), user.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, review)
+}
+
+func (h *ProductHandlers) UnvoteReviewHelpful(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	review, err := h.store.UnvoteReviewHelpful(c.Request.Context(), c.Param("id"), c.Param("review_id"), user.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, review)
+}
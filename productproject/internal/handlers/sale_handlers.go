@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authorizeProductOwner อนุญาตเฉพาะเจ้าของร้านที่ขายสินค้านี้หรือผู้ดูแลระบบ และส่ง response เองเมื่อไม่ผ่าน
+func (h *ProductHandlers) authorizeProductOwner(c *gin.Context, productID string) bool {
+	item, err := h.store.GetProduct(c.Request.Context(), productID)
+	if err != nil {
+		writeError(c, err)
+		return false
+	}
+	_, ok := h.authorizeShopOwner(c, item.SellerID)
+	return ok
+}
+
+func (h *ProductHandlers) GetProductSales(c *gin.Context) {
+	productID := c.Param("id")
+	if !h.authorizeProductOwner(c, productID) {
+		return
+	}
+
+	sales, err := h.store.GetProductSales(c.Request.Context(), productID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, sales)
+}
+
+func (h *ProductHandlers) CreateProductSale(c *gin.Context) {
+	productID := c.Param("id")
+	if !h.authorizeProductOwner(c, productID) {
+		return
+	}
+
+	var sale product.NewProductSale
+	if !bindJSON(c, &sale) {
+		return
+	}
+	if err := sale.Validate(time.Now()); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	created, err := h.store.CreateProductSale(c.Request.Context(), productID, sale)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusCreated, created)
+}
+
+func (h *ProductHandlers) DeleteProductSale(c *gin.Context) {
+	productID := c.Param("id")
+	if !h.authorizeProductOwner(c, productID) {
+		return
+	}
+
+	if err := h.store.DeleteProductSale(c.Request.Context(), productID, c.Param("sale_id")); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{"message": "product sale deleted successfully"})
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type submitDocumentsRequest struct {
+	Documents []product.NewSellerDocument `json:"documents"`
+}
+
+func (h *ProductHandlers) ApplySeller(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	var application product.SellerApplication
+	if !bindJSON(c, &application) {
+		return
+	}
+	if application.Name == "" {
+		writeProblem(c, http.StatusBadRequest, "shop name is required")
+		return
+	}
+
+	created, err := h.store.ApplySeller(c.Request.Context(), user.UserID, application)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusCreated, created)
+}
+
+func (h *ProductHandlers) GetSellerApplication(c *gin.Context) {
+	application, ok := h.authorizeShopOwner(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	writeJSON(c, http.StatusOK, application)
+}
+
+func (h *ProductHandlers) SubmitSellerDocuments(c *gin.Context) {
+	sellerID := c.Param("id")
+	if _, ok := h.authorizeShopOwner(c, sellerID); !ok {
+		return
+	}
+
+	var req submitDocumentsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if len(req.Documents) == 0 {
+		writeProblem(c, http.StatusBadRequest, "at least one document is required")
+		return
+	}
+	for _, document := range req.Documents {
+		if document.DocumentType == "" || document.DocumentURL == "" {
+			writeProblem(c, http.StatusBadRequest, "document_type and document_url are required")
+			return
+		}
+	}
+
+	application, err := h.store.SubmitSellerDocuments(c.Request.Context(), sellerID, req.Documents)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, application)
+}
+
+func (h *ProductHandlers) GetSellerReviewQueue(c *gin.Context) {
+	// ดึงใบสมัครที่รอผู้ดูแลระบบตรวจสอบ เรียงตามเวลาที่ส่งเอกสาร
+	applications, err := h.store.GetSellerReviewQueue(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, applications)
+}
+
+func (h *ProductHandlers) ReviewSeller(c *gin.Context) {
+	user, _ := currentUser(c)
+	sellerID := c.Param("id")
+
+	var review product.SellerReview
+	if !bindJSON(c, &review) {
+		return
+	}
+	switch review.Action {
+	case product.SellerReviewStart, product.SellerReviewApprove:
+	case product.SellerReviewReject:
+		if review.Reason == "" {
+			writeProblem(c, http.StatusBadRequest, "reason is required when rejecting")
+			return
+		}
+	default:
+		writeProblem(c, http.StatusBadRequest, "action must be one of start, approve, reject")
+		return
+	}
+
+	application, err := h.store.ReviewSeller(c.Request.Context(), sellerID, user.UserID, review)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, application)
+}
+
+// authorizeShopOwner อนุญาตเฉพาะเจ้าของร้านหรือผู้ดูแลระบบ และส่ง response เองเมื่อไม่ผ่าน
+func (h *ProductHandlers) authorizeShopOwner(c *gin.Context, sellerID string) (product.SellerApplicationStatus, bool) {
+	user, _ := currentUser(c)
+
+	application, err := h.store.GetSellerApplication(c.Request.Context(), sellerID)
+	if err != nil {
+		writeError(c, err)
+		return product.SellerApplicationStatus{}, false
+	}
+
+	if user.Role != product.RoleAdmin && application.OwnerUserID != user.UserID {
+		writeProblem(c, http.StatusForbidden, "you do not own this shop")
+		return product.SellerApplicationStatus{}, false
+	}
+
+	return application, true
+}
+
+func (h *ProductHandlers) GetShopAnalytics(c *gin.Context) {
+	sellerID := c.Param("id")
+	if _, ok := h.authorizeShopOwner(c, sellerID); !ok {
+		return
+	}
+
+	params := product.SalesAnalyticsParams{
+		From:   c.Query("from"),
+		To:     c.Query("to"),
+		Bucket: c.DefaultQuery("bucket", product.BucketDay),
+	}
+
+	switch params.Bucket {
+	case product.BucketDay, product.BucketWeek, product.BucketMonth:
+	default:
+		writeProblem(c, http.StatusBadRequest, "bucket must be one of day, week, month")
+		return
+	}
+
+	// ตรวจสอบรูปแบบวันที่ก่อน การแปลงตามเขตเวลาของร้านทำใน store
+	var from, to time.Time
+	var err error
+	if params.From != "" {
+		if from, err = time.Parse(product.AnalyticsDateLayout, params.From); err != nil {
+			writeProblem(c, http.StatusBadRequest, "Invalid from date, expected YYYY-MM-DD")
+			return
+		}
+	}
+	if params.To != "" {
+		if to, err = time.Parse(product.AnalyticsDateLayout, params.To); err != nil {
+			writeProblem(c, http.StatusBadRequest, "Invalid to date, expected YYYY-MM-DD")
+			return
+		}
+	}
+	if params.From != "" && params.To != "" {
+		if from.After(to) {
+			writeProblem(c, http.StatusBadRequest, "from date must not be after to date")
+			return
+		}
+		if to.Sub(from) > product.MaxAnalyticsRangeDays*24*time.Hour {
+			writeProblem(c, http.StatusBadRequest, "date range is too long")
+			return
+		}
+	}
+
+	top, err := strconv.Atoi(c.DefaultQuery("top", "5"))
+	if err != nil || top < 1 || top > 50 {
+		writeProblem(c, http.StatusBadRequest, "Invalid top value")
+		return
+	}
+	params.Top = top
+
+	analytics, err := h.store.GetShopAnalytics(c.Request.Context(), sellerID, params)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, analytics)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateShipment ร้านแจ้งขนส่งและเลขพัสดุเมื่อส่งสินค้า หรือแจ้งว่าลูกค้าได้รับสินค้าแล้ว
+func (h *ProductHandlers) UpdateShipment(c *gin.Context) {
+	sellerID := c.Param("id")
+	if _, ok := h.authorizeShopOwner(c, sellerID); !ok {
+		return
+	}
+
+	var update product.ShipmentUpdate
+	if !bindJSON(c, &update) {
+		return
+	}
+	if err := update.Validate(); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	shipment, err := h.store.UpdateShipment(c.Request.Context(), sellerID, c.Param("order_id"), update)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, shipment)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *ProductHandlers) GetShippingProfile(c *gin.Context) {
+	profile, err := h.store.GetShippingProfile(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, profile)
+}
+
+func (h *ProductHandlers) SetShippingProfile(c *gin.Context) {
+	sellerID := c.Param("id")
+	if _, ok := h.authorizeShopOwner(c, sellerID); !ok {
+		return
+	}
+
+	var profile product.NewShippingProfile
+	if !bindJSON(c, &profile) {
+		return
+	}
+	if err := profile.Validate(); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	saved, err := h.store.SetShippingProfile(c.Request.Context(), sellerID, profile)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, saved)
+}
+
+// QuoteShipping คิดค่าจัดส่งของตะกร้าปัจจุบันแยกตามร้าน
+func (h *ProductHandlers) QuoteShipping(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	quote, err := h.store.QuoteShipping(c.Request.Context(), user.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, quote)
+}
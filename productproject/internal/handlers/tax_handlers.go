@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"productproject/internal/invoice"
+	product "productproject/internal/product"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *ProductHandlers) GetSellerTaxSettings(c *gin.Context) {
+	sellerID := c.Param("id")
+	if _, ok := h.authorizeShopOwner(c, sellerID); !ok {
+		return
+	}
+
+	settings, err := h.store.GetSellerTaxSettings(c.Request.Context(), sellerID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, settings)
+}
+
+func (h *ProductHandlers) SetSellerTaxSettings(c *gin.Context) {
+	sellerID := c.Param("id")
+	if _, ok := h.authorizeShopOwner(c, sellerID); !ok {
+		return
+	}
+
+	var settings product.SellerTaxSettings
+	if !bindJSON(c, &settings) {
+		return
+	}
+	if err := settings.Validate(); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	saved, err := h.store.SetSellerTaxSettings(c.Request.Context(), sellerID, settings)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, saved)
+}
+
+// GetTaxInvoice คืนใบกำกับภาษีหรือใบเสร็จของร้านในคำสั่งซื้อ เป็น HTML สำหรับพิมพ์
+// หรือเป็น JSON เมื่อ Accept เป็น application/json
+func (h *ProductHandlers) GetTaxInvoice(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	document, err := h.store.GetTaxInvoice(c.Request.Context(), user.UserID, c.Param("order_id"), c.Param("seller_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	if c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+		writeJSON(c, http.StatusOK, document)
+		return
+	}
+
+	document.IssuedAt = document.IssuedAt.In(requestLocation(c))
+	var page bytes.Buffer
+	if err := invoice.RenderHTML(&page, document); err != nil {
+		log.Printf("failed to render tax invoice %s: %v", document.Number, err)
+		writeError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, invoice.ContentTypeHTML, page.Bytes())
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const timezoneContextKey = "timezone"
+
+type requestTimezone struct {
+	explicit *time.Location // จาก query tz หรือ header X-Timezone
+	fallback *time.Location // ค่าเริ่มต้นจาก config
+}
+
+// Timezone อ่าน timezone ที่ผู้เรียกระบุจาก query tz แล้วจึง header X-Timezone
+// ถ้าไม่ระบุจะใช้ timezone ในโปรไฟล์ของผู้ใช้ (เมื่อเข้าสู่ระบบ) และสุดท้ายคือ fallback
+// ชื่อ timezone ที่ไม่รู้จักจะตอบ 400
+func Timezone(fallback *time.Location) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tz := requestTimezone{fallback: fallback}
+
+		field, name := "tz", c.Query("tz")
+		if name == "" {
+			field, name = "X-Timezone", c.GetHeader("X-Timezone")
+		}
+		if name != "" {
+			loc, err := time.LoadLocation(name)
+			if err != nil {
+				writeFieldProblem(c, http.StatusBadRequest, "unknown timezone "+name, []product.FieldError{
+					{Field: field, Message: "must be an IANA timezone such as Asia/Bangkok"},
+				})
+				return
+			}
+			tz.explicit = loc
+		}
+
+		c.Set(timezoneContextKey, tz)
+		c.Next()
+	}
+}
+
+// requestLocation ต้องเรียกหลัง AuthRequired หรือ OptionalAuth ถ้าต้องการใช้ timezone ในโปรไฟล์ของผู้ใช้
+func requestLocation(c *gin.Context) *time.Location {
+	value, _ := c.Get(timezoneContextKey)
+	tz, _ := value.(requestTimezone)
+	if tz.explicit != nil {
+		return tz.explicit
+	}
+
+	// timezone ที่บันทึกไว้ผิดไม่ควรทำให้ request ล้มเหลว จึงข้ามไปใช้ค่าเริ่มต้นแทน
+	if user, ok := currentUser(c); ok && user.Timezone != "" {
+		if loc, err := time.LoadLocation(user.Timezone); err == nil {
+			return loc
+		}
+	}
+
+	if tz.fallback != nil {
+		return tz.fallback
+	}
+	return time.UTC
+}
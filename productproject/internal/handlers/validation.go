@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// structValidator ให้ gin ใช้ validator ตัวเดียวกับ product เพื่อให้กฎใน binding tag
+// ตรงกันทั้งตอนรับ request และตอนนำเข้าสินค้าจากไฟล์
+type structValidator struct{}
+
+func (structValidator) ValidateStruct(obj any) error {
+	return product.ValidateStruct(obj)
+}
+
+func (structValidator) Engine() any {
+	return nil
+}
+
+func init() {
+	binding.Validator = structValidator{}
+}
+
+// bindJSON อ่าน body และตรวจตาม binding tag ถ้าไม่ผ่านจะตอบ 400 พร้อมรายการ field เอง
+func bindJSON(c *gin.Context, obj any) bool {
+	err := c.ShouldBindJSON(obj)
+	if err == nil {
+		return true
+	}
+	if e := product.Classify(err); e != nil {
+		writeFieldProblem(c, http.StatusBadRequest, e.Message, e.Fields)
+		return false
+	}
+	writeProblem(c, http.StatusBadRequest, err.Error())
+	return false
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ValidateUUIDParams ตรวจ path parameter ที่ชื่อ id หรือลงท้ายด้วย _id/ID ว่าเป็น UUID
+// ก่อนถึง handler เพื่อไม่ให้ค่าที่ผิดรูปแบบไปถึงฐานข้อมูล
+func ValidateUUIDParams() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var fields []product.FieldError
+		for _, param := range c.Params {
+			if !isIDParam(param.Key) || uuidPattern.MatchString(param.Value) {
+				continue
+			}
+			fields = append(fields, product.FieldError{Field: param.Key, Message: "must be a valid UUID"})
+		}
+		if len(fields) > 0 {
+			writeFieldProblem(c, http.StatusBadRequest, fields[0].Field+" "+fields[0].Message, fields)
+			return
+		}
+		c.Next()
+	}
+}
+
+func isIDParam(key string) bool {
+	return key == "id" || strings.HasSuffix(key, "_id") || strings.HasSuffix(key, "ID")
+}
+
+// uuidQuery คืนค่า query parameter ที่ต้องเป็น UUID ถ้าไม่ได้ส่งมาจะคืนค่าว่าง
+// ถ้าค่าผิดรูปแบบจะตอบ 400 แล้วคืน false เช่นเดียวกับ ValidateUUIDParams
+func uuidQuery(c *gin.Context, key string) (string, bool) {
+	value := c.Query(key)
+	if value == "" || uuidPattern.MatchString(value) {
+		return value, true
+	}
+	fields := []product.FieldError{{Field: key, Message: "must be a valid UUID"}}
+	writeFieldProblem(c, http.StatusBadRequest, key+" "+fields[0].Message, fields)
+	return "", false
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type variantOptionsRequest struct {
+	Options []product.VariantOption `json:"options"`
+}
+
+func (h *ProductHandlers) GetProductVariants(c *gin.Context) {
+	variants, err := h.store.GetProductVariants(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, variants)
+}
+
+// SetVariantOptions กำหนดแกนของตัวเลือกทั้งหมดของสินค้า เช่น น้ำหนัก หรือรสชาติ
+func (h *ProductHandlers) SetVariantOptions(c *gin.Context) {
+	id := c.Param("id")
+	if !h.authorizeProductOwner(c, id) {
+		return
+	}
+
+	var req variantOptionsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if err := product.ValidateVariantAxes(req.Options); err != nil {
+		writeProblem(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	options, err := h.store.SetVariantOptions(c.Request.Context(), id, req.Options)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{"options": options})
+}
+
+func (h *ProductHandlers) AddProductVariant(c *gin.Context) {
+	id := c.Param("id")
+	if !h.authorizeProductOwner(c, id) {
+		return
+	}
+
+	var variant product.NewProductVariant
+	if !bindJSON(c, &variant) {
+		return
+	}
+	variant.SKU = strings.TrimSpace(variant.SKU)
+	if variant.SKU == "" {
+		writeProblem(c, http.StatusBadRequest, "sku is required")
+		return
+	}
+	if variant.Price != nil && variant.Price.IsNegative() {
+		writeProblem(c, http.StatusBadRequest, "price must not be negative")
+		return
+	}
+	if variant.Quantity < 0 {
+		writeProblem(c, http.StatusBadRequest, "quantity must not be negative")
+		return
+	}
+	if !h.validateVariantOptions(c, id, variant.Options) {
+		return
+	}
+
+	created, err := h.store.AddProductVariant(c.Request.Context(), id, variant)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusCreated, created)
+}
+
+func (h *ProductHandlers) UpdateProductVariant(c *gin.Context) {
+	id := c.Param("id")
+	if !h.authorizeProductOwner(c, id) {
+		return
+	}
+
+	var update product.UpdateProductVariant
+	if !bindJSON(c, &update) {
+		return
+	}
+	if update.SKU != nil {
+		sku := strings.TrimSpace(*update.SKU)
+		if sku == "" {
+			writeProblem(c, http.StatusBadRequest, "sku must not be empty")
+			return
+		}
+		update.SKU = &sku
+	}
+	if update.Price != nil && update.Price.IsNegative() {
+		writeProblem(c, http.StatusBadRequest, "price must not be negative")
+		return
+	}
+	if update.Quantity != nil && *update.Quantity < 0 {
+		writeProblem(c, http.StatusBadRequest, "quantity must not be negative")
+		return
+	}
+	if update.Options != nil && !h.validateVariantOptions(c, id, update.Options) {
+		return
+	}
+
+	updated, err := h.store.UpdateProductVariant(c.Request.Context(), id, c.Param("variant_id"), update)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, updated)
+}
+
+func (h *ProductHandlers) DeleteProductVariant(c *gin.Context) {
+	id := c.Param("id")
+	if !h.authorizeProductOwner(c, id) {
+		return
+	}
+
+	if err := h.store.DeleteProductVariant(c.Request.Context(), id, c.Param("variant_id")); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{"message": "ProductVariant deleted successfully"})
+}
+
+// validateVariantOptions ตรวจ options กับแกนของสินค้าก่อนบันทึก และส่ง response เองเมื่อไม่ผ่าน
+func (h *ProductHandlers) validateVariantOptions(c *gin.Context, productID string, options map[string]string) bool {
+	current, err := h.store.GetProductVariants(c.Request.Context(), productID)
+	if err != nil {
+		writeError(c, err)
+		return false
+	}
+	if err := product.ValidateVariantOptions(current.Options, options); err != nil {
+		writeProblem(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	product "productproject/internal/product"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *ProductHandlers) GetWishlists(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	wishlists, err := h.store.GetWishlists(c.Request.Context(), user.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, wishlists)
+}
+
+func (h *ProductHandlers) GetWishlist(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	wishlist, err := h.store.GetWishlist(c.Request.Context(), user.UserID, c.Param("wishlist_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, wishlist)
+}
+
+func (h *ProductHandlers) CreateWishlist(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	var wishlist product.NewWishlist
+	if !bindJSON(c, &wishlist) {
+		return
+	}
+	if wishlist.Name == "" {
+		writeProblem(c, http.StatusBadRequest, "wishlist name is required")
+		return
+	}
+
+	created, err := h.store.CreateWishlist(c.Request.Context(), user.UserID, wishlist)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusCreated, created)
+}
+
+func (h *ProductHandlers) DeleteWishlist(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	if err := h.store.DeleteWishlist(c.Request.Context(), user.UserID, c.Param("wishlist_id")); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, gin.H{"message": "wishlist deleted successfully"})
+}
+
+func (h *ProductHandlers) AddWishlistItem(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	var item product.NewWishlistItem
+	if !bindJSON(c, &item) {
+		return
+	}
+	if item.ProductID == "" {
+		writeProblem(c, http.StatusBadRequest, "product_id is required")
+		return
+	}
+
+	wishlist, err := h.store.AddWishlistItem(c.Request.Context(), user.UserID, c.Param("wishlist_id"), item)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, wishlist)
+}
+
+func (h *ProductHandlers) RemoveWishlistItem(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	wishlist, err := h.store.RemoveWishlistItem(c.Request.Context(), user.UserID, c.Param("wishlist_id"), c.Param("product_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, wishlist)
+}
+
+func (h *ProductHandlers) ShareWishlist(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	wishlist, err := h.store.ShareWishlist(c.Request.Context(), user.UserID, c.Param("wishlist_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, wishlist)
+}
+
+func (h *ProductHandlers) UnshareWishlist(c *gin.Context) {
+	user, _ := currentUser(c)
+
+	wishlist, err := h.store.UnshareWishlist(c.Request.Context(), user.UserID, c.Param("wishlist_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, wishlist)
+}
+
+func (h *ProductHandlers) GetSharedWishlist(c *gin.Context) {
+	// ลิงก์สาธารณะ ไม่ต้องเข้าสู่ระบบ
+	wishlist, err := h.store.GetSharedWishlist(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeJSON(c, http.StatusOK, wishlist)
+}
@@ -0,0 +1,119 @@
+// imaging.go
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Rendition คือขนาดของรูปที่ต้องการสร้าง กำหนดเฉพาะความกว้าง ความสูงคำนวณตามสัดส่วนเดิม
+type Rendition struct {
+	Name  string
+	Width int
+}
+
+// DefaultRenditions ขนาดรูปที่หน้าเว็บใช้: thumbnail สำหรับรายการย่อ, card สำหรับการ์ดสินค้า, zoom สำหรับหน้ารายละเอียด
+var DefaultRenditions = []Rendition{
+	{Name: "thumbnail", Width: 150},
+	{Name: "card", Width: 400},
+	{Name: "zoom", Width: 1200},
+}
+
+const (
+	jpegQuality      = 82
+	placeholderWidth = 16
+
+	// MaxPixels ป้องกันไฟล์ที่บีบอัดมาเล็กแต่ขยายแล้วใช้หน่วยความจำมหาศาล
+	MaxPixels = 40_000_000
+)
+
+// CheckDimensions อ่านเฉพาะ header ของรูปเพื่อตรวจว่าเปิดได้และขนาดไม่เกินที่กำหนด
+func CheckDimensions(data []byte) error {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %v", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return fmt.Errorf("image dimensions %dx%d are not allowed", cfg.Width, cfg.Height)
+	}
+	return nil
+}
+
+// Decode อ่านรูป JPEG, PNG หรือ WebP
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %v", err)
+	}
+	return img, nil
+}
+
+// Resize ย่อรูปให้กว้างไม่เกิน width โดยคงสัดส่วนเดิมและไม่ขยายรูปที่เล็กกว่า ส่วนที่โปร่งใสยังโปร่งใสอยู่
+func Resize(src image.Image, width int) *image.RGBA {
+	b := src.Bounds()
+	if width <= 0 || width > b.Dx() {
+		width = b.Dx()
+	}
+	height := b.Dy() * width / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+// EncodeJPEG เติมส่วนที่โปร่งใสเป็นสีขาวก่อนเข้ารหัส เพราะ JPEG ไม่รองรับ alpha
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	if o, ok := img.(interface{ Opaque() bool }); !ok || !o.Opaque() {
+		flat := image.NewRGBA(img.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+		img = flat
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode jpeg: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodePNG ใช้กับรูปที่มีส่วนโปร่งใส เช่น รูปสินค้าพื้นหลังใสจากไฟล์ PNG
+func EncodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeWebP เข้ารหัสแบบ lossless เพราะ encoder ที่ไม่ต้องใช้ cgo รองรับเฉพาะ VP8L
+// รูปถ่ายจึงมักได้ไฟล์ใหญ่กว่า JPEG ผู้เรียกควรเทียบขนาดก่อนใช้
+func EncodeWebP(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := nativewebp.Encode(&buf, img, nil); err != nil {
+		return nil, fmt.Errorf("failed to encode webp: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Placeholder สร้างรูปขนาดเล็กมากในรูปแบบ data URI ให้หน้าเว็บแสดงแบบเบลอระหว่างรอโหลดรูปจริง
+func Placeholder(img image.Image) (string, error) {
+	data, err := EncodeJPEG(Resize(img, placeholderWidth))
+	if err != nil {
+		return "", err
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
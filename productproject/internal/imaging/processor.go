@@ -0,0 +1,165 @@
+// processor.go
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	product "productproject/internal/product"
+	"productproject/internal/storage"
+)
+
+const processTimeout = 2 * time.Minute
+
+// Job คือรูปต้นฉบับที่อัปโหลดแล้วและรอสร้างรูปขนาดต่างๆ
+type Job struct {
+	ProductID string
+	ImageID   string
+	Key       string // key ของไฟล์ต้นฉบับใน storage ใช้เป็นฐานในการตั้งชื่อไฟล์ที่ย่อขนาดแล้ว
+	Data      []byte
+}
+
+type VariantStore interface {
+	SaveProductImageVariants(ctx context.Context, productID, imageID string, variants product.ImageVariants, placeholder string) error
+}
+
+// Processor กระจายงานให้ worker หลายตัวทำในเบื้องหลัง เพื่อไม่ให้คำขออัปโหลดต้องรอการย่อรูป
+type Processor struct {
+	files      storage.Storage
+	store      VariantStore
+	renditions []Rendition
+	workers    int
+	jobs       chan Job
+	wg         sync.WaitGroup
+}
+
+func NewProcessor(files storage.Storage, store VariantStore, workers, queueSize int) *Processor {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	return &Processor{
+		files:      files,
+		store:      store,
+		renditions: DefaultRenditions,
+		workers:    workers,
+		jobs:       make(chan Job, queueSize),
+	}
+}
+
+func (p *Processor) Start() {
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				ctx, cancel := context.WithTimeout(context.Background(), processTimeout)
+				if err := p.process(ctx, job); err != nil {
+					log.Printf("failed to process image %s: %v", job.ImageID, err)
+				}
+				cancel()
+			}
+		}()
+	}
+}
+
+// Enqueue ไม่บล็อกผู้เรียก ถ้าคิวเต็มจะคืน false และรูปนั้นจะใช้ไฟล์ต้นฉบับไปก่อน
+func (p *Processor) Enqueue(job Job) bool {
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close รอให้งานที่ค้างอยู่ในคิวทำเสร็จ ห้ามเรียก Enqueue หลังจากนี้
+func (p *Processor) Close() {
+	close(p.jobs)
+	p.wg.Wait()
+}
+
+func (p *Processor) process(ctx context.Context, job Job) error {
+	src, err := Decode(job.Data)
+	if err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(job.Key, path.Ext(job.Key))
+	variants := make(product.ImageVariants, len(p.renditions))
+	var stored []string
+
+	// ลบไฟล์ที่สร้างไปแล้วถ้าทำไม่สำเร็จ จะได้ไม่มีไฟล์ค้างใน storage
+	cleanup := func() {
+		for _, key := range stored {
+			if err := p.files.Delete(context.Background(), key); err != nil {
+				log.Printf("failed to remove image variant %s: %v", key, err)
+			}
+		}
+	}
+
+	put := func(key string, data []byte, contentType string) (string, error) {
+		url, err := p.files.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+		if err != nil {
+			return "", err
+		}
+		stored = append(stored, key)
+		return url, nil
+	}
+
+	for _, r := range p.renditions {
+		img := Resize(src, r.Width)
+		variant := product.ImageVariant{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+
+		// รูปที่มีส่วนโปร่งใสใช้ PNG เป็นรูปหลักเพื่อคง alpha ไว้ รูปอื่นใช้ JPEG
+		encode, ext, contentType := EncodeJPEG, "jpg", "image/jpeg"
+		if !img.Opaque() {
+			encode, ext, contentType = EncodePNG, "png", "image/png"
+		}
+		data, err := encode(img)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		if variant.URL, err = put(fmt.Sprintf("%s_%s.%s", base, r.Name, ext), data, contentType); err != nil {
+			cleanup()
+			return err
+		}
+
+		// WebP เป็น lossless จึงเก็บไว้เฉพาะเมื่อเล็กกว่ารูปหลัก ไม่เช่นนั้นเบราว์เซอร์จะโหลดไฟล์ที่ใหญ่กว่าโดยเปล่าประโยชน์
+		webp, err := EncodeWebP(img)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		if len(webp) < len(data) {
+			if variant.WebPURL, err = put(fmt.Sprintf("%s_%s.webp", base, r.Name), webp, "image/webp"); err != nil {
+				cleanup()
+				return err
+			}
+		}
+
+		variants[r.Name] = variant
+	}
+
+	placeholder, err := Placeholder(src)
+	if err != nil {
+		cleanup()
+		return err
+	}
+
+	// รูปอาจถูกลบไปแล้วระหว่างประมวลผล ในกรณีนั้นไฟล์ที่สร้างไว้ต้องถูกลบด้วย
+	if err := p.store.SaveProductImageVariants(ctx, job.ProductID, job.ImageID, variants, placeholder); err != nil {
+		cleanup()
+		return err
+	}
+	return nil
+}
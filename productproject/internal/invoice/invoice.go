@@ -0,0 +1,127 @@
+// invoice.go
+package invoice
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	product "productproject/internal/product"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var documentTitles = map[string]string{
+	product.DocumentTaxInvoice: "ใบกำกับภาษี/ใบเสร็จรับเงิน",
+	product.DocumentReceipt:    "ใบเสร็จรับเงิน",
+}
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"inc":      func(i int) int { return i + 1 },
+	"amount":   FormatAmount,
+	"bahtText": BahtText,
+	"title":    func(documentType string) string { return documentTitles[documentType] },
+	"date":     func(t time.Time) string { return t.Format("02/01/2006") },
+	"taxInvoice": func(documentType string) bool {
+		return documentType == product.DocumentTaxInvoice
+	},
+	"branch": func(code string) string {
+		if code == "00000" {
+			return "สำนักงานใหญ่"
+		}
+		return "สาขาที่ " + code
+	},
+}).ParseFS(templateFS, "templates/*.html"))
+
+// ContentTypeHTML คือ Content-Type ของเอกสารที่ RenderHTML เขียน
+const ContentTypeHTML = "text/html; charset=utf-8"
+
+// RenderHTML เขียนเอกสารเป็นหน้า HTML ที่พิมพ์หรือบันทึกเป็น PDF จากเบราว์เซอร์ได้
+// วันที่แสดงตามเขตเวลาของ IssuedAt ผู้เรียกควรแปลงเป็นเขตเวลาของผู้ใช้ก่อน
+func RenderHTML(w io.Writer, invoice product.TaxInvoice) error {
+	return templates.ExecuteTemplate(w, "tax_invoice.html", invoice)
+}
+
+// FormatAmount แสดงจำนวนเงินแบบมีตัวคั่นหลักพัน เช่น "1,234.50"
+func FormatAmount(m product.Money) string {
+	s := m.String()
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return sign + b.String() + "." + fraction
+}
+
+var (
+	thaiDigits    = []string{"", "หนึ่ง", "สอง", "สาม", "สี่", "ห้า", "หก", "เจ็ด", "แปด", "เก้า"}
+	thaiPositions = []string{"", "สิบ", "ร้อย", "พัน", "หมื่น", "แสน"}
+)
+
+// BahtText อ่านจำนวนเงินบาทเป็นตัวอักษรตามรูปแบบที่ใช้ในใบเสร็จ เช่น 1,021.50 เป็น
+// "หนึ่งพันยี่สิบเอ็ดบาทห้าสิบสตางค์" 100.00 เป็น "หนึ่งร้อยบาทถ้วน" และ 0.50 เป็น "ห้าสิบสตางค์"
+func BahtText(m product.Money) string {
+	minor := m.Minor()
+	prefix := ""
+	if minor < 0 {
+		prefix, minor = "ลบ", -minor
+	}
+	baht, satang := minor/100, minor%100
+
+	switch {
+	case baht == 0 && satang == 0:
+		return "ศูนย์บาทถ้วน"
+	case satang == 0:
+		return prefix + readNumber(baht) + "บาทถ้วน"
+	case baht == 0:
+		return prefix + readNumber(satang) + "สตางค์"
+	}
+	return prefix + readNumber(baht) + "บาท" + readNumber(satang) + "สตางค์"
+}
+
+// readNumber อ่านจำนวนเต็มบวก ทีละกลุ่ม 6 หลักคั่นด้วย "ล้าน"
+func readNumber(n int64) string {
+	if n >= 1000000 {
+		text := readNumber(n / 1000000)
+		return text + "ล้าน" + readGroup(n%1000000, true)
+	}
+	return readGroup(n, false)
+}
+
+// readGroup อ่านตัวเลขไม่เกิน 6 หลัก หลักหน่วยที่เป็น 1 อ่านว่า "เอ็ด" เมื่อมีหลักที่สูงกว่า
+func readGroup(n int64, hasHigher bool) string {
+	var b strings.Builder
+	for position := 5; position >= 0; position-- {
+		var divisor int64 = 1
+		for i := 0; i < position; i++ {
+			divisor *= 10
+		}
+		digit := (n / divisor) % 10
+		if digit == 0 {
+			continue
+		}
+
+		switch {
+		case position == 1 && digit == 1:
+			b.WriteString("สิบ")
+		case position == 1 && digit == 2:
+			b.WriteString("ยี่สิบ")
+		case position == 0 && digit == 1 && (n > 1 || hasHigher):
+			b.WriteString("เอ็ด")
+		default:
+			b.WriteString(thaiDigits[digit] + thaiPositions[position])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,19 @@
+// log.go
+package notification
+
+import (
+	"context"
+	"log"
+)
+
+// LogSender เขียนอีเมลลง log แทนการส่งจริง ใช้ตอนพัฒนาเมื่อไม่มี SMTP server
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("email %s to %s: %s\n%s", msg.ID, msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,129 @@
+// notification.go
+package notification
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"productproject/internal/invoice"
+	product "productproject/internal/product"
+)
+
+// template ของแต่ละอีเมลอยู่ในไฟล์ templates/<ชื่อ>.<ภาษา>.tmpl และต้องมี block "subject" กับ "body"
+//
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var funcs = template.FuncMap{
+	"amount": func(m product.Money) string {
+		return invoice.FormatAmount(m) + " " + m.Currency()
+	},
+	"datetime": func(t time.Time) string { return t.Format("02/01/2006 15:04 MST") },
+	"shortID":  func(id string) string { return strings.ToUpper(strings.SplitN(id, "-", 2)[0]) },
+}
+
+// templates ใช้ชื่อ "<ชื่อ>.<ภาษา>" เป็น key แต่ละไฟล์แยก set กันเพราะทุกไฟล์ใช้ชื่อ block เดียวกัน
+var templates = loadTemplates()
+
+func loadTemplates() map[string]*template.Template {
+	paths, err := fs.Glob(templateFS, "templates/*.tmpl")
+	if err != nil {
+		panic(err)
+	}
+	loaded := make(map[string]*template.Template, len(paths))
+	for _, p := range paths {
+		name := path.Base(p)
+		loaded[strings.TrimSuffix(name, ".tmpl")] = template.Must(template.New(name).Funcs(funcs).ParseFS(templateFS, p))
+	}
+	return loaded
+}
+
+// payloads สร้างค่าว่างของข้อมูลที่ template แต่ละชนิดใช้
+var payloads = map[string]func() interface{}{
+	product.EmailOrderConfirmation: func() interface{} { return &product.OrderEmail{} },
+	product.EmailShippingUpdate:    func() interface{} { return &product.ShipmentEmail{} },
+	product.EmailLoginNotice:       func() interface{} { return &product.LoginNoticeEmail{} },
+	product.EmailLowStock:          func() interface{} { return &product.LowStockEmail{} },
+}
+
+// Message คืออีเมลที่พร้อมส่ง เนื้อหาเป็นข้อความธรรมดา
+type Message struct {
+	ID      string // ใช้สร้าง Message-ID ให้ผู้รับตรวจอีเมลซ้ำได้เมื่อส่งซ้ำหลังเกิดข้อผิดพลาด
+	To      string
+	ToName  string
+	Subject string
+	Body    string
+}
+
+// Render สร้างอีเมลจาก template ตามภาษาของผู้รับ ถ้าไม่มี template ของภาษานั้นจะใช้ภาษาหลัก
+// วันที่และเวลาใน payload แสดงตามเขตเวลา loc
+func Render(email product.OutboxEmail, loc *time.Location) (Message, error) {
+	newPayload, ok := payloads[email.Template]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template: %s", email.Template)
+	}
+	payload := newPayload()
+	if err := json.Unmarshal(email.Payload, payload); err != nil {
+		return Message{}, fmt.Errorf("invalid %s payload: %w", email.Template, err)
+	}
+	if notice, ok := payload.(*product.LoginNoticeEmail); ok {
+		notice.SignedInAt = notice.SignedInAt.In(loc)
+	}
+
+	tmpl, ok := templates[email.Template+"."+email.Locale]
+	if !ok {
+		tmpl, ok = templates[email.Template+"."+product.DefaultLocale]
+	}
+	if !ok {
+		return Message{}, fmt.Errorf("missing template for %s", email.Template)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", payload); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s subject: %w", email.Template, err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", payload); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s body: %w", email.Template, err)
+	}
+
+	return Message{
+		ID:      email.ID,
+		To:      email.RecipientEmail,
+		ToName:  email.RecipientName,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(body.String()) + "\n",
+	}, nil
+}
+
+// Sender ส่งอีเมลหนึ่งฉบับ
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type Config struct {
+	Driver   string // 'smtp', 'log'
+	Host     string
+	Port     int
+	Username string // ว่างคือไม่ต้องยืนยันตัวตน เช่น SMTP sink บนเครื่อง
+	Password string
+	From     string // เช่น "Shop <no-reply@example.com>"
+}
+
+func NewSender(cfg Config) (Sender, error) {
+	switch cfg.Driver {
+	case "", "smtp":
+		return NewSMTPSender(cfg)
+	case "log":
+		return NewLogSender(), nil
+	default:
+		return nil, fmt.Errorf("unknown email driver: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,119 @@
+// worker.go
+package notification
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	product "productproject/internal/product"
+)
+
+const (
+	pollInterval = 5 * time.Second
+	batchSize    = 20
+	sendTimeout  = 30 * time.Second
+	// claimLease ต้องนานกว่าเวลาส่งทั้ง batch ไม่เช่นนั้นอีเมลที่กำลังส่งอาจถูก worker อื่นจองซ้ำ
+	claimLease = batchSize*sendTimeout + time.Minute
+)
+
+type Store interface {
+	ClaimEmails(ctx context.Context, limit int, lease time.Duration) ([]product.OutboxEmail, error)
+	MarkEmailSent(ctx context.Context, emailID string) error
+	RetryEmail(ctx context.Context, emailID, lastError string, retryAfter time.Duration) error
+}
+
+// Worker อ่านอีเมลที่ถึงเวลาส่งจาก outbox แล้วส่งผ่าน Sender ทีละฉบับ
+// อีเมลที่ส่งไม่สำเร็จจะถูกเลื่อนไปส่งใหม่ จนครบ product.MaxEmailAttempts ครั้ง
+type Worker struct {
+	store  Store
+	sender Sender
+	loc    *time.Location
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewWorker แสดงวันที่และเวลาในอีเมลตามเขตเวลา loc
+func NewWorker(store Store, sender Sender, loc *time.Location) *Worker {
+	return &Worker{
+		store:  store,
+		sender: sender,
+		loc:    loc,
+		stop:   make(chan struct{}),
+	}
+}
+
+func (w *Worker) Start() {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			// ส่งต่อทันทีถ้า batch เต็ม เพราะอาจยังมีอีเมลค้างอยู่
+			for w.sendBatch() == batchSize {
+				select {
+				case <-w.stop:
+					return
+				default:
+				}
+			}
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close รอให้ batch ที่กำลังส่งเสร็จก่อน อีเมลที่เหลือจะถูกส่งเมื่อเริ่มระบบครั้งถัดไป
+func (w *Worker) Close() {
+	close(w.stop)
+	w.wg.Wait()
+}
+
+func (w *Worker) sendBatch() int {
+	emails, err := w.store.ClaimEmails(context.Background(), batchSize, claimLease)
+	if err != nil {
+		log.Printf("failed to claim emails: %v", err)
+		return 0
+	}
+	for _, email := range emails {
+		w.send(email)
+	}
+	return len(emails)
+}
+
+func (w *Worker) send(email product.OutboxEmail) {
+	msg, err := Render(email, w.loc)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err = w.sender.Send(ctx, msg)
+		cancel()
+	}
+
+	if err != nil {
+		log.Printf("failed to send %s email %s (attempt %d): %v", email.Template, email.ID, email.Attempts, err)
+		if err := w.store.RetryEmail(context.Background(), email.ID, err.Error(), retryDelay(email.Attempts)); err != nil {
+			log.Printf("failed to reschedule email %s: %v", email.ID, err)
+		}
+		return
+	}
+	if err := w.store.MarkEmailSent(context.Background(), email.ID); err != nil {
+		log.Printf("failed to mark email %s as sent: %v", email.ID, err)
+	}
+}
+
+// retryDelay เริ่มที่ 1 นาทีและเพิ่มขึ้นเท่าตัวทุกครั้ง ไม่เกิน 1 ชั่วโมง
+func retryDelay(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
@@ -0,0 +1,20 @@
+// manual.go
+package payment
+
+import (
+	"context"
+	"log"
+)
+
+// ManualGateway ใช้เมื่อยังไม่ได้เชื่อมต่อผู้ให้บริการชำระเงิน การคืนเงินถือว่ารับเรื่องแล้ว
+// และฝ่ายบัญชีต้องโอนเงินคืนเองตามเลขอ้างอิงที่บันทึกไว้ในคำขอคืนสินค้า
+type ManualGateway struct{}
+
+func NewManualGateway() *ManualGateway {
+	return &ManualGateway{}
+}
+
+func (g *ManualGateway) Refund(ctx context.Context, refund Refund) (string, error) {
+	log.Printf("manual refund %s for order %s: %s %s", refund.ID, refund.OrderID, refund.Amount, refund.Amount.Currency())
+	return "manual-" + refund.ID, nil
+}
@@ -0,0 +1,37 @@
+// payment.go
+package payment
+
+import (
+	"context"
+	"fmt"
+
+	product "productproject/internal/product"
+)
+
+// Refund คือคำขอคืนเงินของคำสั่งซื้อ ID ใช้เป็น idempotency key
+// การส่ง Refund ที่มี ID เดิมซ้ำต้องไม่คืนเงินซ้ำ
+type Refund struct {
+	ID      string
+	OrderID string
+	Amount  product.Money
+	Reason  string
+}
+
+// Gateway คือผู้ให้บริการชำระเงิน
+type Gateway interface {
+	// Refund คืนเลขอ้างอิงของการคืนเงินจากผู้ให้บริการ
+	Refund(ctx context.Context, refund Refund) (string, error)
+}
+
+type Config struct {
+	Driver string // 'manual'
+}
+
+func New(cfg Config) (Gateway, error) {
+	switch cfg.Driver {
+	case "", "manual":
+		return NewManualGateway(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment driver: %s", cfg.Driver)
+	}
+}
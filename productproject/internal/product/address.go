@@ -0,0 +1,251 @@
+// address.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"productproject/internal/thaiaddress"
+)
+
+// PostalAddress คือที่อยู่สำหรับจัดส่งหรือออกใบเสร็จ ใช้ทั้งในสมุดที่อยู่และสำเนาในคำสั่งซื้อ
+type PostalAddress struct {
+	RecipientName string `json:"recipient_name" binding:"notblank,max=255"`
+	Phone         string `json:"phone" binding:"phone"`
+	Line1         string `json:"line1" binding:"notblank,max=255"` // บ้านเลขที่ หมู่ ซอย ถนน
+	Line2         string `json:"line2" binding:"max=255"`
+	Subdistrict   string `json:"subdistrict" binding:"notblank,max=100"` // ตำบล/แขวง
+	District      string `json:"district" binding:"notblank,max=100"`    // อำเภอ/เขต
+	Province      string `json:"province" binding:"notblank,max=100"`
+	Postcode      string `json:"postcode" binding:"len=5,numeric"`
+}
+
+type Address struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	PostalAddress
+	IsDefaultShipping bool      `json:"is_default_shipping"`
+	IsDefaultBilling  bool      `json:"is_default_billing"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// NewAddress ใช้ทั้งเพิ่มและแก้ไขที่อยู่ การแก้ไขจะแทนที่ค่าเดิมทั้งหมด
+type NewAddress struct {
+	Label string `json:"label" binding:"max=100"`
+	PostalAddress
+	IsDefaultShipping bool `json:"is_default_shipping"`
+	IsDefaultBilling  bool `json:"is_default_billing"`
+}
+
+// phonePattern รับเบอร์โทรศัพท์ไทย 9 หลัก (บ้าน) หรือ 10 หลัก (มือถือ) ขึ้นต้นด้วย 0 อนุญาตให้มีขีดคั่น
+var phonePattern = regexp.MustCompile(`^0[0-9]{1,2}-?[0-9]{3}-?[0-9]{3,4}$`)
+
+// Validate ตรวจ binding tag และตรวจว่าตำบล อำเภอ จังหวัด และรหัสไปรษณีย์ตรงกันตามชุดข้อมูลใน thaiaddress
+// ชื่อที่ผ่านการตรวจจะถูกแทนด้วยชื่อตามชุดข้อมูล เช่น "แขวงลุมพินี" เป็น "ลุมพินี"
+// จังหวัดที่ชุดข้อมูลยังไม่มีอำเภอและตำบล ตรวจเฉพาะชื่อจังหวัดและรหัสไปรษณีย์ แล้วรับอำเภอและตำบลตามที่กรอก
+func (a *NewAddress) Validate() error {
+	if err := ValidateStruct(a); err != nil {
+		return err
+	}
+
+	location, err := thaiaddress.Match(a.Province, a.District, a.Subdistrict, a.Postcode)
+	var fieldErr *thaiaddress.FieldError
+	if errors.As(err, &fieldErr) {
+		return invalidFields(FieldError{Field: fieldErr.Field, Message: fieldErr.Message})
+	}
+	if err != nil && !errors.Is(err, thaiaddress.ErrNotCovered) {
+		return err
+	}
+	a.Province, a.District, a.Subdistrict, a.Postcode = location.Province, location.District, location.Subdistrict, location.Postcode
+	return nil
+}
+
+const addressColumns = `address_id, COALESCE(label, ''), recipient_name, phone, line1, COALESCE(line2, ''),
+		       subdistrict, district, province, postcode, is_default_shipping, is_default_billing, created_at, updated_at`
+
+func scanAddress(row rowScanner) (Address, error) {
+	var a Address
+	err := row.Scan(&a.ID, &a.Label, &a.RecipientName, &a.Phone, &a.Line1, &a.Line2,
+		&a.Subdistrict, &a.District, &a.Province, &a.Postcode, &a.IsDefaultShipping, &a.IsDefaultBilling,
+		&a.CreatedAt, &a.UpdatedAt)
+	return a, err
+}
+
+func (pdb *PostgresDatabase) GetAddresses(ctx context.Context, userID string) ([]Address, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT `+addressColumns+`
+		FROM user_addresses
+		WHERE user_id = $1
+		ORDER BY is_default_shipping DESC, created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := []Address{}
+	for rows.Next() {
+		address, err := scanAddress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan address: %w", err)
+		}
+		addresses = append(addresses, address)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return addresses, nil
+}
+
+func (pdb *PostgresDatabase) GetAddress(ctx context.Context, userID, addressID string) (Address, error) {
+	return getAddress(ctx, pdb.db, userID, addressID)
+}
+
+func getAddress(ctx context.Context, q queryer, userID, addressID string) (Address, error) {
+	address, err := scanAddress(q.QueryRowContext(ctx, `
+		SELECT `+addressColumns+`
+		FROM user_addresses
+		WHERE user_id = $1 AND address_id = $2
+	`, userID, addressID))
+	if err == sql.ErrNoRows {
+		return Address{}, notFound("address not found")
+	}
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to get address: %w", err)
+	}
+	return address, nil
+}
+
+// CreateAddress ที่อยู่แรกของผู้ใช้จะเป็นที่อยู่เริ่มต้นทั้งการจัดส่งและใบเสร็จ
+func (pdb *PostgresDatabase) CreateAddress(ctx context.Context, userID string, address NewAddress) (Address, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_addresses WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return Address{}, fmt.Errorf("failed to count addresses: %w", err)
+	}
+	if count == 0 {
+		address.IsDefaultShipping, address.IsDefaultBilling = true, true
+	}
+	if err := clearDefaultAddresses(ctx, tx, userID, address); err != nil {
+		return Address{}, err
+	}
+
+	created, err := scanAddress(tx.QueryRowContext(ctx, `
+		INSERT INTO user_addresses (user_id, label, recipient_name, phone, line1, line2,
+		                            subdistrict, district, province, postcode, is_default_shipping, is_default_billing)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, $11, $12)
+		RETURNING `+addressColumns,
+		userID, address.Label, address.RecipientName, address.Phone, address.Line1, address.Line2,
+		address.Subdistrict, address.District, address.Province, address.Postcode,
+		address.IsDefaultShipping, address.IsDefaultBilling))
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to create address: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Address{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return created, nil
+}
+
+func (pdb *PostgresDatabase) UpdateAddress(ctx context.Context, userID, addressID string, address NewAddress) (Address, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := clearDefaultAddresses(ctx, tx, userID, address); err != nil {
+		return Address{}, err
+	}
+
+	updated, err := scanAddress(tx.QueryRowContext(ctx, `
+		UPDATE user_addresses
+		SET label = NULLIF($3, ''), recipient_name = $4, phone = $5, line1 = $6, line2 = NULLIF($7, ''),
+		    subdistrict = $8, district = $9, province = $10, postcode = $11,
+		    is_default_shipping = $12, is_default_billing = $13
+		WHERE user_id = $1 AND address_id = $2
+		RETURNING `+addressColumns,
+		userID, addressID, address.Label, address.RecipientName, address.Phone, address.Line1, address.Line2,
+		address.Subdistrict, address.District, address.Province, address.Postcode,
+		address.IsDefaultShipping, address.IsDefaultBilling))
+	if err == sql.ErrNoRows {
+		return Address{}, notFound("address not found")
+	}
+	if err != nil {
+		return Address{}, fmt.Errorf("failed to update address: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Address{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return updated, nil
+}
+
+// clearDefaultAddresses ยกเลิกที่อยู่เริ่มต้นเดิมก่อนตั้งที่อยู่ใหม่เป็นค่าเริ่มต้น
+func clearDefaultAddresses(ctx context.Context, tx *sql.Tx, userID string, address NewAddress) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE user_addresses
+		SET is_default_shipping = is_default_shipping AND NOT $2,
+		    is_default_billing = is_default_billing AND NOT $3
+		WHERE user_id = $1 AND ((is_default_shipping AND $2) OR (is_default_billing AND $3))
+	`, userID, address.IsDefaultShipping, address.IsDefaultBilling)
+	if err != nil {
+		return fmt.Errorf("failed to update default addresses: %w", err)
+	}
+	return nil
+}
+
+// DeleteAddress คำสั่งซื้อเดิมยังมีสำเนาที่อยู่อยู่ จึงลบได้เสมอ
+func (pdb *PostgresDatabase) DeleteAddress(ctx context.Context, userID, addressID string) error {
+	result, err := pdb.db.ExecContext(ctx, `
+		DELETE FROM user_addresses WHERE user_id = $1 AND address_id = $2
+	`, userID, addressID)
+	if err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return notFound("address not found")
+	}
+	return nil
+}
+
+// LookupPostcode คืนตำบล/แขวงที่ใช้รหัสไปรษณีย์นี้ สำหรับเติมที่อยู่อัตโนมัติ
+func LookupPostcode(postcode string) []thaiaddress.Location {
+	return thaiaddress.ByPostcode(postcode)
+}
+
+func (s *Store) GetAddresses(ctx context.Context, userID string) ([]Address, error) {
+	return s.db.GetAddresses(ctx, userID)
+}
+
+func (s *Store) GetAddress(ctx context.Context, userID, addressID string) (Address, error) {
+	return s.db.GetAddress(ctx, userID, addressID)
+}
+
+func (s *Store) CreateAddress(ctx context.Context, userID string, address NewAddress) (Address, error) {
+	return s.db.CreateAddress(ctx, userID, address)
+}
+
+func (s *Store) UpdateAddress(ctx context.Context, userID, addressID string, address NewAddress) (Address, error) {
+	return s.db.UpdateAddress(ctx, userID, addressID, address)
+}
+
+func (s *Store) DeleteAddress(ctx context.Context, userID, addressID string) error {
+	return s.db.DeleteAddress(ctx, userID, addressID)
+}
@@ -0,0 +1,301 @@
+// analytics.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ช่วงเวลาในการรวมยอดขาย ใช้เป็นหน่วยของ date_trunc ได้โดยตรง
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week" // เริ่มวันจันทร์ตาม ISO 8601
+	BucketMonth = "month"
+)
+
+const (
+	AnalyticsDateLayout   = "2006-01-02"
+	MaxAnalyticsRangeDays = 731
+	defaultAnalyticsDays  = 30
+)
+
+// นับเฉพาะคำสั่งซื้อที่ชำระเงินแล้ว
+const countedOrderStatuses = `o.status IN ('paid', 'shipped', 'delivered')`
+
+type SalesAnalyticsParams struct {
+	From   string `json:"from"`   // YYYY-MM-DD ตามเขตเวลาของร้าน (รวมวันนี้)
+	To     string `json:"to"`     // YYYY-MM-DD ตามเขตเวลาของร้าน (รวมวันนี้)
+	Bucket string `json:"bucket"` // 'day', 'week', 'month'
+	Top    int    `json:"top"`    // จำนวนสินค้าขายดีที่ต้องการ
+}
+
+type SalesSummary struct {
+	Revenue           Money `json:"revenue"`
+	UnitsSold         int   `json:"units_sold"`
+	OrderCount        int   `json:"order_count"`
+	AverageOrderValue Money `json:"average_order_value"`
+}
+
+// SalesBucket คือยอดขายหนึ่งช่วงเวลา BucketStart เป็นเวลาเริ่มต้นของวัน/สัปดาห์/เดือนตาม timezone ของร้าน
+// จึงต้องไม่ถูกแปลงเป็น timezone ของผู้ขอ มิฉะนั้นวันที่จะเลื่อน
+type SalesBucket struct {
+	BucketStart time.Time `json:"bucket_start" timezone:"shop"`
+	Revenue     Money     `json:"revenue"`
+	UnitsSold   int       `json:"units_sold"`
+	OrderCount  int       `json:"order_count"`
+}
+
+type ProductSales struct {
+	ProductID string `json:"product_id"`
+	Name      string `json:"name"`
+	UnitsSold int    `json:"units_sold"`
+	Revenue   Money  `json:"revenue"`
+}
+
+type CategorySales struct {
+	CategoryID int    `json:"category_id"`
+	Name       string `json:"name"`
+	UnitsSold  int    `json:"units_sold"`
+	OrderCount int    `json:"order_count"`
+	Revenue    Money  `json:"revenue"`
+}
+
+type SalesAnalytics struct {
+	SellerID    string          `json:"seller_id"`
+	Timezone    string          `json:"timezone"`
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Bucket      string          `json:"bucket"`
+	Summary     SalesSummary    `json:"summary"`
+	Series      []SalesBucket   `json:"series"`
+	TopProducts []ProductSales  `json:"top_products"`
+	Categories  []CategorySales `json:"categories"`
+}
+
+func (pdb *PostgresDatabase) GetShopAnalytics(ctx context.Context, sellerID string, params SalesAnalyticsParams) (SalesAnalytics, error) {
+	var timezone string
+	err := pdb.db.QueryRowContext(ctx, `SELECT timezone FROM sellers WHERE seller_id = $1`, sellerID).Scan(&timezone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return SalesAnalytics{}, notFound("shop not found")
+		}
+		return SalesAnalytics{}, fmt.Errorf("failed to get shop timezone: %w", err)
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return SalesAnalytics{}, fmt.Errorf("invalid shop timezone %q: %v", timezone, err)
+	}
+
+	// แปลงช่วงวันที่ตามเขตเวลาของร้านเป็นช่วงเวลาจริง [from, toExclusive)
+	from, toExclusive, err := analyticsRange(params, loc)
+	if err != nil {
+		return SalesAnalytics{}, err
+	}
+
+	bucket := params.Bucket
+	if bucket == "" {
+		bucket = BucketDay
+	}
+	top := params.Top
+	if top <= 0 {
+		top = 5
+	}
+
+	analytics := SalesAnalytics{
+		SellerID: sellerID,
+		Timezone: timezone,
+		From:     from.Format(AnalyticsDateLayout),
+		To:       toExclusive.AddDate(0, 0, -1).Format(AnalyticsDateLayout),
+		Bucket:   bucket,
+	}
+
+	// ยอดรวมทั้งช่วง
+	err = pdb.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(oi.quantity * oi.unit_price), 0),
+		       COALESCE(SUM(oi.quantity), 0),
+		       COUNT(DISTINCT oi.order_id),
+		       COALESCE(ROUND(SUM(oi.quantity * oi.unit_price) / NULLIF(COUNT(DISTINCT oi.order_id), 0), 2), 0)
+		FROM order_items oi
+		JOIN orders o ON o.order_id = oi.order_id
+		WHERE oi.seller_id = $1 AND `+countedOrderStatuses+`
+		  AND o.created_at >= $2 AND o.created_at < $3
+	`, sellerID, from, toExclusive).Scan(
+		&analytics.Summary.Revenue, &analytics.Summary.UnitsSold,
+		&analytics.Summary.OrderCount, &analytics.Summary.AverageOrderValue)
+	if err != nil {
+		return SalesAnalytics{}, fmt.Errorf("failed to get sales summary: %w", err)
+	}
+
+	analytics.Series, err = pdb.getSalesSeries(ctx, sellerID, bucket, from, toExclusive, timezone, loc)
+	if err != nil {
+		return SalesAnalytics{}, err
+	}
+
+	analytics.TopProducts, err = pdb.getTopProductSales(ctx, sellerID, from, toExclusive, top)
+	if err != nil {
+		return SalesAnalytics{}, err
+	}
+
+	analytics.Categories, err = pdb.getCategorySales(ctx, sellerID, from, toExclusive)
+	if err != nil {
+		return SalesAnalytics{}, err
+	}
+
+	return analytics, nil
+}
+
+// getSalesSeries สร้างทุกช่วงด้วย generate_series เพื่อให้ช่วงที่ไม่มียอดขายแสดงเป็น 0
+func (pdb *PostgresDatabase) getSalesSeries(ctx context.Context, sellerID, bucket string, from, toExclusive time.Time, timezone string, loc *time.Location) ([]SalesBucket, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		WITH buckets AS (
+			SELECT generate_series(
+				date_trunc($2, $3::timestamptz AT TIME ZONE $5),
+				date_trunc($2, ($4::timestamptz - INTERVAL '1 microsecond') AT TIME ZONE $5),
+				('1 ' || $2)::interval
+			) AS bucket_start
+		), sales AS (
+			SELECT date_trunc($2, o.created_at AT TIME ZONE $5) AS bucket_start,
+			       SUM(oi.quantity * oi.unit_price) AS revenue,
+			       SUM(oi.quantity) AS units_sold,
+			       COUNT(DISTINCT oi.order_id) AS order_count
+			FROM order_items oi
+			JOIN orders o ON o.order_id = oi.order_id
+			WHERE oi.seller_id = $1 AND `+countedOrderStatuses+`
+			  AND o.created_at >= $3 AND o.created_at < $4
+			GROUP BY 1
+		)
+		SELECT b.bucket_start, COALESCE(s.revenue, 0), COALESCE(s.units_sold, 0), COALESCE(s.order_count, 0)
+		FROM buckets b
+		LEFT JOIN sales s ON s.bucket_start = b.bucket_start
+		ORDER BY b.bucket_start
+	`, sellerID, bucket, from, toExclusive, timezone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sales series: %w", err)
+	}
+	defer rows.Close()
+
+	series := []SalesBucket{}
+	for rows.Next() {
+		var point SalesBucket
+		var local time.Time
+		if err := rows.Scan(&local, &point.Revenue, &point.UnitsSold, &point.OrderCount); err != nil {
+			return nil, fmt.Errorf("failed to scan sales bucket: %w", err)
+		}
+		// bucket_start เป็น timestamp ไม่มีเขตเวลา (เวลาท้องถิ่นของร้าน) จึงต้องกำหนด location ใหม่
+		point.BucketStart = time.Date(local.Year(), local.Month(), local.Day(),
+			local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), loc)
+		series = append(series, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return series, nil
+}
+
+func (pdb *PostgresDatabase) getTopProductSales(ctx context.Context, sellerID string, from, toExclusive time.Time, top int) ([]ProductSales, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT COALESCE(oi.product_id::text, ''), MAX(COALESCE(p.name, oi.product_name)),
+		       SUM(oi.quantity), SUM(oi.quantity * oi.unit_price) AS revenue
+		FROM order_items oi
+		JOIN orders o ON o.order_id = oi.order_id
+		LEFT JOIN products p ON p.product_id = oi.product_id
+		WHERE oi.seller_id = $1 AND `+countedOrderStatuses+`
+		  AND o.created_at >= $2 AND o.created_at < $3
+		GROUP BY oi.product_id
+		ORDER BY revenue DESC, SUM(oi.quantity) DESC
+		LIMIT $4
+	`, sellerID, from, toExclusive, top)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []ProductSales{}
+	for rows.Next() {
+		var sales ProductSales
+		if err := rows.Scan(&sales.ProductID, &sales.Name, &sales.UnitsSold, &sales.Revenue); err != nil {
+			return nil, fmt.Errorf("failed to scan product sales: %w", err)
+		}
+		products = append(products, sales)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return products, nil
+}
+
+func (pdb *PostgresDatabase) getCategorySales(ctx context.Context, sellerID string, from, toExclusive time.Time) ([]CategorySales, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT COALESCE(c.category_id, 0), COALESCE(c.name, ''),
+		       SUM(oi.quantity), COUNT(DISTINCT oi.order_id), SUM(oi.quantity * oi.unit_price) AS revenue
+		FROM order_items oi
+		JOIN orders o ON o.order_id = oi.order_id
+		LEFT JOIN products p ON p.product_id = oi.product_id
+		LEFT JOIN categories c ON c.category_id = p.category_id
+		WHERE oi.seller_id = $1 AND `+countedOrderStatuses+`
+		  AND o.created_at >= $2 AND o.created_at < $3
+		GROUP BY c.category_id, c.name
+		ORDER BY revenue DESC
+	`, sellerID, from, toExclusive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category sales: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []CategorySales{}
+	for rows.Next() {
+		var sales CategorySales
+		if err := rows.Scan(&sales.CategoryID, &sales.Name, &sales.UnitsSold, &sales.OrderCount, &sales.Revenue); err != nil {
+			return nil, fmt.Errorf("failed to scan category sales: %w", err)
+		}
+		categories = append(categories, sales)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return categories, nil
+}
+
+// analyticsRange คืนค่าเริ่มต้น 30 วันล่าสุดเมื่อไม่ได้ระบุช่วงวันที่
+func analyticsRange(params SalesAnalyticsParams, loc *time.Location) (time.Time, time.Time, error) {
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if params.To != "" {
+		parsed, err := time.ParseInLocation(AnalyticsDateLayout, params.To, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, invalid("invalid to date: %v", err)
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -(defaultAnalyticsDays - 1))
+	if params.From != "" {
+		parsed, err := time.ParseInLocation(AnalyticsDateLayout, params.From, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, invalid("invalid from date: %v", err)
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, invalid("from date must not be after to date")
+	}
+	if to.Sub(from) > MaxAnalyticsRangeDays*24*time.Hour {
+		return time.Time{}, time.Time{}, invalid("date range is too long")
+	}
+
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+func (s *Store) GetShopAnalytics(ctx context.Context, sellerID string, params SalesAnalyticsParams) (SalesAnalytics, error) {
+	return s.db.GetShopAnalytics(ctx, sellerID, params)
+}
@@ -0,0 +1,324 @@
+// cart.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type Cart struct {
+	ID        string     `json:"id"`
+	Items     []CartItem `json:"items"`
+	ItemCount int        `json:"item_count"`
+	Subtotal  Money      `json:"subtotal"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// ส่วนลดจากโปรโมชัน Promotions อธิบายทั้งโปรโมชันที่ใช้ได้และใช้ไม่ได้ (ดู promotion.go)
+	Discount   Money             `json:"discount"`
+	Total      Money             `json:"total"`
+	Promotions []PromotionResult `json:"promotions"`
+
+	// FreeShippingSellerIDs คือร้านที่ได้ส่งฟรีจากโปรโมชัน ร้านอื่นยังคิดค่าส่งตามปกติ
+	FreeShippingSellerIDs []string `json:"free_shipping_seller_ids"`
+}
+
+// CartItem แสดงราคาและสต็อกปัจจุบัน ราคาจะถูกบันทึกจริงตอนสั่งซื้อ
+type CartItem struct {
+	ID             string            `json:"id"`
+	ProductID      string            `json:"product_id"`
+	VariantID      string            `json:"variant_id,omitempty"`
+	Name           string            `json:"name"`
+	VariantSKU     string            `json:"variant_sku,omitempty"`
+	VariantOptions map[string]string `json:"variant_options,omitempty"`
+	UnitPrice      Money             `json:"unit_price"`
+	Quantity       int               `json:"quantity"`
+	LineTotal      Money             `json:"line_total"`
+	Available      int               `json:"available"`
+	InStock        bool              `json:"in_stock"`
+	ImageURL       string            `json:"image_url"`
+	AddedAt        time.Time         `json:"added_at"`
+	SellerID       string            `json:"seller_id"`
+
+	// ใช้ตรวจขอบเขตของโปรโมชัน
+	CategoryID  int    `json:"-"`
+	ProductType string `json:"-"`
+
+	// SaleID คือราคาลดที่ใช้กับรายการนี้ (ดู sale.go) ใช้นับจำนวนที่ขายได้ของ flash sale ตอนสั่งซื้อ
+	SaleID string `json:"-"`
+}
+
+type NewCartItem struct {
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id"` // จำเป็นเมื่อสินค้ามีตัวเลือก
+	Quantity  int    `json:"quantity"`
+}
+
+type UpdateCartItem struct {
+	Quantity int `json:"quantity"`
+}
+
+// GetCart คืนตะกร้าว่างถ้าผู้ใช้ยังไม่เคยเพิ่มสินค้า
+func (pdb *PostgresDatabase) GetCart(ctx context.Context, userID string) (Cart, error) {
+	var cart Cart
+	err := pdb.db.QueryRowContext(ctx, `
+		SELECT cart_id, updated_at FROM carts WHERE user_id = $1
+	`, userID).Scan(&cart.ID, &cart.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return Cart{Items: []CartItem{}, Promotions: []PromotionResult{}, FreeShippingSellerIDs: []string{}}, nil
+	}
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	cart.Items, err = pdb.getCartItems(ctx, cart.ID)
+	if err != nil {
+		return Cart{}, err
+	}
+	for _, item := range cart.Items {
+		cart.ItemCount += item.Quantity
+		cart.Subtotal = cart.Subtotal.Add(item.LineTotal)
+	}
+
+	if err := pdb.applyCartPromotions(ctx, userID, &cart); err != nil {
+		return Cart{}, err
+	}
+
+	return cart, nil
+}
+
+// AddCartItem เพิ่มจำนวนถ้ามีสินค้า (และตัวเลือกเดียวกัน) อยู่ในตะกร้าแล้ว
+func (pdb *PostgresDatabase) AddCartItem(ctx context.Context, userID string, item NewCartItem) (Cart, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	available, err := cartItemStock(ctx, tx, item.ProductID, item.VariantID)
+	if err != nil {
+		return Cart{}, err
+	}
+
+	var cartID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO carts (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET updated_at = NOW()
+		RETURNING cart_id
+	`, userID).Scan(&cartID)
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	var quantity int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO cart_items (cart_id, product_id, variant_id, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (cart_id, product_id, variant_id) DO UPDATE
+		SET quantity = cart_items.quantity + EXCLUDED.quantity, updated_at = NOW()
+		RETURNING quantity
+	`, cartID, item.ProductID, nullString(item.VariantID), item.Quantity).Scan(&quantity)
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to add cart item: %w", err)
+	}
+	if quantity > available {
+		return Cart{}, conflict("only %d left in stock", available)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Cart{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pdb.GetCart(ctx, userID)
+}
+
+func (pdb *PostgresDatabase) UpdateCartItem(ctx context.Context, userID, itemID string, update UpdateCartItem) (Cart, error) {
+	var productID string
+	var variantID sql.NullString
+	err := pdb.db.QueryRowContext(ctx, `
+		SELECT ci.product_id, ci.variant_id
+		FROM cart_items ci
+		JOIN carts c ON c.cart_id = ci.cart_id
+		WHERE ci.cart_item_id = $1 AND c.user_id = $2
+	`, itemID, userID).Scan(&productID, &variantID)
+	if err == sql.ErrNoRows {
+		return Cart{}, notFound("cart item not found")
+	}
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to get cart item: %w", err)
+	}
+
+	available, err := cartItemStock(ctx, pdb.db, productID, variantID.String)
+	if err != nil {
+		return Cart{}, err
+	}
+	if update.Quantity > available {
+		return Cart{}, conflict("only %d left in stock", available)
+	}
+
+	_, err = pdb.db.ExecContext(ctx, `
+		UPDATE cart_items SET quantity = $1, updated_at = NOW() WHERE cart_item_id = $2
+	`, update.Quantity, itemID)
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to update cart item: %w", err)
+	}
+
+	return pdb.GetCart(ctx, userID)
+}
+
+func (pdb *PostgresDatabase) RemoveCartItem(ctx context.Context, userID, itemID string) (Cart, error) {
+	result, err := pdb.db.ExecContext(ctx, `
+		DELETE FROM cart_items ci
+		USING carts c
+		WHERE c.cart_id = ci.cart_id AND ci.cart_item_id = $1 AND c.user_id = $2
+	`, itemID, userID)
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to remove cart item: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return Cart{}, notFound("cart item not found")
+	}
+
+	return pdb.GetCart(ctx, userID)
+}
+
+// cartItemStock ตรวจว่าสินค้าเปิดขายอยู่ และคืนจำนวนที่มีในสต็อก
+// สินค้าที่มีตัวเลือกต้องระบุ variantID และใช้สต็อกของตัวเลือกนั้นแทนสต็อกของสินค้าหลัก
+func cartItemStock(ctx context.Context, q queryer, productID, variantID string) (int, error) {
+	var availability string
+	var productStock, activeVariants int
+	err := q.QueryRowContext(ctx, `
+		SELECT p.availability, COALESCE(i.quantity, 0),
+		       (SELECT COUNT(*) FROM product_variants v WHERE v.product_id = p.product_id AND v.is_active)
+		FROM products p
+		LEFT JOIN inventory i ON i.product_id = p.product_id
+		WHERE p.product_id = $1
+	`, productID).Scan(&availability, &productStock, &activeVariants)
+	if err == sql.ErrNoRows {
+		return 0, notFound("product not found")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get product: %w", err)
+	}
+	if availability != "active" {
+		return 0, conflict("product is not available")
+	}
+
+	if variantID == "" {
+		if activeVariants > 0 {
+			return 0, invalid("variant_id is required for this product")
+		}
+		return productStock, nil
+	}
+
+	var isActive bool
+	var variantStock int
+	err = q.QueryRowContext(ctx, `
+		SELECT v.is_active, COALESCE(vi.quantity, 0)
+		FROM product_variants v
+		LEFT JOIN variant_inventory vi ON vi.variant_id = v.variant_id
+		WHERE v.product_id = $1 AND v.variant_id = $2
+	`, productID, variantID).Scan(&isActive, &variantStock)
+	if err == sql.ErrNoRows {
+		return 0, notFound("product variant not found")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get product variant: %w", err)
+	}
+	if !isActive {
+		return 0, conflict("product variant is not available")
+	}
+	return variantStock, nil
+}
+
+func (pdb *PostgresDatabase) getCartItems(ctx context.Context, cartID string) ([]CartItem, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT ci.cart_item_id, p.product_id, COALESCE(v.variant_id::text, ''), p.name,
+		       COALESCE(v.sku, ''), v.option_values,
+		       COALESCE(v.price, aps.sale_price, p.price), ci.quantity,
+		       CASE WHEN v.variant_id IS NULL THEN COALESCE(i.quantity, 0) ELSE COALESCE(vi.quantity, 0) END,
+		       p.availability = 'active' AND COALESCE(v.is_active, TRUE),
+		       COALESCE((
+		           SELECT pi.image_url FROM product_images pi
+		           WHERE pi.product_id = p.product_id
+		           ORDER BY pi.variant_id = v.variant_id DESC NULLS LAST, pi.is_primary DESC, pi.sort_order ASC
+		           LIMIT 1
+		       ), ''),
+		       ci.added_at, p.seller_id, COALESCE(p.category_id, 0), p.product_type,
+		       COALESCE(CASE WHEN v.price IS NULL THEN aps.sale_id::text END, '')
+		FROM cart_items ci
+		JOIN products p ON p.product_id = ci.product_id
+		LEFT JOIN inventory i ON i.product_id = p.product_id
+		LEFT JOIN product_variants v ON v.variant_id = ci.variant_id
+		LEFT JOIN variant_inventory vi ON vi.variant_id = v.variant_id
+		LEFT JOIN active_product_sales aps ON aps.product_id = p.product_id
+		WHERE ci.cart_id = $1
+		ORDER BY ci.added_at ASC
+	`, cartID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []CartItem{}
+	for rows.Next() {
+		var item CartItem
+		var options []byte
+		var sellable bool
+		if err := rows.Scan(&item.ID, &item.ProductID, &item.VariantID, &item.Name,
+			&item.VariantSKU, &options, &item.UnitPrice, &item.Quantity,
+			&item.Available, &sellable, &item.ImageURL, &item.AddedAt,
+			&item.SellerID, &item.CategoryID, &item.ProductType, &item.SaleID); err != nil {
+			return nil, fmt.Errorf("failed to scan cart item: %w", err)
+		}
+		if options != nil {
+			if err := json.Unmarshal(options, &item.VariantOptions); err != nil {
+				return nil, fmt.Errorf("failed to decode variant options: %w", err)
+			}
+		}
+		item.LineTotal = item.UnitPrice.Mul(item.Quantity)
+		item.InStock = sellable && item.Available >= item.Quantity
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over cart items: %w", err)
+	}
+
+	productIDs := make([]string, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	names, err := pdb.localizedProductNames(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Name = translated(names[items[i].ProductID], items[i].Name)
+	}
+
+	return items, nil
+}
+
+func (s *Store) GetCart(ctx context.Context, userID string) (Cart, error) {
+	return s.db.GetCart(ctx, userID)
+}
+
+func (s *Store) AddCartItem(ctx context.Context, userID string, item NewCartItem) (Cart, error) {
+	return s.db.AddCartItem(ctx, userID, item)
+}
+
+func (s *Store) UpdateCartItem(ctx context.Context, userID, itemID string, update UpdateCartItem) (Cart, error) {
+	return s.db.UpdateCartItem(ctx, userID, itemID, update)
+}
+
+func (s *Store) RemoveCartItem(ctx context.Context, userID, itemID string) (Cart, error) {
+	return s.db.RemoveCartItem(ctx, userID, itemID)
+}
@@ -0,0 +1,145 @@
+// currency.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"regexp"
+	"time"
+)
+
+// ExchangeRate ใช้แปลงราคาเพื่อแสดงผลเท่านั้น การสั่งซื้อและชำระเงินยังเป็น DefaultCurrency เสมอ
+// Rate คือจำนวนบาทต่อ 1 หน่วยของ Currency เช่น USD "36.25" หมายถึง 1 USD = 36.25 บาท
+type ExchangeRate struct {
+	Currency  string    `json:"currency" binding:"currency_code"`
+	Rate      string    `json:"rate" binding:"exchange_rate"` // NUMERIC(18, 6) ส่งเป็น string เพื่อไม่ให้เสียความละเอียด
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SetExchangeRates ใช้ตอนผู้ดูแลระบบแก้ไขอัตราแลกเปลี่ยนหลายสกุลพร้อมกัน
+type SetExchangeRates struct {
+	Rates []ExchangeRate `json:"rates" binding:"required,min=1,dive"`
+}
+
+var (
+	currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+	exchangeRatePattern = regexp.MustCompile(`^[0-9]{1,12}(\.[0-9]{1,6})?$`)
+)
+
+func validCurrencyCode(code string) bool {
+	return currencyCodePattern.MatchString(code) && code != DefaultCurrency
+}
+
+func validExchangeRate(rate string) bool {
+	if !exchangeRatePattern.MatchString(rate) {
+		return false
+	}
+	r, ok := new(big.Rat).SetString(rate)
+	return ok && r.Sign() > 0
+}
+
+// Convert แปลงจำนวนเงินสกุล DefaultCurrency เป็นสกุลของ rate เพื่อแสดงผล
+// ปัดเศษแบบ half-up (ปัดออกจากศูนย์เมื่อเศษเท่ากับครึ่ง) ที่ทศนิยม 2 ตำแหน่งของสกุลปลายทาง
+// ผลลัพธ์จำราคาเดิมไว้ใน Base และไม่ควรนำไปคำนวณต่อ
+func (m Money) Convert(rate ExchangeRate) (Money, error) {
+	if m.Currency() != DefaultCurrency {
+		return Money{}, fmt.Errorf("cannot convert %s, only %s prices can be converted", m.Currency(), DefaultCurrency)
+	}
+	r, ok := new(big.Rat).SetString(rate.Rate)
+	if !ok || r.Sign() <= 0 {
+		return Money{}, fmt.Errorf("invalid exchange rate %q for %s", rate.Rate, rate.Currency)
+	}
+
+	minor, ok := roundHalfUp(new(big.Rat).Quo(new(big.Rat).SetInt64(m.minor), r))
+	if !ok {
+		return Money{}, fmt.Errorf("converted amount is out of range")
+	}
+
+	base := m
+	return Money{minor: minor, currency: rate.Currency, base: &base}, nil
+}
+
+// Base คืนราคาเดิมก่อนแปลงสกุลเงิน ok เป็น false ถ้า m ไม่ได้มาจาก Convert
+func (m Money) Base() (Money, bool) {
+	if m.base == nil {
+		return Money{}, false
+	}
+	return *m.base, true
+}
+
+func (pdb *PostgresDatabase) GetExchangeRates(ctx context.Context) ([]ExchangeRate, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT currency, rate::text, updated_at FROM exchange_rates ORDER BY currency
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := []ExchangeRate{}
+	for rows.Next() {
+		var rate ExchangeRate
+		if err := rows.Scan(&rate.Currency, &rate.Rate, &rate.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return rates, nil
+}
+
+func (pdb *PostgresDatabase) GetExchangeRate(ctx context.Context, currency string) (ExchangeRate, error) {
+	var rate ExchangeRate
+	err := pdb.db.QueryRowContext(ctx, `
+		SELECT currency, rate::text, updated_at FROM exchange_rates WHERE currency = $1
+	`, currency).Scan(&rate.Currency, &rate.Rate, &rate.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ExchangeRate{}, notFound("no exchange rate for currency %s", currency)
+	}
+	if err != nil {
+		return ExchangeRate{}, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+	return rate, nil
+}
+
+// SetExchangeRates เพิ่มหรือแก้ไขอัตราแลกเปลี่ยนใน transaction เดียว สกุลที่ไม่ได้ส่งมายังคงค่าเดิม
+func (pdb *PostgresDatabase) SetExchangeRates(ctx context.Context, rates []ExchangeRate, updatedBy string) ([]ExchangeRate, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, rate := range rates {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO exchange_rates (currency, rate, updated_by)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (currency)
+			DO UPDATE SET rate = EXCLUDED.rate, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+		`, rate.Currency, rate.Rate, nullString(updatedBy))
+		if err != nil {
+			return nil, fmt.Errorf("failed to save exchange rate %s: %w", rate.Currency, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pdb.GetExchangeRates(ctx)
+}
+
+func (s *Store) GetExchangeRates(ctx context.Context) ([]ExchangeRate, error) {
+	return s.db.GetExchangeRates(ctx)
+}
+
+func (s *Store) GetExchangeRate(ctx context.Context, currency string) (ExchangeRate, error) {
+	return s.db.GetExchangeRate(ctx, currency)
+}
+
+func (s *Store) SetExchangeRates(ctx context.Context, rates []ExchangeRate, updatedBy string) ([]ExchangeRate, error) {
+	return s.db.SetExchangeRates(ctx, rates, updatedBy)
+}
@@ -0,0 +1,54 @@
+package ecommerce
+
+import "testing"
+
+func TestMoneyConvert(t *testing.T) {
+	tests := []struct {
+		name    string
+		price   Money
+		rate    ExchangeRate
+		want    int64
+		wantErr bool
+	}{
+		{name: "exact", price: NewMoney(3625, DefaultCurrency), rate: ExchangeRate{Currency: "USD", Rate: "36.25"}, want: 100},
+		{name: "rounds down", price: NewMoney(10000, DefaultCurrency), rate: ExchangeRate{Currency: "USD", Rate: "36.25"}, want: 276},
+		{name: "rounds half up", price: NewMoney(5, DefaultCurrency), rate: ExchangeRate{Currency: "EUR", Rate: "2"}, want: 3},
+		{name: "rate below one", price: NewMoney(100, DefaultCurrency), rate: ExchangeRate{Currency: "JPY", Rate: "0.25"}, want: 400},
+		{name: "negative amount", price: NewMoney(-5, DefaultCurrency), rate: ExchangeRate{Currency: "EUR", Rate: "2"}, want: -3},
+		{name: "zero rate", price: NewMoney(100, DefaultCurrency), rate: ExchangeRate{Currency: "USD", Rate: "0"}, wantErr: true},
+		{name: "invalid rate", price: NewMoney(100, DefaultCurrency), rate: ExchangeRate{Currency: "USD", Rate: "abc"}, wantErr: true},
+		{name: "not default currency", price: NewMoney(100, "USD"), rate: ExchangeRate{Currency: "EUR", Rate: "1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.price.Convert(tt.rate)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Convert(%s) = %s, want error", tt.rate.Rate, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Convert(%s) error: %v", tt.rate.Rate, err)
+			}
+			if got.Minor() != tt.want || got.Currency() != tt.rate.Currency {
+				t.Errorf("Convert(%s) = %d %s, want %d %s", tt.rate.Rate, got.Minor(), got.Currency(), tt.want, tt.rate.Currency)
+			}
+
+			base, ok := got.Base()
+			if !ok {
+				t.Fatal("Base() ok = false, want the original price")
+			}
+			if base.Cmp(tt.price) != 0 || base.Currency() != DefaultCurrency {
+				t.Errorf("Base() = %s %s, want %s %s", base, base.Currency(), tt.price, DefaultCurrency)
+			}
+		})
+	}
+}
+
+func TestMoneyBaseWithoutConvert(t *testing.T) {
+	if _, ok := NewMoney(100, DefaultCurrency).Base(); ok {
+		t.Error("Base() ok = true for a price that was never converted")
+	}
+}
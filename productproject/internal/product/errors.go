@@ -0,0 +1,95 @@
+// errors.go
+package ecommerce
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ชนิดของข้อผิดพลาดที่เกิดจากข้อมูลของผู้ใช้ ใช้ errors.Is ตรวจได้ และ handler ใช้เลือก HTTP status
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrForeignKey = errors.New("foreign key violation")
+)
+
+// Error คือข้อผิดพลาดที่บอกชนิดได้ Message ส่งให้ผู้ใช้ได้โดยตรงเพราะไม่มีรายละเอียดภายในระบบ
+type Error struct {
+	Kind    error
+	Message string
+	Fields  []FieldError // มีเฉพาะ ErrValidation ที่ตรวจจาก binding tag
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func (e *Error) Unwrap() error { return e.Kind }
+
+func notFound(format string, args ...interface{}) error {
+	return &Error{Kind: ErrNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func conflict(format string, args ...interface{}) error {
+	return &Error{Kind: ErrConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+func invalid(format string, args ...interface{}) error {
+	return &Error{Kind: ErrValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// keyDetail อ่านชื่อคอลัมน์และค่าจาก detail ของ Postgres เช่น `Key (sku)=(A-1) already exists.`
+var keyDetail = regexp.MustCompile(`^Key \((.+?)\)=\((.*?)\)`)
+
+// Classify คืน *Error ถ้า err เกิดจากข้อมูลของผู้ใช้ รวมถึง error ของ Postgres ที่ห่อไว้ด้วย %w
+// เช่น SKU ซ้ำหรือ UUID ผิดรูปแบบ ซึ่งจะถูกแปลงเป็นข้อความที่ไม่เปิดเผยรายละเอียดของฐานข้อมูล
+// คืน nil ถ้าเป็นข้อผิดพลาดภายในระบบ
+func Classify(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Error{Kind: ErrNotFound, Message: "resource not found"}
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return nil
+	}
+
+	column, value := "value", ""
+	if m := keyDetail.FindStringSubmatch(pqErr.Detail); m != nil {
+		column, value = m[1], m[2]
+	}
+
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		return &Error{Kind: ErrConflict, Message: fmt.Sprintf("%s %q already exists", column, value)}
+	case "foreign_key_violation":
+		if strings.Contains(pqErr.Detail, "is still referenced") {
+			return &Error{Kind: ErrConflict, Message: "resource is still referenced by other records"}
+		}
+		return &Error{Kind: ErrForeignKey, Message: fmt.Sprintf("%s %q does not exist", column, value)}
+	case "invalid_text_representation":
+		if strings.Contains(pqErr.Message, "type uuid") {
+			return &Error{Kind: ErrValidation, Message: "invalid id format"}
+		}
+		return &Error{Kind: ErrValidation, Message: "invalid input value"}
+	case "not_null_violation":
+		return &Error{Kind: ErrValidation, Message: fmt.Sprintf("%s is required", pqErr.Column)}
+	case "exclusion_violation":
+		return &Error{Kind: ErrConflict, Message: "value overlaps an existing record"}
+	case "check_violation":
+		return &Error{Kind: ErrValidation, Message: fmt.Sprintf("value violates %s", pqErr.Constraint)}
+	case "string_data_right_truncation":
+		return &Error{Kind: ErrValidation, Message: "value is too long"}
+	case "numeric_value_out_of_range":
+		return &Error{Kind: ErrValidation, Message: "number is out of range"}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+// events.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Event คือเหตุการณ์ที่เกิดขึ้นแล้วในระบบ ถูกเขียนลง domain_events ใน transaction เดียวกับการเปลี่ยนแปลง
+// แล้ว worker ใน internal/events จะส่งต่อให้ subscriber ภายหลัง subscriber อาจได้รับ event ซ้ำ
+// เมื่อการส่งครั้งก่อนล้มเหลว จึงต้องทำงานซ้ำได้โดยไม่เกิดผลซ้ำ
+type Event interface {
+	EventType() string
+	AggregateID() string // id ของสินค้าหรือคำสั่งซื้อที่ event นี้เกี่ยวข้อง
+}
+
+// ชนิดของ event ค่าของ stock events ต้องตรงกับ trigger publish_stock_events ใน init.sql
+const (
+	EventProductCreated      = "product.created"
+	EventProductUpdated      = "product.updated"
+	EventProductDeleted      = "product.deleted"
+	EventProductPriceChanged = "product.price_changed"
+	EventStockChanged        = "inventory.stock_changed"
+	EventStockDepleted       = "inventory.stock_depleted"
+	EventOrderPlaced         = "order.placed"
+	EventOrderStatusChanged  = "order.status_changed"
+)
+
+// MaxEventAttempts คือจำนวนครั้งที่พยายามส่ง event ก่อนเลิกและเปลี่ยนสถานะเป็น failed
+const MaxEventAttempts = 10
+
+type ProductCreated struct {
+	ProductID string `json:"product_id"`
+	SellerID  string `json:"seller_id"`
+	SKU       string `json:"sku"`
+	Name      string `json:"name"`
+	Price     Money  `json:"price"`
+}
+
+// ProductUpdated เกิดทุกครั้งที่แก้ไขสินค้า ถ้าราคาเปลี่ยนจะมี ProductPriceChanged ตามมาด้วย
+type ProductUpdated struct {
+	ProductID string `json:"product_id"`
+	SellerID  string `json:"seller_id"`
+}
+
+type ProductDeleted struct {
+	ProductID string `json:"product_id"`
+	SellerID  string `json:"seller_id"`
+}
+
+// ProductPriceChanged คือการเปลี่ยนราคาหลักของสินค้า ไม่รวมราคาลดตามช่วงเวลา
+type ProductPriceChanged struct {
+	ProductID string `json:"product_id"`
+	SellerID  string `json:"seller_id"`
+	OldPrice  Money  `json:"old_price"`
+	NewPrice  Money  `json:"new_price"`
+}
+
+// StockChanged สร้างโดย trigger บน inventory และ variant_inventory VariantID ว่างสำหรับสต็อกของสินค้าหลัก
+type StockChanged struct {
+	ProductID   string `json:"product_id"`
+	VariantID   string `json:"variant_id,omitempty"`
+	OldQuantity int    `json:"old_quantity"`
+	NewQuantity int    `json:"new_quantity"`
+}
+
+// StockDepleted สร้างโดย trigger เมื่อสต็อกลดลงเหลือ 0
+type StockDepleted struct {
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id,omitempty"`
+}
+
+type OrderPlaced struct {
+	OrderID   string   `json:"order_id"`
+	UserID    string   `json:"user_id"`
+	SellerIDs []string `json:"seller_ids"`
+	Total     Money    `json:"total"`
+}
+
+type OrderStatusChanged struct {
+	OrderID    string `json:"order_id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+}
+
+func (e ProductCreated) EventType() string        { return EventProductCreated }
+func (e ProductUpdated) EventType() string        { return EventProductUpdated }
+func (e ProductDeleted) EventType() string        { return EventProductDeleted }
+func (e ProductPriceChanged) EventType() string   { return EventProductPriceChanged }
+func (e StockChanged) EventType() string          { return EventStockChanged }
+func (e StockDepleted) EventType() string         { return EventStockDepleted }
+func (e OrderPlaced) EventType() string           { return EventOrderPlaced }
+func (e OrderStatusChanged) EventType() string    { return EventOrderStatusChanged }
+func (e ProductCreated) AggregateID() string      { return e.ProductID }
+func (e ProductUpdated) AggregateID() string      { return e.ProductID }
+func (e ProductDeleted) AggregateID() string      { return e.ProductID }
+func (e ProductPriceChanged) AggregateID() string { return e.ProductID }
+func (e StockChanged) AggregateID() string        { return e.ProductID }
+func (e StockDepleted) AggregateID() string       { return e.ProductID }
+func (e OrderPlaced) AggregateID() string         { return e.OrderID }
+func (e OrderStatusChanged) AggregateID() string  { return e.OrderID }
+
+// eventDecoders แปลง payload ใน domain_events กลับเป็น event ตามชนิด
+var eventDecoders = map[string]func([]byte) (Event, error){
+	EventProductCreated:      decodeEvent[ProductCreated],
+	EventProductUpdated:      decodeEvent[ProductUpdated],
+	EventProductDeleted:      decodeEvent[ProductDeleted],
+	EventProductPriceChanged: decodeEvent[ProductPriceChanged],
+	EventStockChanged:        decodeEvent[StockChanged],
+	EventStockDepleted:       decodeEvent[StockDepleted],
+	EventOrderPlaced:         decodeEvent[OrderPlaced],
+	EventOrderStatusChanged:  decodeEvent[OrderStatusChanged],
+}
+
+func decodeEvent[T Event](payload []byte) (Event, error) {
+	var event T
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// QueuedEvent คือ event ที่รอส่งใน domain_events
+type QueuedEvent struct {
+	ID          string
+	Type        string
+	AggregateID string
+	Payload     json.RawMessage
+	Attempts    int // รวมครั้งที่กำลังส่งอยู่
+	OccurredAt  time.Time
+}
+
+// Event แปลง payload เป็น event ตาม Type
+func (q QueuedEvent) Event() (Event, error) {
+	decode, ok := eventDecoders[q.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type: %s", q.Type)
+	}
+	event, err := decode(q.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", q.Type, err)
+	}
+	return event, nil
+}
+
+// publish เขียน event ลง domain_events ใน transaction เดียวกับการเปลี่ยนแปลง
+// ถ้า transaction ถูก rollback event จะหายไปด้วย subscriber จึงไม่เห็นการเปลี่ยนแปลงที่ไม่ได้เกิดขึ้นจริง
+func publish(ctx context.Context, tx *sql.Tx, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event.EventType(), err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO domain_events (event_type, aggregate_id, payload) VALUES ($1, $2, $3)
+	`, event.EventType(), event.AggregateID(), data)
+	if err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", event.EventType(), err)
+	}
+	return nil
+}
+
+// publishProductUpdate เขียน ProductUpdated และ ProductPriceChanged ถ้าราคาเปลี่ยน
+func publishProductUpdate(ctx context.Context, tx *sql.Tx, productID, sellerID string, oldPrice, newPrice Money) error {
+	if err := publish(ctx, tx, ProductUpdated{ProductID: productID, SellerID: sellerID}); err != nil {
+		return err
+	}
+	if oldPrice.Cmp(newPrice) == 0 {
+		return nil
+	}
+	return publish(ctx, tx, ProductPriceChanged{ProductID: productID, SellerID: sellerID, OldPrice: oldPrice, NewPrice: newPrice})
+}
+
+// ClaimEvents จอง event ที่ถึงเวลาส่งไม่เกิน limit รายการตามลำดับที่เกิด โดยเลื่อน next_attempt_at ออกไปเท่ากับ lease
+// ถ้า worker หยุดทำงานระหว่างส่ง event จะกลับมาให้ส่งใหม่เมื่อหมด lease
+func (pdb *PostgresDatabase) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]QueuedEvent, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		UPDATE domain_events
+		SET attempts = attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE event_id IN (
+			SELECT event_id FROM domain_events
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY occurred_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING event_id, event_type, aggregate_id, payload, attempts, occurred_at
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []QueuedEvent{}
+	for rows.Next() {
+		var event QueuedEvent
+		if err := rows.Scan(&event.ID, &event.Type, &event.AggregateID, &event.Payload, &event.Attempts, &event.OccurredAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	// UPDATE ... RETURNING ไม่รับประกันลำดับ
+	sort.Slice(events, func(i, j int) bool { return events[i].OccurredAt.Before(events[j].OccurredAt) })
+	return events, nil
+}
+
+func (pdb *PostgresDatabase) MarkEventProcessed(ctx context.Context, eventID string) error {
+	_, err := pdb.db.ExecContext(ctx, `
+		UPDATE domain_events SET status = 'processed', processed_at = NOW(), last_error = NULL WHERE event_id = $1
+	`, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to mark event processed: %w", err)
+	}
+	return nil
+}
+
+// RetryEvent บันทึกข้อผิดพลาดและเลื่อนการส่งครั้งถัดไปออกไป retryAfter
+// เมื่อพยายามครบ MaxEventAttempts แล้วจะเปลี่ยนเป็น failed และไม่ส่งอีก
+func (pdb *PostgresDatabase) RetryEvent(ctx context.Context, eventID, lastError string, retryAfter time.Duration) error {
+	_, err := pdb.db.ExecContext(ctx, `
+		UPDATE domain_events
+		SET status = CASE WHEN attempts >= $4 THEN 'failed'::domain_event_status ELSE 'pending'::domain_event_status END,
+		    last_error = $2,
+		    next_attempt_at = NOW() + $3 * INTERVAL '1 second'
+		WHERE event_id = $1
+	`, eventID, lastError, retryAfter.Seconds(), MaxEventAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule event: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]QueuedEvent, error) {
+	return s.db.ClaimEvents(ctx, limit, lease)
+}
+
+func (s *Store) MarkEventProcessed(ctx context.Context, eventID string) error {
+	return s.db.MarkEventProcessed(ctx, eventID)
+}
+
+func (s *Store) RetryEvent(ctx context.Context, eventID, lastError string, retryAfter time.Duration) error {
+	return s.db.RetryEvent(ctx, eventID, lastError, retryAfter)
+}
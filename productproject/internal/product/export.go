@@ -0,0 +1,126 @@
+// export.go
+package ecommerce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ExportProduct คือสินค้าหนึ่งแถวในไฟล์ส่งออก field ของ CatalogProduct ใช้ชื่อเดียวกับไฟล์นำเข้า
+// จึงนำไฟล์ที่ส่งออกไปนำเข้าแบบ upsert ได้ทันที field ที่เหลือมีไว้อ่านอย่างเดียว
+type ExportProduct struct {
+	CatalogProduct
+	ID           string    `json:"product_id"`
+	CategoryName string    `json:"category_name"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ExportProducts อ่านสินค้าตามตัวกรองเดียวกับ GetProducts (ไม่แบ่งหน้า) แล้วส่งให้ fn ทีละแถว
+// โดยไม่เก็บทั้งแคตตาล็อกไว้ในหน่วยความจำ ถ้า fn คืน error จะหยุดอ่านทันที
+// ข้อมูลที่ไม่ถูกส่งออกเพราะนำเข้ากลับไม่ได้หรือไม่ใช่ข้อมูลของแคตตาล็อก:
+//   - option ของสินค้าที่มีหลายรายการ จะส่งออกเฉพาะ option แรก เพราะไฟล์นำเข้ารองรับ option เดียวต่อสินค้า
+//   - alt text ของรูป และการผูกรูปกับตัวเลือก เพราะรูปถูกสร้างใหม่ตอนนำเข้า
+//   - ราคาลดตามช่วงเวลา รีวิว และคะแนน ซึ่งจัดการแยกจากแคตตาล็อก
+func (pdb *PostgresDatabase) ExportProducts(ctx context.Context, params ProductQueryParams, fn func(ExportProduct) error) error {
+	query := `
+		SELECT p.product_id, p.sku, p.name, COALESCE(p.description, ''), COALESCE(p.brand, ''),
+		       COALESCE(p.model_number, ''), p.price, p.availability, p.recommendation, p.product_type,
+		       COALESCE(p.category_id, 0), COALESCE(c.name, ''), p.seller_id, COALESCE(i.quantity, 0),
+		       COALESCE(o.optname, ''), o.values,
+		       COALESCE(p.weight_grams, 0), COALESCE(p.length_cm, 0), COALESCE(p.width_cm, 0), COALESCE(p.height_cm, 0),
+		       ARRAY(
+		           SELECT pi.image_url FROM product_images pi
+		           WHERE pi.product_id = p.product_id
+		           ORDER BY pi.sort_order ASC
+		       ),
+		       (
+		           SELECT json_agg(json_build_object('name', a.name, 'values', a.option_values) ORDER BY a.position)
+		           FROM product_option_axes a
+		           WHERE a.product_id = p.product_id
+		       ),
+		       (
+		           SELECT json_agg(json_build_object(
+		                      'sku', v.sku, 'price', v.price, 'options', v.option_values, 'quantity', COALESCE(vi.quantity, 0),
+		                      'is_active', v.is_active, 'sort_order', v.sort_order
+		                  ) ORDER BY v.sort_order, v.sku)
+		           FROM product_variants v
+		           LEFT JOIN variant_inventory vi ON vi.variant_id = v.variant_id
+		           WHERE v.product_id = p.product_id
+		       ),
+		       (
+		           SELECT json_object_agg(t.locale, json_build_object('name', COALESCE(t.name, ''), 'description', COALESCE(t.description, '')))
+		           FROM product_translations t
+		           WHERE t.product_id = p.product_id
+		       ),
+		       p.updated_at
+		FROM products p
+		LEFT JOIN categories c ON p.category_id = c.category_id
+		LEFT JOIN inventory i ON p.product_id = i.product_id` + activeSaleJoin + `
+		LEFT JOIN LATERAL (
+		    SELECT po.optname, po.values FROM product_options po
+		    WHERE po.product_id = p.product_id
+		    LIMIT 1
+		) o ON TRUE
+		WHERE TRUE`
+
+	query, args := appendProductFilters(query, []interface{}{}, params)
+	query += productOrderBy(params)
+
+	rows, err := pdb.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to export products: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product ExportProduct
+		var values, axes, variants, translations []byte
+		if err := rows.Scan(&product.ID, &product.SKU, &product.Name, &product.Description, &product.Brand,
+			&product.ModelNumber, &product.Price, &product.Availability, &product.Recommendation, &product.ProductType,
+			&product.CategoryID, &product.CategoryName, &product.SellerID, &product.Quantity,
+			&product.OptName, &values, &product.Dimensions.WeightGrams, &product.Dimensions.LengthCm,
+			&product.Dimensions.WidthCm, &product.Dimensions.HeightCm, pq.Array(&product.ImageURLs),
+			&axes, &variants, &translations, &product.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to scan product: %w", err)
+		}
+		if values != nil {
+			product.Values = values
+		}
+		product.VariantOptions = []VariantOption{}
+		product.Variants = []CatalogVariant{}
+		product.Translations = map[string]Translation{}
+		if axes != nil {
+			if err := json.Unmarshal(axes, &product.VariantOptions); err != nil {
+				return fmt.Errorf("failed to decode variant options: %w", err)
+			}
+		}
+		if variants != nil {
+			if err := json.Unmarshal(variants, &product.Variants); err != nil {
+				return fmt.Errorf("failed to decode product variants: %w", err)
+			}
+		}
+		if translations != nil {
+			if err := json.Unmarshal(translations, &product.Translations); err != nil {
+				return fmt.Errorf("failed to decode product translations: %w", err)
+			}
+		}
+
+		if err := fn(product); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate over products: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) ExportProducts(ctx context.Context, params ProductQueryParams, fn func(ExportProduct) error) error {
+	return s.db.ExportProducts(ctx, params, fn)
+}
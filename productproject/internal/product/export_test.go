@@ -0,0 +1,100 @@
+package ecommerce
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openTestDatabase สร้าง schema ใหม่จาก init.sql ในฐานข้อมูลของ TEST_DATABASE_URL และลบทิ้งเมื่อเทสต์จบ
+// ข้ามเทสต์ถ้าไม่ได้กำหนด TEST_DATABASE_URL
+func openTestDatabase(t *testing.T) *PostgresDatabase {
+	t.Helper()
+	connStr := os.Getenv("TEST_DATABASE_URL")
+	if connStr == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	script, err := os.ReadFile("../../../ecommercedatabase/docker/init.sql")
+	if err != nil {
+		t.Fatalf("failed to read init.sql: %v", err)
+	}
+	// คำสั่งของ psql เช่น \c ใช้ผ่าน database/sql ไม่ได้
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(script), "\r\n", "\n"), "\n") {
+		if !strings.HasPrefix(line, `\`) {
+			lines = append(lines, line)
+		}
+	}
+
+	pdb, err := NewPostgresDatabase(connStr)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	// search_path ตั้งได้ต่อ connection จึงใช้ connection เดียวตลอดเทสต์
+	pdb.db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if _, err := pdb.db.ExecContext(ctx, `CREATE SCHEMA `+schema+`; SET search_path TO `+schema+`, public`); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		pdb.db.ExecContext(context.Background(), `DROP SCHEMA `+schema+` CASCADE`)
+		pdb.Close()
+	})
+	if _, err := pdb.db.ExecContext(ctx, strings.Join(lines, "\n")); err != nil {
+		t.Fatalf("failed to apply init.sql: %v", err)
+	}
+	return pdb
+}
+
+func TestExportProductsAgainstSchema(t *testing.T) {
+	pdb := openTestDatabase(t)
+	ctx := context.Background()
+
+	var sellerID, productID string
+	var categoryID int
+	err := pdb.db.QueryRowContext(ctx, `INSERT INTO sellers (name) VALUES ('Export Shop') RETURNING seller_id`).Scan(&sellerID)
+	if err == nil {
+		err = pdb.db.QueryRowContext(ctx, `INSERT INTO categories (name) VALUES ('Food') RETURNING category_id`).Scan(&categoryID)
+	}
+	if err == nil {
+		err = pdb.db.QueryRowContext(ctx, `
+			INSERT INTO products (name, sku, price, availability, recommendation, seller_id, category_id, product_type)
+			VALUES ('Cat food', 'EXPORT-1', 199.50, 'active', 'normal', $1, $2, 'food')
+			RETURNING product_id
+		`, sellerID, categoryID).Scan(&productID)
+	}
+	if err == nil {
+		_, err = pdb.db.ExecContext(ctx, `
+			INSERT INTO product_options (product_id, optname, values) VALUES ($1, 'flavour', '["tuna", "chicken"]')
+		`, productID)
+	}
+	if err != nil {
+		t.Fatalf("failed to insert fixtures: %v", err)
+	}
+
+	var exported []ExportProduct
+	err = pdb.ExportProducts(ctx, ProductQueryParams{}, func(p ExportProduct) error {
+		exported = append(exported, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportProducts error: %v", err)
+	}
+	if len(exported) != 1 {
+		t.Fatalf("exported %d products, want 1", len(exported))
+	}
+
+	got := exported[0]
+	if got.ID != productID || got.SKU != "EXPORT-1" || got.Price.String() != "199.50" || got.CategoryName != "Food" {
+		t.Errorf("exported %s %s %s %s, want %s EXPORT-1 199.50 Food", got.ID, got.SKU, got.Price, got.CategoryName, productID)
+	}
+	if got.OptName != "flavour" || string(got.Values) != `["tuna", "chicken"]` {
+		t.Errorf("exported option %q %s, want flavour [\"tuna\", \"chicken\"]", got.OptName, got.Values)
+	}
+}
@@ -0,0 +1,247 @@
+// image.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ImageVariant คือรูปที่ถูกย่อขนาดแล้วหนึ่งขนาด พร้อมไฟล์ WebP ของขนาดเดียวกัน
+type ImageVariant struct {
+	URL     string `json:"url"`
+	WebPURL string `json:"webp_url,omitempty"` // ว่างเมื่อ WebP ใหญ่กว่ารูปหลัก
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+}
+
+// ImageVariants เก็บเป็น JSONB ในคอลัมน์ product_images.variants โดยใช้ชื่อขนาดเป็น key เช่น "thumbnail", "card", "zoom"
+type ImageVariants map[string]ImageVariant
+
+func (v ImageVariants) Value() (driver.Value, error) {
+	if v == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(v)
+}
+
+func (v *ImageVariants) Scan(src interface{}) error {
+	var data []byte
+	switch s := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		data = s
+	case string:
+		data = []byte(s)
+	default:
+		return fmt.Errorf("unsupported image variants type %T", src)
+	}
+	variants := ImageVariants{}
+	if err := json.Unmarshal(data, &variants); err != nil {
+		return err
+	}
+	if len(variants) == 0 {
+		variants = nil
+	}
+	*v = variants
+	return nil
+}
+
+// productImageColumns ใช้คู่กับ scanProductImage
+const productImageColumns = `image_id, product_id, image_url, is_primary, sort_order, COALESCE(alt_text, ''),
+		       created_at, variants, COALESCE(placeholder, ''), COALESCE(variant_id::text, '')`
+
+func scanProductImage(row rowScanner) (ProductImage, error) {
+	var image ProductImage
+	err := row.Scan(
+		&image.ID, &image.ProductID, &image.ImageURL, &image.IsPrimary, &image.SortOrder, &image.AltText,
+		&image.CreatedAt, &image.Variants, &image.Placeholder, &image.VariantID)
+	return image, err
+}
+
+func getProductImage(ctx context.Context, tx *sql.Tx, productID, imageID string) (ProductImage, error) {
+	image, err := scanProductImage(tx.QueryRowContext(ctx, `
+		SELECT `+productImageColumns+`
+		FROM product_images
+		WHERE product_id = $1 AND image_id = $2
+	`, productID, imageID))
+	if err == sql.ErrNoRows {
+		return ProductImage{}, notFound("product image not found")
+	}
+	if err != nil {
+		return ProductImage{}, fmt.Errorf("failed to get product image: %w", err)
+	}
+	return image, nil
+}
+
+// lockProductImageOrder ล็อกสินค้าแล้วคืน image_id ตามลำดับที่แสดงอยู่ในปัจจุบัน
+func lockProductImageOrder(ctx context.Context, tx *sql.Tx, productID string) ([]string, error) {
+	if err := lockProduct(ctx, tx, productID); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT image_id FROM product_images
+		WHERE product_id = $1
+		ORDER BY sort_order ASC, created_at ASC
+	`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product image order: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan product image id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over product images: %w", err)
+	}
+	return ids, nil
+}
+
+// writeProductImageOrder กำหนด sort_order ใหม่เป็น 0, 1, 2, ... ตามลำดับของ ids ในคำสั่งเดียว
+// (constraint ของ sort_order เป็น DEFERRABLE จึงตรวจหลังจบคำสั่ง ทำให้สลับตำแหน่งกันได้)
+func writeProductImageOrder(ctx context.Context, tx *sql.Tx, productID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `
+		UPDATE product_images p
+		SET sort_order = o.ord - 1, updated_at = NOW()
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(image_id, ord)
+		WHERE p.product_id = $1 AND p.image_id = o.image_id AND p.sort_order <> o.ord - 1
+	`, productID, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to reorder product images: %w", err)
+	}
+	return nil
+}
+
+// setPrimaryProductImage ต้องล้างรูปหลักเดิมก่อน เพราะ unique index อนุญาตรูปหลักได้เพียงรูปเดียวต่อสินค้า
+func setPrimaryProductImage(ctx context.Context, tx *sql.Tx, productID, imageID string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE product_images SET is_primary = FALSE, updated_at = NOW()
+		WHERE product_id = $1 AND is_primary AND image_id <> $2
+	`, productID, imageID)
+	if err != nil {
+		return fmt.Errorf("failed to clear primary image: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE product_images SET is_primary = TRUE, updated_at = NOW()
+		WHERE product_id = $1 AND image_id = $2 AND NOT is_primary
+	`, productID, imageID)
+	if err != nil {
+		return fmt.Errorf("failed to set primary image: %w", err)
+	}
+	return nil
+}
+
+// moveImageID ย้าย id ไปไว้ที่ position (ถ้าเกินขอบเขตจะย้ายไปหัวหรือท้ายรายการ)
+func moveImageID(order []string, id string, position int) []string {
+	rest := removeID(order, id)
+	if position < 0 {
+		position = 0
+	}
+	if position > len(rest) {
+		position = len(rest)
+	}
+
+	moved := make([]string, 0, len(rest)+1)
+	moved = append(moved, rest[:position]...)
+	moved = append(moved, id)
+	return append(moved, rest[position:]...)
+}
+
+func removeID(ids []string, id string) []string {
+	rest := make([]string, 0, len(ids))
+	for _, v := range ids {
+		if v != id {
+			rest = append(rest, v)
+		}
+	}
+	return rest
+}
+
+func containsID(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ReorderProductImages จัดลำดับรูปทั้งหมดของสินค้าใหม่ imageIDs ต้องมีรูปของสินค้านั้นครบทุกรูปและไม่ซ้ำกัน
+func (pdb *PostgresDatabase) ReorderProductImages(ctx context.Context, productID string, imageIDs []string) ([]ProductImage, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	order, err := lockProductImageOrder(ctx, tx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(imageIDs))
+	for _, id := range imageIDs {
+		if seen[id] || !containsID(order, id) {
+			return nil, invalid("image_ids must list every image of the product exactly once")
+		}
+		seen[id] = true
+	}
+	if len(seen) != len(order) {
+		return nil, invalid("image_ids must list every image of the product exactly once")
+	}
+
+	if err := writeProductImageOrder(ctx, tx, productID, imageIDs); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pdb.GetProductImages(ctx, productID)
+}
+
+// SaveProductImageVariants บันทึกผลจากการประมวลผลรูปในเบื้องหลัง
+func (pdb *PostgresDatabase) SaveProductImageVariants(ctx context.Context, productID, imageID string, variants ImageVariants, placeholder string) error {
+	result, err := pdb.db.ExecContext(ctx, `
+		UPDATE product_images
+		SET variants = $1, placeholder = $2, updated_at = NOW()
+		WHERE product_id = $3 AND image_id = $4
+	`, variants, nullString(placeholder), productID, imageID)
+	if err != nil {
+		return fmt.Errorf("failed to save product image variants: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return notFound("product image not found")
+	}
+	return nil
+}
+
+func (s *Store) ReorderProductImages(ctx context.Context, productID string, imageIDs []string) ([]ProductImage, error) {
+	return s.db.ReorderProductImages(ctx, productID, imageIDs)
+}
+
+func (s *Store) SaveProductImageVariants(ctx context.Context, productID, imageID string, variants ImageVariants, placeholder string) error {
+	return s.db.SaveProductImageVariants(ctx, productID, imageID, variants, placeholder)
+}
@@ -0,0 +1,480 @@
+// import.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	ImportJobQueued    = "queued"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+const (
+	ImportActionCreated = "created"
+	ImportActionUpdated = "updated"
+)
+
+// ImportJob คืองานนำเข้าสินค้าจากไฟล์ที่ทำในเบื้องหลัง
+type ImportJob struct {
+	ID            string     `json:"id"`
+	SellerID      string     `json:"seller_id"`
+	UserID        string     `json:"user_id"`
+	Format        string     `json:"format"`
+	DryRun        bool       `json:"dry_run"`
+	Upsert        bool       `json:"upsert"`
+	Status        string     `json:"status"`
+	TotalRows     int        `json:"total_rows"`
+	ProcessedRows int        `json:"processed_rows"`
+	CreatedCount  int        `json:"created_count"`
+	UpdatedCount  int        `json:"updated_count"`
+	FailedCount   int        `json:"failed_count"`
+	Error         string     `json:"error,omitempty"` // ข้อผิดพลาดที่ทำให้ทั้งงานล้มเหลว เช่น อ่านไฟล์ไม่ได้
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+}
+
+type NewImportJob struct {
+	SellerID string
+	UserID   string
+	Format   string
+	DryRun   bool
+	Upsert   bool
+}
+
+// ImportJobError คือข้อผิดพลาดของแถวใดแถวหนึ่งในไฟล์ (แถวแรกของข้อมูลคือแถวที่ 1 ไม่นับหัวตาราง)
+type ImportJobError struct {
+	Row     int    `json:"row"`
+	SKU     string `json:"sku"`
+	Message string `json:"message"`
+}
+
+// CatalogProduct คือสินค้าหนึ่งแถวในไฟล์นำเข้าและส่งออก นอกจาก field ของ NewProduct แล้วยังมีรูป
+// ตัวเลือก และคำแปล เพื่อให้นำไฟล์ที่ส่งออกกลับมานำเข้าได้โดยข้อมูลไม่หาย
+// VariantOptions ที่เป็น nil (ไม่มีคอลัมน์หรือ key นี้) คือไม่แก้ไขตัวเลือกของสินค้าที่มีอยู่แล้ว
+type CatalogProduct struct {
+	NewProduct
+	ImageURLs      []string               `json:"image_urls" binding:"dive,required,http_url,max=255"`
+	VariantOptions []VariantOption        `json:"variant_options"`
+	Variants       []CatalogVariant       `json:"variants" binding:"dive"`
+	Translations   map[string]Translation `json:"translations" binding:"omitempty,dive,keys,translation_locale,endkeys"`
+}
+
+// CatalogVariant คือตัวเลือกของสินค้าในไฟล์ ไม่มีรูปของตัวเลือกเพราะ image_id เปลี่ยนไปเมื่อนำเข้า
+// ตัวเลือกถูกจับคู่กับของเดิมด้วย SKU
+type CatalogVariant struct {
+	SKU       string            `json:"sku" binding:"sku"`
+	Price     *Money            `json:"price" binding:"omitempty,gte=0,lte=99999999.99"` // null คือใช้ราคาของสินค้าหลัก
+	Options   map[string]string `json:"options"`
+	Quantity  int               `json:"quantity" binding:"gte=0"`
+	IsActive  *bool             `json:"is_active"` // ค่าเริ่มต้นคือ true
+	SortOrder int               `json:"sort_order"`
+}
+
+// Validate ตรวจสินค้าด้วยกฎของ NewProduct แล้วตรวจแกนและค่าของตัวเลือกทุกรายการ
+func (p CatalogProduct) Validate() error {
+	if err := p.NewProduct.Validate(); err != nil {
+		return err
+	}
+	if err := ValidateStruct(p); err != nil {
+		return err
+	}
+	if err := ValidateVariantAxes(p.VariantOptions); err != nil {
+		return err
+	}
+	for _, variant := range p.Variants {
+		if err := ValidateVariantOptions(p.VariantOptions, variant.Options); err != nil {
+			return invalid("variant %s: %v", variant.SKU, err)
+		}
+	}
+	return nil
+}
+
+// ImportProgress คือจำนวนที่ทำไปแล้ว ใช้อัปเดตความคืบหน้าของงาน
+type ImportProgress struct {
+	ProcessedRows int
+	CreatedCount  int
+	UpdatedCount  int
+	FailedCount   int
+}
+
+const importJobColumns = `job_id, seller_id, user_id, format, dry_run, upsert, status, total_rows, processed_rows,
+		       created_count, updated_count, failed_count, COALESCE(error, ''), created_at, started_at, finished_at`
+
+func scanImportJob(row rowScanner) (ImportJob, error) {
+	var job ImportJob
+	var startedAt, finishedAt sql.NullTime
+	err := row.Scan(&job.ID, &job.SellerID, &job.UserID, &job.Format, &job.DryRun, &job.Upsert, &job.Status,
+		&job.TotalRows, &job.ProcessedRows, &job.CreatedCount, &job.UpdatedCount, &job.FailedCount,
+		&job.Error, &job.CreatedAt, &startedAt, &finishedAt)
+	if err != nil {
+		return ImportJob{}, err
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return job, nil
+}
+
+func (pdb *PostgresDatabase) CreateImportJob(ctx context.Context, job NewImportJob) (ImportJob, error) {
+	created, err := scanImportJob(pdb.db.QueryRowContext(ctx, `
+		INSERT INTO product_import_jobs (seller_id, user_id, format, dry_run, upsert)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+importJobColumns,
+		job.SellerID, job.UserID, job.Format, job.DryRun, job.Upsert))
+	if err != nil {
+		return ImportJob{}, fmt.Errorf("failed to create import job: %w", err)
+	}
+	return created, nil
+}
+
+func (pdb *PostgresDatabase) GetImportJob(ctx context.Context, jobID string) (ImportJob, error) {
+	job, err := scanImportJob(pdb.db.QueryRowContext(ctx, `
+		SELECT `+importJobColumns+`
+		FROM product_import_jobs
+		WHERE job_id = $1
+	`, jobID))
+	if err == sql.ErrNoRows {
+		return ImportJob{}, notFound("import job not found")
+	}
+	if err != nil {
+		return ImportJob{}, fmt.Errorf("failed to get import job: %w", err)
+	}
+	return job, nil
+}
+
+func (pdb *PostgresDatabase) GetImportJobErrors(ctx context.Context, jobID string) ([]ImportJobError, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT row_number, COALESCE(sku, ''), message
+		FROM product_import_errors
+		WHERE job_id = $1
+		ORDER BY row_number ASC
+	`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import errors: %w", err)
+	}
+	defer rows.Close()
+
+	errs := []ImportJobError{}
+	for rows.Next() {
+		var e ImportJobError
+		if err := rows.Scan(&e.Row, &e.SKU, &e.Message); err != nil {
+			return nil, fmt.Errorf("failed to scan import error: %w", err)
+		}
+		errs = append(errs, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over import errors: %w", err)
+	}
+
+	return errs, nil
+}
+
+func (pdb *PostgresDatabase) StartImportJob(ctx context.Context, jobID string, totalRows int) error {
+	_, err := pdb.db.ExecContext(ctx, `
+		UPDATE product_import_jobs
+		SET status = $1, total_rows = $2, started_at = NOW()
+		WHERE job_id = $3
+	`, ImportJobRunning, totalRows, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to start import job: %w", err)
+	}
+	return nil
+}
+
+func (pdb *PostgresDatabase) UpdateImportJobProgress(ctx context.Context, jobID string, progress ImportProgress, rowErrors []ImportJobError) error {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, e := range rowErrors {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO product_import_errors (job_id, row_number, sku, message)
+			VALUES ($1, $2, $3, $4)
+		`, jobID, e.Row, nullString(e.SKU), e.Message)
+		if err != nil {
+			return fmt.Errorf("failed to add import error: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE product_import_jobs
+		SET processed_rows = $1, created_count = $2, updated_count = $3, failed_count = $4
+		WHERE job_id = $5
+	`, progress.ProcessedRows, progress.CreatedCount, progress.UpdatedCount, progress.FailedCount, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// FinishImportJob ปิดงาน ถ้า jobErr ไม่ว่างงานจะมีสถานะ failed
+func (pdb *PostgresDatabase) FinishImportJob(ctx context.Context, jobID string, jobErr string) error {
+	status := ImportJobCompleted
+	if jobErr != "" {
+		status = ImportJobFailed
+	}
+
+	_, err := pdb.db.ExecContext(ctx, `
+		UPDATE product_import_jobs
+		SET status = $1, error = $2, finished_at = NOW()
+		WHERE job_id = $3
+	`, status, nullString(jobErr), jobID)
+	if err != nil {
+		return fmt.Errorf("failed to finish import job: %w", err)
+	}
+	return nil
+}
+
+// FailInterruptedImportJobs ปิดงานที่ค้างอยู่จากการรันครั้งก่อน เพราะคิวงานเก็บไว้ในหน่วยความจำเท่านั้น
+func (pdb *PostgresDatabase) FailInterruptedImportJobs(ctx context.Context) error {
+	_, err := pdb.db.ExecContext(ctx, `
+		UPDATE product_import_jobs
+		SET status = $1, error = 'interrupted by server restart', finished_at = NOW()
+		WHERE status IN ($2, $3)
+	`, ImportJobFailed, ImportJobQueued, ImportJobRunning)
+	if err != nil {
+		return fmt.Errorf("failed to close interrupted import jobs: %w", err)
+	}
+	return nil
+}
+
+// ImportProduct บันทึกสินค้าหนึ่งแถวใน transaction ของตัวเอง เมื่อ dryRun จะ rollback ทุกครั้ง
+// เพื่อให้ตรวจ constraint ของฐานข้อมูลได้ครบโดยไม่เปลี่ยนข้อมูลจริง
+// ImageURLs ใช้เฉพาะตอนสร้างสินค้าใหม่ รูปของสินค้าที่มีอยู่แล้วจะไม่ถูกแก้ไข
+// คำแปลแก้ไขเฉพาะภาษาที่อยู่ในไฟล์ ส่วนตัวเลือกดู importVariants
+func (pdb *PostgresDatabase) ImportProduct(ctx context.Context, row CatalogProduct, upsert, dryRun bool) (string, error) {
+	product := row.NewProduct
+
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var productID, sellerID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT product_id, seller_id FROM products WHERE sku = $1 FOR UPDATE
+	`, product.SKU).Scan(&productID, &sellerID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get product: %w", err)
+	}
+
+	action := ImportActionCreated
+	switch {
+	case err == sql.ErrNoRows:
+		created, err := insertProduct(ctx, tx, product)
+		if err != nil {
+			return "", err
+		}
+		productID = created.ID
+		for i, url := range row.ImageURLs {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO product_images (product_id, image_url, is_primary, sort_order)
+				VALUES ($1, $2, $3, $4)
+			`, created.ID, url, i == 0, i)
+			if err != nil {
+				return "", fmt.Errorf("failed to add product image: %w", err)
+			}
+		}
+	case !upsert:
+		return "", conflict("sku %s already exists", product.SKU)
+	case sellerID != product.SellerID:
+		return "", conflict("sku %s belongs to another seller", product.SKU)
+	default:
+		action = ImportActionUpdated
+		if err := updateImportedProduct(ctx, tx, productID, product); err != nil {
+			return "", err
+		}
+	}
+
+	if err := saveProductTranslations(ctx, tx, productID, row.Translations); err != nil {
+		return "", err
+	}
+	if row.VariantOptions != nil {
+		if err := importVariants(ctx, tx, productID, row.VariantOptions, row.Variants); err != nil {
+			return "", err
+		}
+	}
+
+	if dryRun {
+		return action, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return action, nil
+}
+
+// updateImportedProduct แก้ไขสินค้าที่มี SKU ตรงกันด้วยข้อมูลจากไฟล์ (ประเภทสินค้าเปลี่ยนไม่ได้)
+func updateImportedProduct(ctx context.Context, tx *sql.Tx, productID string, product NewProduct) error {
+	var productType, sellerID string
+	var oldPrice, newPrice Money
+	err := tx.QueryRowContext(ctx, `
+		WITH old AS (SELECT price FROM products WHERE product_id = $9 FOR UPDATE)
+		UPDATE products
+		SET name = $1, description = $2, brand = $3, model_number = $4, price = $5,
+		    availability = $6, recommendation = $7, category_id = $8, updated_at = NOW()
+		WHERE product_id = $9
+		RETURNING product_type, seller_id, (SELECT price FROM old), price
+	`, product.Name, product.Description, product.Brand, product.ModelNumber, product.Price,
+		product.Availability, product.Recommendation, product.CategoryID, productID).Scan(&productType, &sellerID, &oldPrice, &newPrice)
+	if err != nil {
+		return fmt.Errorf("failed to update product: %w", err)
+	}
+	if productType != product.ProductType {
+		return invalid("product_type cannot be changed from %s to %s", productType, product.ProductType)
+	}
+	if err := publishProductUpdate(ctx, tx, productID, sellerID, oldPrice, newPrice); err != nil {
+		return err
+	}
+	if err := saveProductDimensions(ctx, tx, productID, product.Dimensions); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO inventory (product_id, quantity) VALUES ($1, $2)
+		ON CONFLICT (product_id) DO UPDATE SET quantity = EXCLUDED.quantity, updated_at = NOW()
+	`, productID, product.Quantity)
+	if err != nil {
+		return fmt.Errorf("failed to update inventory: %w", err)
+	}
+	return nil
+}
+
+// importVariants แทนที่แกนของตัวเลือกด้วย axes แล้วเพิ่มหรือแก้ไขตัวเลือกตาม SKU
+// ตัวเลือกเดิมที่ไม่อยู่ในไฟล์จะไม่ถูกลบเพราะอาจมีคำสั่งซื้ออ้างถึงอยู่ แต่ต้องยังตรงกับแกนใหม่
+func importVariants(ctx context.Context, tx *sql.Tx, productID string, axes []VariantOption, variants []CatalogVariant) error {
+	skus := make([]string, len(variants))
+	for i, variant := range variants {
+		skus[i] = variant.SKU
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT sku, option_values FROM product_variants WHERE product_id = $1 AND NOT (sku = ANY($2::text[]))
+	`, productID, pq.Array(skus))
+	if err != nil {
+		return fmt.Errorf("failed to get product variants: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sku string
+		var data []byte
+		var current map[string]string
+		if err := rows.Scan(&sku, &data); err != nil {
+			return fmt.Errorf("failed to scan product variant: %w", err)
+		}
+		if err := json.Unmarshal(data, &current); err != nil {
+			return fmt.Errorf("failed to decode variant options: %w", err)
+		}
+		if err := ValidateVariantOptions(axes, current); err != nil {
+			return conflict("variant %s is not in the file and does not match the new options: %v", sku, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate over product variants: %w", err)
+	}
+	rows.Close()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_option_axes WHERE product_id = $1`, productID); err != nil {
+		return fmt.Errorf("failed to replace variant options: %w", err)
+	}
+	for i, axis := range axes {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO product_option_axes (product_id, name, option_values, position)
+			VALUES ($1, $2, $3, $4)
+		`, productID, axis.Name, pq.Array(axis.Values), i)
+		if err != nil {
+			return fmt.Errorf("failed to add variant option: %w", err)
+		}
+	}
+
+	for _, variant := range variants {
+		options, err := json.Marshal(variant.Options)
+		if err != nil {
+			return fmt.Errorf("failed to encode variant options: %w", err)
+		}
+		isActive := true
+		if variant.IsActive != nil {
+			isActive = *variant.IsActive
+		}
+
+		// WHERE ของ DO UPDATE กันไม่ให้ไฟล์ของสินค้าหนึ่งแก้ตัวเลือกของสินค้าอื่นที่ใช้ SKU เดียวกัน
+		var variantID string
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO product_variants (product_id, sku, price, option_values, is_active, sort_order)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (sku) DO UPDATE
+			SET price = EXCLUDED.price, option_values = EXCLUDED.option_values, is_active = EXCLUDED.is_active,
+			    sort_order = EXCLUDED.sort_order, updated_at = NOW()
+			WHERE product_variants.product_id = EXCLUDED.product_id
+			RETURNING variant_id
+		`, productID, variant.SKU, variant.Price, options, isActive, variant.SortOrder).Scan(&variantID)
+		if err == sql.ErrNoRows {
+			return conflict("variant sku %s belongs to another product", variant.SKU)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save product variant: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO variant_inventory (variant_id, quantity) VALUES ($1, $2)
+			ON CONFLICT (variant_id) DO UPDATE SET quantity = EXCLUDED.quantity, updated_at = NOW()
+		`, variantID, variant.Quantity)
+		if err != nil {
+			return fmt.Errorf("failed to save variant inventory: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) CreateImportJob(ctx context.Context, job NewImportJob) (ImportJob, error) {
+	return s.db.CreateImportJob(ctx, job)
+}
+
+func (s *Store) GetImportJob(ctx context.Context, jobID string) (ImportJob, error) {
+	return s.db.GetImportJob(ctx, jobID)
+}
+
+func (s *Store) GetImportJobErrors(ctx context.Context, jobID string) ([]ImportJobError, error) {
+	return s.db.GetImportJobErrors(ctx, jobID)
+}
+
+func (s *Store) StartImportJob(ctx context.Context, jobID string, totalRows int) error {
+	return s.db.StartImportJob(ctx, jobID, totalRows)
+}
+
+func (s *Store) UpdateImportJobProgress(ctx context.Context, jobID string, progress ImportProgress, rowErrors []ImportJobError) error {
+	return s.db.UpdateImportJobProgress(ctx, jobID, progress, rowErrors)
+}
+
+func (s *Store) FinishImportJob(ctx context.Context, jobID string, jobErr string) error {
+	return s.db.FinishImportJob(ctx, jobID, jobErr)
+}
+
+func (s *Store) FailInterruptedImportJobs(ctx context.Context) error {
+	return s.db.FailInterruptedImportJobs(ctx)
+}
+
+func (s *Store) ImportProduct(ctx context.Context, row CatalogProduct, upsert, dryRun bool) (string, error) {
+	return s.db.ImportProduct(ctx, row, upsert, dryRun)
+}
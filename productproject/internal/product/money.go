@@ -0,0 +1,273 @@
+// money.go
+package ecommerce
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency คือสกุลเงินของราคาที่บันทึกในฐานข้อมูล คอลัมน์ราคาเป็น NUMERIC(10, 2) ที่ไม่มีสกุลเงินกำกับ
+const DefaultCurrency = "THB"
+
+// moneyScale คือจำนวนหน่วยย่อยต่อหนึ่งหน่วยเงิน ตรงกับทศนิยม 2 ตำแหน่งของ NUMERIC(10, 2)
+const moneyScale = 100
+
+// currencies คือสกุลเงินที่รับจากผู้ใช้ได้
+var currencies = []string{DefaultCurrency}
+
+// Money คือจำนวนเงินแบบทศนิยมตายตัว เก็บเป็นหน่วยย่อย (สตางค์) เพื่อให้บวกและคูณได้ค่าตรงทุกหลัก
+// ค่าศูนย์ของ Money คือ 0.00 ในสกุล DefaultCurrency
+//
+// JSON เป็น {"amount": "199.00", "currency": "THB"} amount เป็น string เพื่อไม่ให้ client แปลงเป็น float
+// ราคาที่แปลงสกุลเงินแล้วจะมี "base" เป็นราคาเดิมในสกุล DefaultCurrency
+// ตอนรับค่ายังยอมรับตัวเลขหรือ string เปล่าๆ เช่น 199.5 หรือ "199.50" ซึ่งจะใช้ DefaultCurrency
+type Money struct {
+	minor    int64
+	currency string
+	base     *Money // ราคาเดิมเมื่อแปลงสกุลเงินเพื่อแสดงผล (ดู Convert)
+}
+
+// NewMoney สร้าง Money จากจำนวนหน่วยย่อย เช่น NewMoney(19950, "THB") คือ 199.50 บาท
+func NewMoney(minor int64, currency string) Money {
+	return Money{minor: minor, currency: currency}
+}
+
+// ParseMoney อ่านจำนวนเงินรูปแบบ 199, 199.5 หรือ -199.50 ทศนิยมเกิน 2 ตำแหน่งถือว่าไม่ถูกต้อง
+// เพราะจะถูกปัดเศษเมื่อบันทึกลงฐานข้อมูล
+func ParseMoney(amount, currency string) (Money, error) {
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if !containsID(currencies, currency) {
+		return Money{}, invalid("unsupported currency %q, must be one of %s", currency, strings.Join(currencies, ", "))
+	}
+
+	s := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		s = s[1:]
+	}
+	whole, fraction, hasFraction := strings.Cut(s, ".")
+	if whole == "" || !isDigits(whole) || (hasFraction && (fraction == "" || !isDigits(fraction))) {
+		return Money{}, invalid("invalid amount %q", amount)
+	}
+	if len(fraction) > 2 {
+		return Money{}, invalid("amount %q must have at most 2 decimal places", amount)
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > (1<<63-1)/moneyScale-1 {
+		return Money{}, invalid("amount %q is out of range", amount)
+	}
+	cents, _ := strconv.ParseInt((fraction + "00")[:2], 10, 64)
+
+	minor := units*moneyScale + cents
+	if negative {
+		minor = -minor
+	}
+	return Money{minor: minor, currency: currency}, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor คืนจำนวนหน่วยย่อย เช่น 19950 สำหรับ 199.50
+func (m Money) Minor() int64 { return m.minor }
+
+func (m Money) Currency() string {
+	if m.currency == "" {
+		return DefaultCurrency
+	}
+	return m.currency
+}
+
+func (m Money) IsZero() bool { return m.minor == 0 }
+
+func (m Money) IsNegative() bool { return m.minor < 0 }
+
+// String คืนจำนวนเงินทศนิยม 2 ตำแหน่งโดยไม่มีสกุลเงิน เช่น "199.50" ใช้กับ CSV และบันทึกลงฐานข้อมูล
+func (m Money) String() string {
+	minor := m.minor
+	sign := ""
+	if minor < 0 {
+		sign, minor = "-", -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/moneyScale, minor%moneyScale)
+}
+
+// Add และ Sub ใช้กับเงินสกุลเดียวกันเท่านั้น ผู้เรียกต้องแปลงสกุลเงินก่อน
+// ถ้าสกุลเงินไม่ตรงกันถือเป็นบั๊กของโปรแกรมจึง panic แทนการคืนผลรวมที่ผิด
+func (m Money) Add(other Money) Money {
+	m.mustMatch(other)
+	return Money{minor: m.minor + other.minor, currency: m.Currency()}
+}
+
+func (m Money) Sub(other Money) Money {
+	m.mustMatch(other)
+	return Money{minor: m.minor - other.minor, currency: m.Currency()}
+}
+
+// Mul คูณด้วยจำนวนชิ้น เช่น ราคาต่อชิ้นคูณจำนวนในตะกร้า
+func (m Money) Mul(quantity int) Money {
+	return Money{minor: m.minor * int64(quantity), currency: m.Currency()}
+}
+
+// Percent คืน percent เปอร์เซ็นต์ของ m เช่น "12.5" ปัดเศษแบบ half-up ที่หน่วยย่อย
+func (m Money) Percent(percent string) (Money, error) {
+	p, ok := new(big.Rat).SetString(percent)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid percent %q", percent)
+	}
+	q := new(big.Rat).Mul(new(big.Rat).SetInt64(m.minor), p)
+	minor, ok := roundHalfUp(q.Quo(q, big.NewRat(100, 1)))
+	if !ok {
+		return Money{}, fmt.Errorf("amount is out of range")
+	}
+	return Money{minor: minor, currency: m.Currency()}, nil
+}
+
+// roundHalfUp ปัด q เป็นจำนวนเต็ม เศษครึ่งหนึ่งปัดออกจากศูนย์ คำนวณจาก (2*|num| + den) / (2*den)
+func roundHalfUp(q *big.Rat) (int64, bool) {
+	num := new(big.Int).Abs(q.Num())
+	num.Add(num.Lsh(num, 1), q.Denom())
+	rounded := num.Quo(num, new(big.Int).Lsh(q.Denom(), 1))
+	if !rounded.IsInt64() {
+		return 0, false
+	}
+	if q.Sign() < 0 {
+		return -rounded.Int64(), true
+	}
+	return rounded.Int64(), true
+}
+
+// Min คืนค่าที่น้อยกว่าระหว่าง m และ other
+func (m Money) Min(other Money) Money {
+	if m.Cmp(other) <= 0 {
+		return m
+	}
+	return other
+}
+
+// Cmp คืน -1, 0 หรือ 1 เมื่อ m น้อยกว่า เท่ากับ หรือมากกว่า other
+func (m Money) Cmp(other Money) int {
+	m.mustMatch(other)
+	switch {
+	case m.minor < other.minor:
+		return -1
+	case m.minor > other.minor:
+		return 1
+	}
+	return 0
+}
+
+func (m Money) mustMatch(other Money) {
+	if m.Currency() != other.Currency() {
+		panic(fmt.Sprintf("money: currency mismatch %s and %s", m.Currency(), other.Currency()))
+	}
+}
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+		Base     *Money `json:"base,omitempty"`
+	}{m.String(), m.Currency(), m.base})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+
+	amount, currency := data, ""
+	if bytes.HasPrefix(data, []byte("{")) {
+		var v moneyJSON
+		if err := json.Unmarshal(data, &v); err != nil {
+			return invalid("invalid money value: %v", err)
+		}
+		amount, currency = bytes.TrimSpace(v.Amount), v.Currency
+	}
+
+	// amount เป็นได้ทั้ง string และตัวเลข ตัวเลขอ่านจากข้อความตรงๆ โดยไม่ผ่าน float64
+	text := string(amount)
+	if bytes.HasPrefix(amount, []byte(`"`)) {
+		if err := json.Unmarshal(amount, &text); err != nil {
+			return invalid("invalid amount %s", amount)
+		}
+	}
+
+	parsed, err := ParseMoney(text, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan อ่านค่าจากคอลัมน์ NUMERIC ซึ่ง lib/pq ส่งมาเป็นข้อความ ค่า NULL ต้องสแกนผ่าน *Money
+func (m *Money) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into Money")
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+
+	parsed, err := parseNumeric(text)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// parseNumeric อ่านผลลัพธ์จาก SQL ซึ่งอาจมีทศนิยมมากกว่า 2 ตำแหน่ง เช่น SUM(quantity * unit_price)
+// ของคอลัมน์ที่ไม่ได้กำหนด scale จึงปัดเศษแบบ half-up แทนการปฏิเสธ
+func parseNumeric(text string) (Money, error) {
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(text), ".")
+	if len(fraction) <= 2 {
+		return ParseMoney(text, DefaultCurrency)
+	}
+
+	m, err := ParseMoney(whole+"."+fraction[:2], DefaultCurrency)
+	if err != nil {
+		return Money{}, err
+	}
+	if fraction[2] >= '5' {
+		if strings.HasPrefix(whole, "-") {
+			m.minor--
+		} else {
+			m.minor++
+		}
+	}
+	return m, nil
+}
+
+// Value บันทึกเป็นข้อความทศนิยมเพื่อให้ Postgres แปลงเป็น NUMERIC โดยไม่ผ่าน float
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
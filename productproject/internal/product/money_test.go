@@ -0,0 +1,142 @@
+package ecommerce
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{name: "whole amount", amount: "199", want: 19900},
+		{name: "one decimal", amount: "199.5", want: 19950},
+		{name: "two decimals", amount: "199.99", want: 19999},
+		{name: "surrounding spaces", amount: " 10.00 ", want: 1000},
+		{name: "explicit currency", amount: "1.00", currency: DefaultCurrency, want: 100},
+		{name: "negative", amount: "-12.34", want: -1234},
+		{name: "negative below one", amount: "-0.05", want: -5},
+		{name: "zero", amount: "0", want: 0},
+		{name: "largest amount", amount: "92233720368547757.99", want: 9223372036854775799},
+		{name: "out of range", amount: "92233720368547758", wantErr: true},
+		{name: "overflows int64", amount: "99999999999999999999", wantErr: true},
+		{name: "three decimals", amount: "1.005", wantErr: true},
+		{name: "empty", amount: "", wantErr: true},
+		{name: "sign only", amount: "-", wantErr: true},
+		{name: "missing whole part", amount: ".50", wantErr: true},
+		{name: "missing fraction", amount: "1.", wantErr: true},
+		{name: "double sign", amount: "--1", wantErr: true},
+		{name: "plus sign", amount: "+1", wantErr: true},
+		{name: "exponent", amount: "1e2", wantErr: true},
+		{name: "unsupported currency", amount: "1.00", currency: "USD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.amount, tt.currency)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMoney(%q) = %s, want error", tt.amount, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q) error: %v", tt.amount, err)
+			}
+			if got.Minor() != tt.want || got.Currency() != DefaultCurrency {
+				t.Errorf("ParseMoney(%q) = %d %s, want %d %s", tt.amount, got.Minor(), got.Currency(), tt.want, DefaultCurrency)
+			}
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		minor int64
+		want  string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{19950, "199.50"},
+		{-5, "-0.05"},
+		{-1234, "-12.34"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := NewMoney(tt.minor, DefaultCurrency).String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		name    string
+		minor   int64
+		percent string
+		want    int64
+		wantErr bool
+	}{
+		{name: "exact", minor: 10000, percent: "7", want: 700},
+		{name: "rounds down below half", minor: 149, percent: "1", want: 1},
+		{name: "rounds half up", minor: 150, percent: "1", want: 2},
+		{name: "rounds half up on even", minor: 250, percent: "1", want: 3},
+		{name: "rounds above half", minor: 19999, percent: "7", want: 1400},
+		{name: "fractional percent", minor: 10000, percent: "12.5", want: 1250},
+		{name: "negative rounds half away from zero", minor: -150, percent: "1", want: -2},
+		{name: "negative below half", minor: -149, percent: "1", want: -1},
+		{name: "zero percent", minor: 19999, percent: "0", want: 0},
+		{name: "invalid percent", minor: 100, percent: "ten", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewMoney(tt.minor, DefaultCurrency).Percent(tt.percent)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Percent(%q) = %s, want error", tt.percent, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Percent(%q) error: %v", tt.percent, err)
+			}
+			if got.Minor() != tt.want {
+				t.Errorf("%d.Percent(%q) = %d, want %d", tt.minor, tt.percent, got.Minor(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundHalfUp(t *testing.T) {
+	tests := []struct {
+		name   string
+		q      *big.Rat
+		want   int64
+		wantOK bool
+	}{
+		{name: "integer", q: big.NewRat(42, 1), want: 42, wantOK: true},
+		{name: "below half", q: big.NewRat(249, 100), want: 2, wantOK: true},
+		{name: "half", q: big.NewRat(5, 2), want: 3, wantOK: true},
+		{name: "above half", q: big.NewRat(251, 100), want: 3, wantOK: true},
+		{name: "negative half", q: big.NewRat(-5, 2), want: -3, wantOK: true},
+		{name: "negative below half", q: big.NewRat(-249, 100), want: -2, wantOK: true},
+		{name: "zero", q: new(big.Rat), want: 0, wantOK: true},
+		{name: "out of range", q: new(big.Rat).SetFrac(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1)), wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := roundHalfUp(tt.q)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("roundHalfUp(%s) = %d, %t, want %d, %t", tt.q, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// notification.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ชื่อ template ของอีเมลใน outbox ตรงกับไฟล์ใน internal/notification/templates
+// login_notice และ low_stock ถูกเขียนลง outbox โดย trigger ในฐานข้อมูล
+const (
+	EmailOrderConfirmation = "order_confirmation"
+	EmailShippingUpdate    = "shipping_update"
+	EmailLoginNotice       = "login_notice"
+	EmailLowStock          = "low_stock"
+)
+
+// MaxEmailAttempts คือจำนวนครั้งที่พยายามส่งก่อนเลิกและเปลี่ยนสถานะเป็น failed
+const MaxEmailAttempts = 8
+
+// OutboxEmail คืออีเมลที่รอส่ง Payload เป็นข้อมูลของ template ตามชนิดใน Template
+type OutboxEmail struct {
+	ID             string
+	Template       string
+	Locale         string
+	RecipientEmail string
+	RecipientName  string
+	Payload        json.RawMessage
+	Attempts       int // รวมครั้งที่กำลังส่งอยู่
+	CreatedAt      time.Time
+}
+
+// OrderEmail คือข้อมูลของอีเมลยืนยันคำสั่งซื้อ
+type OrderEmail struct {
+	OrderID         string           `json:"order_id"`
+	CustomerName    string           `json:"customer_name"`
+	Items           []OrderEmailItem `json:"items"`
+	Subtotal        Money            `json:"subtotal"`
+	Discount        Money            `json:"discount"`
+	ShippingTotal   Money            `json:"shipping_total"`
+	Total           Money            `json:"total"`
+	ShippingAddress PostalAddress    `json:"shipping_address"`
+}
+
+type OrderEmailItem struct {
+	ProductName string `json:"product_name"`
+	VariantName string `json:"variant_name,omitempty"`
+	Quantity    int    `json:"quantity"`
+	LineTotal   Money  `json:"line_total"`
+}
+
+// ShipmentEmail คือข้อมูลของอีเมลแจ้งสถานะการจัดส่งของร้านหนึ่งในคำสั่งซื้อ
+type ShipmentEmail struct {
+	OrderID        string           `json:"order_id"`
+	CustomerName   string           `json:"customer_name"`
+	ShopName       string           `json:"shop_name"`
+	Status         string           `json:"status"` // 'shipped', 'delivered'
+	Carrier        string           `json:"carrier"`
+	TrackingNumber string           `json:"tracking_number"`
+	Items          []OrderEmailItem `json:"items"`
+}
+
+// LoginNoticeEmail สร้างโดย trigger enqueue_login_notice
+type LoginNoticeEmail struct {
+	Name       string    `json:"name"`
+	SignedInAt time.Time `json:"signed_in_at"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+}
+
+// LowStockEmail สร้างโดย trigger enqueue_low_stock_warning
+type LowStockEmail struct {
+	ShopName    string `json:"shop_name"`
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	SKU         string `json:"sku"`
+	Quantity    int    `json:"quantity"`
+	Threshold   int    `json:"threshold"`
+}
+
+// requestLocale คืนภาษาของ request สำหรับเลือก template ของอีเมล
+func requestLocale(ctx context.Context) string {
+	if locale := localeFrom(ctx); locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// enqueueEmail เขียนอีเมลลง outbox ใน transaction เดียวกับการเปลี่ยนแปลงที่เป็นต้นเหตุ
+// ถ้า transaction ถูก rollback อีเมลจะไม่ถูกส่ง และเมื่อ commit แล้ว worker จะส่งจนสำเร็จหรือครบจำนวนครั้ง
+func enqueueEmail(ctx context.Context, tx *sql.Tx, template, locale, recipientEmail, recipientName string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode email payload: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO email_outbox (template, locale, recipient_email, recipient_name, payload)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+	`, template, locale, recipientEmail, recipientName, data)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return nil
+}
+
+// ClaimEmails จองอีเมลที่ถึงเวลาส่งไม่เกิน limit ฉบับ โดยเลื่อน next_attempt_at ออกไปเท่ากับ lease
+// ถ้า worker หยุดทำงานระหว่างส่ง อีเมลจะกลับมาให้ส่งใหม่เมื่อหมด lease
+// SKIP LOCKED ทำให้รัน worker หลาย instance พร้อมกันได้โดยไม่ส่งซ้ำ
+func (pdb *PostgresDatabase) ClaimEmails(ctx context.Context, limit int, lease time.Duration) ([]OutboxEmail, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		UPDATE email_outbox
+		SET attempts = attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE email_id IN (
+			SELECT email_id FROM email_outbox
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING email_id, template, locale, recipient_email, COALESCE(recipient_name, ''), payload, attempts, created_at
+	`, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim emails: %w", err)
+	}
+	defer rows.Close()
+
+	emails := []OutboxEmail{}
+	for rows.Next() {
+		var email OutboxEmail
+		if err := rows.Scan(&email.ID, &email.Template, &email.Locale, &email.RecipientEmail, &email.RecipientName,
+			&email.Payload, &email.Attempts, &email.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		emails = append(emails, email)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return emails, nil
+}
+
+func (pdb *PostgresDatabase) MarkEmailSent(ctx context.Context, emailID string) error {
+	_, err := pdb.db.ExecContext(ctx, `
+		UPDATE email_outbox SET status = 'sent', sent_at = NOW(), last_error = NULL WHERE email_id = $1
+	`, emailID)
+	if err != nil {
+		return fmt.Errorf("failed to mark email sent: %w", err)
+	}
+	return nil
+}
+
+// RetryEmail บันทึกข้อผิดพลาดและเลื่อนการส่งครั้งถัดไปออกไป retryAfter
+// เมื่อพยายามครบ MaxEmailAttempts แล้วจะเปลี่ยนเป็น failed และไม่ส่งอีก
+func (pdb *PostgresDatabase) RetryEmail(ctx context.Context, emailID, lastError string, retryAfter time.Duration) error {
+	_, err := pdb.db.ExecContext(ctx, `
+		UPDATE email_outbox
+		SET status = CASE WHEN attempts >= $4 THEN 'failed'::email_status ELSE 'pending'::email_status END,
+		    last_error = $2,
+		    next_attempt_at = NOW() + $3 * INTERVAL '1 second'
+		WHERE email_id = $1
+	`, emailID, lastError, retryAfter.Seconds(), MaxEmailAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule email: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) ClaimEmails(ctx context.Context, limit int, lease time.Duration) ([]OutboxEmail, error) {
+	return s.db.ClaimEmails(ctx, limit, lease)
+}
+
+func (s *Store) MarkEmailSent(ctx context.Context, emailID string) error {
+	return s.db.MarkEmailSent(ctx, emailID)
+}
+
+func (s *Store) RetryEmail(ctx context.Context, emailID, lastError string, retryAfter time.Duration) error {
+	return s.db.RetryEmail(ctx, emailID, lastError, retryAfter)
+}
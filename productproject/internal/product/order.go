@@ -0,0 +1,493 @@
+// order.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Order struct {
+	ID              string          `json:"id"`
+	Status          string          `json:"status"` // 'pending', 'paid', 'shipped', 'delivered', 'cancelled', 'refunded'
+	Items           []OrderItem     `json:"items"`
+	Shipments       []OrderShipment `json:"shipments"`
+	Subtotal        Money           `json:"subtotal"`
+	Discount        Money           `json:"discount"`
+	ShippingTotal   Money           `json:"shipping_total"`
+	Total           Money           `json:"total"`
+	VATTotal        Money           `json:"vat_total"`        // ภาษีที่รวมอยู่ใน Total ของร้านที่จดทะเบียน VAT
+	ShippingAddress *PostalAddress  `json:"shipping_address"` // สำเนา ณ เวลาที่สั่งซื้อ คำสั่งซื้อเก่าที่ไม่มีที่อยู่จะเป็น null
+	BillingAddress  *PostalAddress  `json:"billing_address"`
+	PaidAt          *time.Time      `json:"paid_at"`
+	DeliveredAt     *time.Time      `json:"delivered_at"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// OrderItem เก็บชื่อ ตัวเลือก และราคา ณ เวลาที่สั่งซื้อ ProductID ว่างถ้าสินค้าถูกลบไปแล้ว
+// Discount คือส่วนแบ่งของส่วนลดทั้งคำสั่งซื้อ ภาษีคิดจากยอดหลังหักส่วนลด (LineTotal - Discount)
+// ซึ่งเท่ากับ NetAmount + VATAmount
+type OrderItem struct {
+	ID          string `json:"id"`
+	ProductID   string `json:"product_id"`
+	VariantID   string `json:"variant_id,omitempty"`
+	SellerID    string `json:"seller_id"`
+	ProductName string `json:"product_name"`
+	VariantSKU  string `json:"variant_sku,omitempty"`
+	VariantName string `json:"variant_name,omitempty"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   Money  `json:"unit_price"`
+	LineTotal   Money  `json:"line_total"`
+	Discount    Money  `json:"discount"`
+	VATRate     string `json:"vat_rate"` // เปอร์เซ็นต์ เช่น "7.00" ร้านที่ไม่ได้จดทะเบียน VAT เป็น "0.00"
+	VATAmount   Money  `json:"vat_amount"`
+	NetAmount   Money  `json:"net_amount"` // มูลค่าก่อนภาษี
+}
+
+type OrderShipment struct {
+	SellerID           string     `json:"seller_id"`
+	WeightGrams        int        `json:"weight_grams"`
+	Cost               Money      `json:"cost"`
+	FreeShippingReason string     `json:"free_shipping_reason,omitempty"`
+	VATRate            string     `json:"vat_rate"`
+	VATAmount          Money      `json:"vat_amount"` // ภาษีที่รวมอยู่ใน Cost
+	Status             string     `json:"status"`     // 'pending', 'shipped', 'delivered'
+	Carrier            string     `json:"carrier,omitempty"`
+	TrackingNumber     string     `json:"tracking_number,omitempty"`
+	ShippedAt          *time.Time `json:"shipped_at"`
+	DeliveredAt        *time.Time `json:"delivered_at"`
+}
+
+// NewCheckout อ้างอิงที่อยู่ในสมุดที่อยู่ของผู้ใช้ ถ้าไม่ระบุที่อยู่ใบเสร็จจะใช้ที่อยู่จัดส่ง
+type NewCheckout struct {
+	ShippingAddressID string `json:"shipping_address_id" binding:"required,uuid"`
+	BillingAddressID  string `json:"billing_address_id" binding:"omitempty,uuid"`
+}
+
+// variantName แปลงตัวเลือกเป็นข้อความเดียว เช่น "color: red, size: M" เรียงตามชื่อตัวเลือก
+func variantName(options map[string]string) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + options[name]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Checkout สร้างคำสั่งซื้อจากตะกร้าใน transaction เดียว: ตัดสต็อก นับจำนวนของ flash sale
+// บันทึกการใช้โปรโมชันและค่าจัดส่งของแต่ละร้าน เก็บสำเนาที่อยู่ เขียนอีเมลยืนยันลง outbox แล้วล้างตะกร้า
+// ราคาเป็นราคาเดียวกับที่ผู้ใช้เห็นในตะกร้า ชื่อสินค้าเก็บเป็นภาษาหลัก
+// ส่วนลดถูกกระจายลงแต่ละรายการ และแยก VAT ออกจากราคาของร้านที่จดทะเบียน VAT ณ เวลาที่สั่งซื้อ
+func (pdb *PostgresDatabase) Checkout(ctx context.Context, userID string, checkout NewCheckout) (Order, error) {
+	cart, err := pdb.GetCart(WithLocale(ctx, DefaultLocale), userID)
+	if err != nil {
+		return Order{}, err
+	}
+	if len(cart.Items) == 0 {
+		return Order{}, conflict("cart is empty")
+	}
+	for _, item := range cart.Items {
+		if !item.InStock {
+			return Order{}, conflict("%s is not available in the requested quantity", item.Name)
+		}
+	}
+
+	quote, err := pdb.quoteCartShipping(ctx, cart)
+	if err != nil {
+		return Order{}, err
+	}
+
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	shippingAddress, err := getAddress(ctx, tx, userID, checkout.ShippingAddressID)
+	if err != nil {
+		return Order{}, err
+	}
+	billingAddress := shippingAddress
+	if checkout.BillingAddressID != "" && checkout.BillingAddressID != checkout.ShippingAddressID {
+		if billingAddress, err = getAddress(ctx, tx, userID, checkout.BillingAddressID); err != nil {
+			return Order{}, err
+		}
+	}
+	shippingJSON, err := json.Marshal(shippingAddress.PostalAddress)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to encode shipping address: %w", err)
+	}
+	billingJSON, err := json.Marshal(billingAddress.PostalAddress)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to encode billing address: %w", err)
+	}
+
+	sellerIDs := make([]string, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		sellerIDs = append(sellerIDs, item.SellerID)
+	}
+	vatRegistered, err := getVATRegisteredSellers(ctx, tx, sellerIDs)
+	if err != nil {
+		return Order{}, err
+	}
+
+	discounts := allocateDiscounts(cart.Items, cart.Promotions)
+	itemVAT := make([]Money, len(cart.Items))
+	vatTotal := NewMoney(0, cart.Total.Currency())
+	for i, item := range cart.Items {
+		if itemVAT[i], err = includedVAT(item.LineTotal.Sub(discounts[i]), vatRateFor(vatRegistered[item.SellerID])); err != nil {
+			return Order{}, err
+		}
+		vatTotal = vatTotal.Add(itemVAT[i])
+	}
+	shipmentVAT := make([]Money, len(quote.Shipments))
+	for i, shipment := range quote.Shipments {
+		if shipmentVAT[i], err = includedVAT(shipment.Cost, vatRateFor(vatRegistered[shipment.SellerID])); err != nil {
+			return Order{}, err
+		}
+		vatTotal = vatTotal.Add(shipmentVAT[i])
+	}
+
+	var orderID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO orders (user_id, status, subtotal, discount_amount, shipping_amount, total_amount, vat_amount, shipping_address, billing_address)
+		VALUES ($1, 'pending', $2, $3, $4, $5, $6, $7, $8)
+		RETURNING order_id
+	`, userID, cart.Subtotal, cart.Discount, quote.Total, cart.Total.Add(quote.Total), vatTotal, shippingJSON, billingJSON).Scan(&orderID)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	for i, item := range cart.Items {
+		if err := reserveCartItem(ctx, tx, item); err != nil {
+			return Order{}, err
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO order_items (order_id, product_id, seller_id, product_name, quantity, unit_price, variant_id, variant_sku, variant_name,
+			                         discount_amount, vat_rate, vat_amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10, $11, $12)
+		`, orderID, item.ProductID, item.SellerID, item.Name, item.Quantity, item.UnitPrice,
+			nullString(item.VariantID), item.VariantSKU, variantName(item.VariantOptions),
+			discounts[i], vatRateFor(vatRegistered[item.SellerID]), itemVAT[i])
+		if err != nil {
+			return Order{}, fmt.Errorf("failed to add order item: %w", err)
+		}
+	}
+
+	for i, shipment := range quote.Shipments {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO order_shipments (order_id, seller_id, weight_grams, shipping_cost, free_shipping_reason, vat_rate, vat_amount)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+		`, orderID, shipment.SellerID, shipment.WeightGrams, shipment.Cost, shipment.FreeShippingReason,
+			vatRateFor(vatRegistered[shipment.SellerID]), shipmentVAT[i])
+		if err != nil {
+			return Order{}, fmt.Errorf("failed to add order shipment: %w", err)
+		}
+	}
+
+	for _, result := range cart.Promotions {
+		if !result.Applied {
+			continue
+		}
+		if err := redeemPromotion(ctx, tx, userID, orderID, result); err != nil {
+			return Order{}, err
+		}
+	}
+
+	if err := enqueueOrderConfirmation(ctx, tx, userID, orderID, cart, quote.Total, shippingAddress.PostalAddress); err != nil {
+		return Order{}, err
+	}
+	placed := OrderPlaced{OrderID: orderID, UserID: userID, Total: cart.Total.Add(quote.Total)}
+	for _, shipment := range quote.Shipments {
+		placed.SellerIDs = append(placed.SellerIDs, shipment.SellerID)
+	}
+	if err := publish(ctx, tx, placed); err != nil {
+		return Order{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cart_items WHERE cart_id = $1`, cart.ID); err != nil {
+		return Order{}, fmt.Errorf("failed to clear cart: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cart_promotion_codes WHERE cart_id = $1`, cart.ID); err != nil {
+		return Order{}, fmt.Errorf("failed to clear cart promotion codes: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Order{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pdb.GetOrder(ctx, userID, orderID)
+}
+
+// enqueueOrderConfirmation เขียนอีเมลยืนยันคำสั่งซื้อลง outbox ตามภาษาที่ผู้ใช้เลือกไว้
+// ถ้ายังไม่ได้เลือกจะใช้ภาษาของ request
+func enqueueOrderConfirmation(ctx context.Context, tx *sql.Tx, userID, orderID string, cart Cart, shipping Money, address PostalAddress) error {
+	var email, name, locale string
+	err := tx.QueryRowContext(ctx, `
+		SELECT email, full_name, COALESCE(locale, '') FROM users WHERE user_id = $1
+	`, userID).Scan(&email, &name, &locale)
+	if err != nil {
+		return fmt.Errorf("failed to get customer: %w", err)
+	}
+	if locale == "" {
+		locale = requestLocale(ctx)
+	}
+
+	payload := OrderEmail{
+		OrderID:         orderID,
+		CustomerName:    name,
+		Items:           make([]OrderEmailItem, len(cart.Items)),
+		Subtotal:        cart.Subtotal,
+		Discount:        cart.Discount,
+		ShippingTotal:   shipping,
+		Total:           cart.Total.Add(shipping),
+		ShippingAddress: address,
+	}
+	for i, item := range cart.Items {
+		payload.Items[i] = OrderEmailItem{
+			ProductName: item.Name,
+			VariantName: variantName(item.VariantOptions),
+			Quantity:    item.Quantity,
+			LineTotal:   item.LineTotal,
+		}
+	}
+	return enqueueEmail(ctx, tx, EmailOrderConfirmation, locale, email, name, payload)
+}
+
+// reserveCartItem ตัดสต็อกของสินค้าหรือตัวเลือก และนับจำนวนที่ขายได้ของราคาลด
+// เงื่อนไขใน WHERE กันไม่ให้สต็อกติดลบหรือขาย flash sale เกินจำนวนเมื่อมีคำสั่งซื้อพร้อมกัน
+func reserveCartItem(ctx context.Context, tx *sql.Tx, item CartItem) error {
+	var result sql.Result
+	var err error
+	if item.VariantID != "" {
+		result, err = tx.ExecContext(ctx, `
+			UPDATE variant_inventory SET quantity = quantity - $1, updated_at = NOW()
+			WHERE variant_id = $2 AND quantity >= $1
+		`, item.Quantity, item.VariantID)
+	} else {
+		result, err = tx.ExecContext(ctx, `
+			UPDATE inventory SET quantity = quantity - $1
+			WHERE product_id = $2 AND quantity >= $1
+		`, item.Quantity, item.ProductID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update stock: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return conflict("%s is out of stock", item.Name)
+	}
+
+	if item.SaleID == "" {
+		return nil
+	}
+	result, err = tx.ExecContext(ctx, `
+		UPDATE product_sales SET sold_quantity = sold_quantity + $1
+		WHERE sale_id = $2 AND (quantity_limit IS NULL OR sold_quantity + $1 <= quantity_limit)
+	`, item.Quantity, item.SaleID)
+	if err != nil {
+		return fmt.Errorf("failed to update product sale: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return conflict("not enough sale quantity left for %s, please review your cart", item.Name)
+	}
+	return nil
+}
+
+// redeemPromotion บันทึกการใช้โปรโมชัน ล็อกแถวของโปรโมชันก่อนนับเพื่อไม่ให้ใช้เกินสิทธิ์เมื่อสั่งซื้อพร้อมกัน
+func redeemPromotion(ctx context.Context, tx *sql.Tx, userID, orderID string, result PromotionResult) error {
+	var usageLimit, perUserLimit sql.NullInt64
+	err := tx.QueryRowContext(ctx, `
+		SELECT usage_limit, per_user_limit FROM promotions WHERE promotion_id = $1 FOR UPDATE
+	`, result.PromotionID).Scan(&usageLimit, &perUserLimit)
+	if err == sql.ErrNoRows {
+		return conflict("promotion %s is no longer available", result.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get promotion: %w", err)
+	}
+
+	var total, user int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE user_id = $2)
+		FROM promotion_redemptions WHERE promotion_id = $1
+	`, result.PromotionID, userID).Scan(&total, &user)
+	if err != nil {
+		return fmt.Errorf("failed to get promotion usage: %w", err)
+	}
+	if (usageLimit.Valid && total >= usageLimit.Int64) || (perUserLimit.Valid && user >= perUserLimit.Int64) {
+		return conflict("promotion %s has reached its usage limit", result.Name)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO promotion_redemptions (promotion_id, user_id, order_id, discount)
+		VALUES ($1, $2, $3, $4)
+	`, result.PromotionID, userID, orderID, result.Discount)
+	if err != nil {
+		return fmt.Errorf("failed to redeem promotion: %w", err)
+	}
+	return nil
+}
+
+const orderColumns = `order_id, status, subtotal, discount_amount, shipping_amount, total_amount, vat_amount,
+		       shipping_address, billing_address, paid_at, delivered_at, created_at, updated_at`
+
+func scanOrder(row rowScanner) (Order, error) {
+	var order Order
+	var shippingAddress, billingAddress []byte
+	err := row.Scan(&order.ID, &order.Status, &order.Subtotal, &order.Discount, &order.ShippingTotal, &order.Total, &order.VATTotal,
+		&shippingAddress, &billingAddress, &order.PaidAt, &order.DeliveredAt, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return Order{}, err
+	}
+	if shippingAddress != nil {
+		if err := json.Unmarshal(shippingAddress, &order.ShippingAddress); err != nil {
+			return Order{}, fmt.Errorf("failed to decode shipping address: %w", err)
+		}
+	}
+	if billingAddress != nil {
+		if err := json.Unmarshal(billingAddress, &order.BillingAddress); err != nil {
+			return Order{}, fmt.Errorf("failed to decode billing address: %w", err)
+		}
+	}
+	return order, nil
+}
+
+func (pdb *PostgresDatabase) GetOrders(ctx context.Context, userID string) ([]Order, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT `+orderColumns+`
+		FROM orders
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []Order{}
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	rows.Close()
+
+	for i := range orders {
+		if err := pdb.loadOrderDetails(ctx, &orders[i]); err != nil {
+			return nil, err
+		}
+	}
+	return orders, nil
+}
+
+func (pdb *PostgresDatabase) GetOrder(ctx context.Context, userID, orderID string) (Order, error) {
+	order, err := scanOrder(pdb.db.QueryRowContext(ctx, `
+		SELECT `+orderColumns+`
+		FROM orders
+		WHERE user_id = $1 AND order_id = $2
+	`, userID, orderID))
+	if err == sql.ErrNoRows {
+		return Order{}, notFound("order not found")
+	}
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if err := pdb.loadOrderDetails(ctx, &order); err != nil {
+		return Order{}, err
+	}
+	return order, nil
+}
+
+// loadOrderDetails ดึงรายการสินค้าและค่าจัดส่งของคำสั่งซื้อ
+func (pdb *PostgresDatabase) loadOrderDetails(ctx context.Context, order *Order) error {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT order_item_id, COALESCE(product_id::text, ''), COALESCE(variant_id::text, ''), seller_id,
+		       product_name, COALESCE(variant_sku, ''), COALESCE(variant_name, ''), quantity, unit_price,
+		       discount_amount, vat_rate, vat_amount
+		FROM order_items
+		WHERE order_id = $1
+		ORDER BY created_at, order_item_id
+	`, order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get order items: %w", err)
+	}
+	defer rows.Close()
+
+	order.Items = []OrderItem{}
+	for rows.Next() {
+		var item OrderItem
+		if err := rows.Scan(&item.ID, &item.ProductID, &item.VariantID, &item.SellerID,
+			&item.ProductName, &item.VariantSKU, &item.VariantName, &item.Quantity, &item.UnitPrice,
+			&item.Discount, &item.VATRate, &item.VATAmount); err != nil {
+			return fmt.Errorf("failed to scan order item: %w", err)
+		}
+		item.LineTotal = item.UnitPrice.Mul(item.Quantity)
+		item.NetAmount = item.LineTotal.Sub(item.Discount).Sub(item.VATAmount)
+		order.Items = append(order.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+	rows.Close()
+
+	shipmentRows, err := pdb.db.QueryContext(ctx, `SELECT `+orderShipmentColumns+` FROM order_shipments WHERE order_id = $1`, order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get order shipments: %w", err)
+	}
+	defer shipmentRows.Close()
+
+	order.Shipments = []OrderShipment{}
+	for shipmentRows.Next() {
+		shipment, err := scanOrderShipment(shipmentRows)
+		if err != nil {
+			return fmt.Errorf("failed to scan order shipment: %w", err)
+		}
+		order.Shipments = append(order.Shipments, shipment)
+	}
+	if err := shipmentRows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+	return nil
+}
+
+const orderShipmentColumns = `seller_id, weight_grams, shipping_cost, COALESCE(free_shipping_reason, ''), vat_rate, vat_amount,
+		       status, COALESCE(carrier, ''), COALESCE(tracking_number, ''), shipped_at, delivered_at`
+
+func scanOrderShipment(row rowScanner) (OrderShipment, error) {
+	var shipment OrderShipment
+	err := row.Scan(&shipment.SellerID, &shipment.WeightGrams, &shipment.Cost, &shipment.FreeShippingReason,
+		&shipment.VATRate, &shipment.VATAmount, &shipment.Status, &shipment.Carrier, &shipment.TrackingNumber,
+		&shipment.ShippedAt, &shipment.DeliveredAt)
+	return shipment, err
+}
+
+func (s *Store) Checkout(ctx context.Context, userID string, checkout NewCheckout) (Order, error) {
+	return s.db.Checkout(ctx, userID, checkout)
+}
+
+func (s *Store) GetOrders(ctx context.Context, userID string) ([]Order, error) {
+	return s.db.GetOrders(ctx, userID)
+}
+
+func (s *Store) GetOrder(ctx context.Context, userID, orderID string) (Order, error) {
+	return s.db.GetOrder(ctx, userID, orderID)
+}
@@ -0,0 +1,73 @@
+// payment.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PaymentConfirmation คือการยืนยันว่าได้รับเงินของคำสั่งซื้อแล้ว จากผู้ให้บริการชำระเงินหรือฝ่ายบัญชี
+// Reference คือเลขอ้างอิงการชำระเงิน การยืนยันซ้ำด้วยเลขเดิมจะคืนคำสั่งซื้อเดิมโดยไม่เปลี่ยนแปลงอะไร
+type PaymentConfirmation struct {
+	Reference string `json:"reference" binding:"notblank,max=100"`
+	Amount    Money  `json:"amount" binding:"gt=0"`
+}
+
+// ConfirmPayment เปลี่ยนคำสั่งซื้อจาก pending เป็น paid เมื่อยอดที่ชำระตรงกับยอดรวมของคำสั่งซื้อ
+// หลังจากนี้ร้านจึงแจ้งการจัดส่ง ออกใบกำกับภาษี และยอดขายจึงถูกนับในรายงาน
+func (pdb *PostgresDatabase) ConfirmPayment(ctx context.Context, orderID string, confirmation PaymentConfirmation) (Order, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status, reference string
+	var total Money
+	err = tx.QueryRowContext(ctx, `
+		SELECT status, total_amount, COALESCE(payment_reference, '') FROM orders WHERE order_id = $1 FOR UPDATE
+	`, orderID).Scan(&status, &total, &reference)
+	if err == sql.ErrNoRows {
+		return Order{}, notFound("order not found")
+	}
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	switch {
+	case status != "pending" && reference == confirmation.Reference:
+		// ผู้ให้บริการส่งการยืนยันเดิมซ้ำ
+	case status != "pending":
+		return Order{}, conflict("cannot confirm payment of a %s order", status)
+	case confirmation.Amount.Cmp(total) != 0:
+		return Order{}, conflict("payment amount %s does not match the order total %s", confirmation.Amount, total)
+	default:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE orders SET status = 'paid', paid_at = NOW(), payment_reference = $2 WHERE order_id = $1
+		`, orderID, confirmation.Reference)
+		if err != nil {
+			return Order{}, fmt.Errorf("failed to confirm payment: %w", err)
+		}
+		if err := publish(ctx, tx, OrderStatusChanged{OrderID: orderID, FromStatus: status, ToStatus: "paid"}); err != nil {
+			return Order{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Order{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	order, err := scanOrder(pdb.db.QueryRowContext(ctx, `SELECT `+orderColumns+` FROM orders WHERE order_id = $1`, orderID))
+	if err != nil {
+		return Order{}, fmt.Errorf("failed to get order: %w", err)
+	}
+	if err := pdb.loadOrderDetails(ctx, &order); err != nil {
+		return Order{}, err
+	}
+	return order, nil
+}
+
+func (s *Store) ConfirmPayment(ctx context.Context, orderID string, confirmation PaymentConfirmation) (Order, error) {
+	return s.db.ConfirmPayment(ctx, orderID, confirmation)
+}
@@ -20,7 +20,7 @@ type Product struct {
 	Brand          string    `json:"brand"`
 	ModelNumber    string    `json:"model_number"`
 	SKU            string    `json:"sku"`
-	Price          float64   `json:"price"`
+	Price          Money     `json:"price"`
 	Availability   string    `json:"availability"`   // สถานะการใช้งาน เช่น 'active', 'inactive'
 	Recommendation string    `json:"recommendation"` // สถานะแนะนำสินค้า เช่น 'recommended', 'normal'
 	SellerID       string    `json:"seller_id"`
@@ -28,29 +28,60 @@ type Product struct {
 	CategoryID     int       `json:"category_id"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Dimensions มีเฉพาะตอนเพิ่ม แก้ไข และดูรายละเอียดสินค้า
+	Dimensions *ProductDimensions `json:"dimensions,omitempty"`
 }
 
+// NewProduct ใช้กฎใน binding tag ทั้งตอนรับ request และตอนนำเข้าจากไฟล์ (ดู validation.go)
 type NewProduct struct {
-	Name           string          `json:"name"`
+	Name           string          `json:"name" binding:"notblank,max=255"`
 	Description    string          `json:"description"`
-	Brand          string          `json:"brand"`
-	ModelNumber    string          `json:"model_number"`
-	SKU            string          `json:"sku"`
-	Price          float64         `json:"price"`
-	Availability   string          `json:"availability"`   // สถานะการใช้งาน เช่น 'active', 'inactive'
-	Recommendation string          `json:"recommendation"` // สถานะแนะนำสินค้า เช่น 'recommended', 'normal'
-	SellerID       string          `json:"seller_id"`
-	ProductType    string          `json:"product_type"`
-	CategoryID     int             `json:"category_id"`
-	Quantity       int             `json:"quantity"`
-	Values         json.RawMessage `json:"values"`
-	OptName        string          `json:"optname"`
+	Brand          string          `json:"brand" binding:"max=255"`
+	ModelNumber    string          `json:"model_number" binding:"max=100"`
+	SKU            string          `json:"sku" binding:"sku"`
+	Price          Money           `json:"price" binding:"gte=0,lte=99999999.99"`           // NUMERIC(10, 2)
+	Availability   string          `json:"availability" binding:"product_availability"`     // สถานะการใช้งาน เช่น 'active', 'inactive'
+	Recommendation string          `json:"recommendation" binding:"product_recommendation"` // สถานะแนะนำสินค้า เช่น 'recommended', 'normal'
+	SellerID       string          `json:"seller_id" binding:"required,uuid"`
+	ProductType    string          `json:"product_type" binding:"product_type"`
+	CategoryID     int             `json:"category_id" binding:"gt=0"`
+	Quantity       int             `json:"quantity" binding:"gte=0"`
+	Values         json.RawMessage `json:"values" binding:"omitempty,rawjson"`
+	OptName        string          `json:"optname" binding:"max=255"`
+
+	// น้ำหนักและขนาดสำหรับคิดค่าจัดส่ง (ดู shipping.go)
+	Dimensions ProductDimensions `json:"dimensions"`
+}
+
+// ค่าที่อนุญาตตาม ENUM ในฐานข้อมูล
+var (
+	productAvailabilities  = []string{"active", "inactive"}
+	productRecommendations = []string{"recommended", "normal"}
+	productTypes           = []string{"food", "toy", "medicine", "shelter"}
+)
+
+// Validate ตรวจข้อมูลสินค้าก่อนบันทึก ใช้ทั้งตอนเพิ่มสินค้าทีละรายการและตอนนำเข้าจากไฟล์
+func (p NewProduct) Validate() error {
+	if err := ValidateStruct(p); err != nil {
+		return err
+	}
+	if p.OptName == "" && len(p.Values) > 0 && string(p.Values) != "null" {
+		return invalidFields(FieldError{Field: "optname", Message: "is required when values is set"})
+	}
+	return nil
 }
 
 type UpdateProduct struct {
-	Price          float64 `json:"price"`
-	Availability   string  `json:"availability"`   // สถานะการใช้งาน เช่น 'active', 'inactive'
-	Recommendation string  `json:"recommendation"` // สถานะแนะนำสินค้า เช่น 'recommended', 'normal'
+	Price          Money  `json:"price" binding:"gte=0,lte=99999999.99"`
+	Availability   string `json:"availability" binding:"product_availability"`     // สถานะการใช้งาน เช่น 'active', 'inactive'
+	Recommendation string `json:"recommendation" binding:"product_recommendation"` // สถานะแนะนำสินค้า เช่น 'recommended', 'normal'
+
+	// Dimensions แทนที่น้ำหนักและขนาดเดิมทั้งหมด ถ้าไม่ส่งมาจะคงค่าเดิม
+	Dimensions *ProductDimensions `json:"dimensions"`
+
+	// Translations แก้ไขเฉพาะภาษาที่ส่งมา ส่งชื่อและคำอธิบายว่างเพื่อลบคำแปลของภาษานั้น
+	Translations map[string]Translation `json:"translations" binding:"omitempty,dive,keys,translation_locale,endkeys"`
 }
 
 type ProductImage struct {
@@ -61,31 +92,40 @@ type ProductImage struct {
 	AltText   string    `json:"alt_text"`
 	SortOrder int       `json:"sort_order"`
 	CreatedAt time.Time `json:"created_at"`
+
+	// Variants ว่างจนกว่า worker จะประมวลผลรูปเสร็จ หน้าเว็บควรใช้ image_url แทนในระหว่างนั้น
+	Variants    ImageVariants `json:"variants,omitempty"`
+	Placeholder string        `json:"placeholder,omitempty"`
+	VariantID   string        `json:"variant_id,omitempty"` // รูปของตัวเลือกสินค้า (ถ้ามี)
 }
 
 type NewProductImage struct {
-	ImageURL  string `json:"image_url"`
+	ImageURL  string `json:"image_url" binding:"required,http_url,max=255"`
 	IsPrimary bool   `json:"is_primary"`
-	AltText   string `json:"alt_text"`
-	SortOrder int    `json:"sort_order"`
+	AltText   string `json:"alt_text" binding:"max=255"`
+	SortOrder *int   `json:"sort_order" binding:"omitempty,gte=0"` // ตำแหน่งที่ต้องการแทรก ถ้าไม่ระบุจะต่อท้าย
 }
 
+// UpdateProductImage แก้ไขเฉพาะ field ที่ส่งมา
 type UpdateProductImage struct {
-	IsPrimary bool `json:"is_primary"`
-	SortOrder int  `json:"sort_order"`
+	IsPrimary *bool   `json:"is_primary"`
+	SortOrder *int    `json:"sort_order" binding:"omitempty,gte=0"`
+	AltText   *string `json:"alt_text" binding:"omitempty,max=255"`
 }
 
 type ProductQueryParams struct {
-	Cursor         string `json:"cursor"`
-	Limit          int    `json:"limit"`
-	Search         string `json:"search"`
-	CategoryID     int    `json:"category_id"`
-	SellerID       string `json:"seller_id"`
-	Availability   string `json:"availability"`   // สถานะการใช้งาน เช่น 'active', 'inactive'
-	Recommendation string `json:"recommendation"` // สถานะแนะนำสินค้า เช่น 'recommended', 'normal'
-	ProductType    string `json:"product_type"`
-	Sort           string `json:"sort"`
-	Order          string `json:"order"`
+	Cursor         string  `json:"cursor"`
+	Limit          int     `json:"limit"`
+	Search         string  `json:"search"`
+	CategoryID     int     `json:"category_id"`
+	SellerID       string  `json:"seller_id"`
+	Availability   string  `json:"availability"`   // สถานะการใช้งาน เช่น 'active', 'inactive'
+	Recommendation string  `json:"recommendation"` // สถานะแนะนำสินค้า เช่น 'recommended', 'normal'
+	ProductType    string  `json:"product_type"`
+	Sort           string  `json:"sort"`
+	Order          string  `json:"order"`
+	MinRating      float64 `json:"min_rating"`
+	OnSale         bool    `json:"on_sale"` // เฉพาะสินค้าที่กำลังลดราคา
 }
 
 type ProductResponse struct {
@@ -96,15 +136,29 @@ type ProductResponse struct {
 
 type ProductItem struct {
 	Product
-	Categories []Category      `json:"categories"`
-	Inventory  Inventory       `json:"inventory"`
-	Images     []ProductImage  `json:"images"`
-	Options    []ProductOption `json:"options"`
+	RatingAverage float64         `json:"rating_average"`
+	RatingCount   int             `json:"rating_count"`
+	Categories    []Category      `json:"categories"`
+	Inventory     Inventory       `json:"inventory"`
+	Images        []ProductImage  `json:"images"`
+	Options       []ProductOption `json:"options"`
+
+	// VariantOptions, Variants และ Translations มีเฉพาะใน GetProduct
+	VariantOptions []VariantOption        `json:"variant_options,omitempty"`
+	Variants       []ProductVariant       `json:"variants,omitempty"`
+	Translations   map[string]Translation `json:"translations,omitempty"`
+
+	// มีเฉพาะระหว่างช่วงลดราคา (ดู sale.go) ตอนนั้น Price คือราคาลดและ OriginalPrice คือราคาปกติ
+	OriginalPrice    *Money     `json:"original_price,omitempty"`
+	SalePrice        *Money     `json:"sale_price,omitempty"`
+	SaleEndsAt       *time.Time `json:"sale_ends_at,omitempty"`
+	SaleQuantityLeft *int       `json:"sale_quantity_left,omitempty"` // จำนวนที่เหลือของ flash sale
 }
 
 type Category struct {
-	ID   int    `json:"category_id"`
-	Name string `json:"name"`
+	ID          int    `json:"category_id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
 }
 
 type CategoryWithProducts struct {
@@ -127,6 +181,9 @@ type Seller struct {
 	Address     string    `json:"address"`     // character varying(255), optional
 	Phone       string    `json:"phone"`       // character varying(20), optional
 	Email       string    `json:"email"`       // character varying(255), optional
+
+	VerificationStatus string `json:"verification_status"` // 'applied', 'documents_submitted', 'in_review', 'approved', 'rejected'
+	VATRegistered      bool   `json:"vat_registered"`      // ร้านที่จดทะเบียน VAT ออกใบกำกับภาษีได้
 }
 
 type ProductOption struct {
@@ -136,31 +193,31 @@ type ProductOption struct {
 }
 
 type NewFood struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Brand       string  `json:"brand"`
-	Price       float64 `json:"price"` // ใช้ float64 เพื่อให้ตรงกับ NUMERIC(10, 2)
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Brand       string `json:"brand"`
+	Price       Money  `json:"price"` // NUMERIC(10, 2)
 }
 
 type NewMedicine struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Brand       string  `json:"brand"`
-	Price       float64 `json:"price"` // ใช้ float64 เพื่อให้ตรงกับ NUMERIC(10, 2)
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Brand       string `json:"brand"`
+	Price       Money  `json:"price"` // NUMERIC(10, 2)
 }
 
 type NewToy struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Brand       string  `json:"brand"`
-	Price       float64 `json:"price"` // ใช้ float64 เพื่อให้ตรงกับ NUMERIC(10, 2)
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Brand       string `json:"brand"`
+	Price       Money  `json:"price"` // NUMERIC(10, 2)
 }
 
 type NewShelter struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Brand       string  `json:"brand"`
-	Price       float64 `json:"price"` // ใช้ float64 เพื่อให้ตรงกับ NUMERIC(10, 2)
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Brand       string `json:"brand"`
+	Price       Money  `json:"price"` // NUMERIC(10, 2)
 }
 
 type EcommerceDatabase interface {
@@ -174,25 +231,151 @@ type EcommerceDatabase interface {
 	AddProductImage(ctx context.Context, productID string, image NewProductImage) (ProductImage, error)
 	UpdateProductImage(ctx context.Context, productID, imageID string, update UpdateProductImage) (ProductImage, error)
 	DeleteProductImage(ctx context.Context, productID, imageID string) error
+	ReorderProductImages(ctx context.Context, productID string, imageIDs []string) ([]ProductImage, error)
+
+	// ตัวเลือกสินค้า (Variants)
+	GetProductVariants(ctx context.Context, productID string) (ProductVariants, error)
+	GetProductVariant(ctx context.Context, productID, variantID string) (ProductVariant, error)
+	SetVariantOptions(ctx context.Context, productID string, options []VariantOption) ([]VariantOption, error)
+	AddProductVariant(ctx context.Context, productID string, variant NewProductVariant) (ProductVariant, error)
+	UpdateProductVariant(ctx context.Context, productID, variantID string, update UpdateProductVariant) (ProductVariant, error)
+	DeleteProductVariant(ctx context.Context, productID, variantID string) error
+	SaveProductImageVariants(ctx context.Context, productID, imageID string, variants ImageVariants, placeholder string) error
 	GetShopDetail(ctx context.Context, sellerID string) (Seller, error)
-	GetRecommendedProduct(ctx context.Context) ([]ProductItem, error)                   // เพิ่ม method ใหม่
+	GetRecommendedProduct(ctx context.Context, req RecommendationRequest) ([]RecommendedProduct, error) // แนะนำสินค้าตามกลยุทธ์ที่กำหนด
+	RecordProductView(ctx context.Context, productID, userID, sessionID string) error
 	GetNewProductSeller(ctx context.Context) ([]ProductItem, error)                     // method ใหม่สำหรับสินค้าล่าสุดจากแต่ละ Seller
 	GetAllProductImages(ctx context.Context) ([]ProductImage, error)                    // เพิ่ม method ใหม่สำหรับดึงรูปสินค้าทั้งหมด
 	GetDetailProductSeller(ctx context.Context, sellerID string) ([]ProductItem, error) // New method for seller product details
 	GetAllShops(ctx context.Context) ([]Seller, error)                                  // เพิ่ม method สำหรับดึงข้อมูลร้านค้าทั้งหมด
+	GetUserBySessionToken(ctx context.Context, token string) (User, error)
+
+	// การสมัครและยืนยันตัวตนผู้ขาย
+	ApplySeller(ctx context.Context, ownerUserID string, application SellerApplication) (SellerApplicationStatus, error)
+	GetSellerApplication(ctx context.Context, sellerID string) (SellerApplicationStatus, error)
+	SubmitSellerDocuments(ctx context.Context, sellerID string, documents []NewSellerDocument) (SellerApplicationStatus, error)
+	GetSellerReviewQueue(ctx context.Context) ([]SellerApplicationStatus, error)
+	ReviewSeller(ctx context.Context, sellerID, reviewerID string, review SellerReview) (SellerApplicationStatus, error)
+
+	// รายงานยอดขายของร้านค้า
+	GetShopAnalytics(ctx context.Context, sellerID string, params SalesAnalyticsParams) (SalesAnalytics, error)
+
+	// รีวิวและคะแนนสินค้า
+	HasDeliveredOrder(ctx context.Context, userID, productID string) (bool, error)
+	GetProductReviews(ctx context.Context, productID string, params ReviewQueryParams) (*ReviewResponse, error)
+	GetReview(ctx context.Context, productID, reviewID string) (Review, error)
+	AddReview(ctx context.Context, productID, userID string, review NewReview) (Review, error)
+	ReplyToReview(ctx context.Context, productID, reviewID string, reply ReviewReply) (Review, error)
+	VoteReviewHelpful(ctx context.Context, productID, reviewID, userID string) (Review, error)
+	UnvoteReviewHelpful(ctx context.Context, productID, reviewID, userID string) (Review, error)
+
+	// รายการสินค้าที่อยากได้
+	GetWishlists(ctx context.Context, userID string) ([]Wishlist, error)
+	GetWishlist(ctx context.Context, userID, wishlistID string) (Wishlist, error)
+	GetSharedWishlist(ctx context.Context, shareToken string) (Wishlist, error)
+	CreateWishlist(ctx context.Context, userID string, wishlist NewWishlist) (Wishlist, error)
+	DeleteWishlist(ctx context.Context, userID, wishlistID string) error
+	AddWishlistItem(ctx context.Context, userID, wishlistID string, item NewWishlistItem) (Wishlist, error)
+	RemoveWishlistItem(ctx context.Context, userID, wishlistID, productID string) (Wishlist, error)
+	ShareWishlist(ctx context.Context, userID, wishlistID string) (Wishlist, error)
+	UnshareWishlist(ctx context.Context, userID, wishlistID string) (Wishlist, error)
+
+	// ตะกร้าสินค้า
+	GetCart(ctx context.Context, userID string) (Cart, error)
+	AddCartItem(ctx context.Context, userID string, item NewCartItem) (Cart, error)
+	UpdateCartItem(ctx context.Context, userID, itemID string, update UpdateCartItem) (Cart, error)
+	RemoveCartItem(ctx context.Context, userID, itemID string) (Cart, error)
+
+	// โปรโมชันและโค้ดส่วนลด
+	GetPromotions(ctx context.Context) ([]Promotion, error)
+	GetPromotion(ctx context.Context, id string) (Promotion, error)
+	CreatePromotion(ctx context.Context, promotion NewPromotion) (Promotion, error)
+	UpdatePromotion(ctx context.Context, id string, promotion NewPromotion) (Promotion, error)
+	DeletePromotion(ctx context.Context, id string) error
+	ApplyPromotionCode(ctx context.Context, userID, code string) (Cart, error)
+	RemovePromotionCode(ctx context.Context, userID, code string) (Cart, error)
+
+	// ราคาลดตามช่วงเวลาและ flash sale
+	GetProductSales(ctx context.Context, productID string) ([]ProductSale, error)
+	CreateProductSale(ctx context.Context, productID string, sale NewProductSale) (ProductSale, error)
+	DeleteProductSale(ctx context.Context, productID, saleID string) error
+
+	// ค่าจัดส่งของแต่ละร้าน
+	GetShippingProfile(ctx context.Context, sellerID string) (ShippingProfile, error)
+	SetShippingProfile(ctx context.Context, sellerID string, profile NewShippingProfile) (ShippingProfile, error)
+	QuoteShipping(ctx context.Context, userID string) (ShippingQuote, error)
+
+	// สมุดที่อยู่และการสั่งซื้อ
+	GetAddresses(ctx context.Context, userID string) ([]Address, error)
+	GetAddress(ctx context.Context, userID, addressID string) (Address, error)
+	CreateAddress(ctx context.Context, userID string, address NewAddress) (Address, error)
+	UpdateAddress(ctx context.Context, userID, addressID string, address NewAddress) (Address, error)
+	DeleteAddress(ctx context.Context, userID, addressID string) error
+	Checkout(ctx context.Context, userID string, checkout NewCheckout) (Order, error)
+	GetOrders(ctx context.Context, userID string) ([]Order, error)
+	GetOrder(ctx context.Context, userID, orderID string) (Order, error)
+	ConfirmPayment(ctx context.Context, orderID string, confirmation PaymentConfirmation) (Order, error)
+	UpdateShipment(ctx context.Context, sellerID, orderID string, update ShipmentUpdate) (OrderShipment, error)
+
+	// ภาษีมูลค่าเพิ่มและใบกำกับภาษี
+	GetSellerTaxSettings(ctx context.Context, sellerID string) (SellerTaxSettings, error)
+	SetSellerTaxSettings(ctx context.Context, sellerID string, settings SellerTaxSettings) (SellerTaxSettings, error)
+	GetTaxInvoice(ctx context.Context, userID, orderID, sellerID string) (TaxInvoice, error)
+
+	// การคืนสินค้าและคืนเงิน
+	CreateReturnRequest(ctx context.Context, userID, orderID string, request NewReturnRequest) (ReturnRequest, error)
+	GetReturnRequests(ctx context.Context, userID string) ([]ReturnRequest, error)
+	GetReturnRequest(ctx context.Context, userID, returnID string) (ReturnRequest, error)
+	CancelReturnRequest(ctx context.Context, userID, returnID string) (ReturnRequest, error)
+	GetShopReturnRequests(ctx context.Context, sellerID, status string) ([]ReturnRequest, error)
+	GetShopReturnRequest(ctx context.Context, sellerID, returnID string) (ReturnRequest, error)
+	ReviewReturnRequest(ctx context.Context, sellerID, returnID, actorID string, action ReturnAction) (ReturnRequest, error)
+	StartReturnRefund(ctx context.Context, sellerID, returnID, actorID string) (ReturnRequest, error)
+	CompleteReturnRefund(ctx context.Context, returnID, actorID, reference string) (ReturnRequest, error)
+	FailReturnRefund(ctx context.Context, returnID, actorID, reason string) (ReturnRequest, error)
+
+	// อีเมลแจ้งเตือนใน outbox
+	ClaimEmails(ctx context.Context, limit int, lease time.Duration) ([]OutboxEmail, error)
+	MarkEmailSent(ctx context.Context, emailID string) error
+	RetryEmail(ctx context.Context, emailID, lastError string, retryAfter time.Duration) error
+
+	// domain events ที่รอส่งให้ subscriber
+	ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]QueuedEvent, error)
+	MarkEventProcessed(ctx context.Context, eventID string) error
+	RetryEvent(ctx context.Context, eventID, lastError string, retryAfter time.Duration) error
+
+	// นำเข้าสินค้าจากไฟล์
+	CreateImportJob(ctx context.Context, job NewImportJob) (ImportJob, error)
+	GetImportJob(ctx context.Context, jobID string) (ImportJob, error)
+	GetImportJobErrors(ctx context.Context, jobID string) ([]ImportJobError, error)
+	StartImportJob(ctx context.Context, jobID string, totalRows int) error
+	UpdateImportJobProgress(ctx context.Context, jobID string, progress ImportProgress, rowErrors []ImportJobError) error
+	FinishImportJob(ctx context.Context, jobID string, jobErr string) error
+	FailInterruptedImportJobs(ctx context.Context) error
+	ImportProduct(ctx context.Context, row CatalogProduct, upsert, dryRun bool) (string, error)
+
+	// ส่งออกแคตตาล็อก
+	ExportProducts(ctx context.Context, params ProductQueryParams, fn func(ExportProduct) error) error
+
+	// อัตราแลกเปลี่ยนสำหรับแสดงราคา
+	GetExchangeRates(ctx context.Context) ([]ExchangeRate, error)
+	GetExchangeRate(ctx context.Context, currency string) (ExchangeRate, error)
+	SetExchangeRates(ctx context.Context, rates []ExchangeRate, updatedBy string) ([]ExchangeRate, error)
+
 	Close() error
 	Ping() error
 	Reconnect(connStr string) error
 }
 
 type PostgresDatabase struct {
-	db *sql.DB
+	db                       *sql.DB
+	recommendationStrategies []RecommendationStrategy
 }
 
 func NewPostgresDatabase(connStr string) (*PostgresDatabase, error) {
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %v", err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	db.SetMaxOpenConns(25)
@@ -203,10 +386,12 @@ func NewPostgresDatabase(connStr string) (*PostgresDatabase, error) {
 	defer cancel()
 
 	if err := db.PingContext(ctx); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %v", err)
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &PostgresDatabase{db: db}, nil
+	pdb := &PostgresDatabase{db: db}
+	pdb.recommendationStrategies = defaultRecommendationStrategies(pdb)
+	return pdb, nil
 }
 
 func (pdb *PostgresDatabase) GetCategories(ctx context.Context) ([]CategoryWithProducts, error) {
@@ -216,7 +401,10 @@ func (pdb *PostgresDatabase) GetCategories(ctx context.Context) ([]CategoryWithP
 	// ดึงข้อมูลหมวดหมู่
 	categories, err := pdb.getCategories(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get categories: %v", err)
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	if err := pdb.localizeCategories(ctx, categories); err != nil {
+		return nil, err
 	}
 
 	for _, category := range categories {
@@ -243,7 +431,7 @@ func (pdb *PostgresDatabase) getProductsByCategory(ctx context.Context, category
 	rows, err := pdb.db.QueryContext(ctx, `
 		SELECT 
 			p.product_id, p.name, p.description, p.brand, p.model_number, p.sku, 
-			p.price, p.availability, p.recommendation, p.seller_id, p.product_type, p.created_at, p.updated_at,
+			p.price, p.availability, p.recommendation, p.seller_id, p.product_type, p.created_at, p.updated_at, p.rating_average, p.rating_count,
 			i.quantity,
 			c.category_id, c.name as category_name
 		FROM 
@@ -255,7 +443,7 @@ func (pdb *PostgresDatabase) getProductsByCategory(ctx context.Context, category
 		WHERE 
 			p.category_id = $1`, categoryID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query products: %v", err)
+		return nil, fmt.Errorf("failed to query products: %w", err)
 	}
 	defer rows.Close()
 
@@ -266,9 +454,9 @@ func (pdb *PostgresDatabase) getProductsByCategory(ctx context.Context, category
 		// เพิ่มการสแกนข้อมูลที่เกี่ยวข้องกับหมวดหมู่
 		if err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.Brand,
 			&product.ModelNumber, &product.SKU, &product.Price, &product.Availability,
-			&product.Recommendation, &product.SellerID, &product.ProductType, &product.CreatedAt, &product.UpdatedAt,
+			&product.Recommendation, &product.SellerID, &product.ProductType, &product.CreatedAt, &product.UpdatedAt, &product.RatingAverage, &product.RatingCount,
 			&product.Inventory.Quantity, &category.ID, &category.Name); err != nil {
-			return nil, fmt.Errorf("failed to scan product: %v", err)
+			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
 
 		product.Categories = []Category{category} // เพิ่มข้อมูลหมวดหมู่ไปยังผลิตภัณฑ์
@@ -276,22 +464,28 @@ func (pdb *PostgresDatabase) getProductsByCategory(ctx context.Context, category
 		// ดึงข้อมูลรูปภาพ
 		product.Images, err = pdb.GetProductImages(ctx, product.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get product images: %v", err)
+			return nil, fmt.Errorf("failed to get product images: %w", err)
 		}
 
 		// ดึงข้อมูลตัวเลือก
 		product.Options, err = pdb.getProductOptions(ctx, product.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get product options: %v", err)
+			return nil, fmt.Errorf("failed to get product options: %w", err)
 		}
 
 		products = append(products, product)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows iteration error: %v", err)
+		return nil, fmt.Errorf("rows iteration error: %w", err)
 	}
 
+	if err := pdb.localizeProducts(ctx, products); err != nil {
+		return nil, err
+	}
+	if err := pdb.applySales(ctx, products); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
@@ -299,22 +493,22 @@ func (pdb *PostgresDatabase) getProductsByCategory(ctx context.Context, category
 func (pdb *PostgresDatabase) getCategories(ctx context.Context) ([]Category, error) {
 	categories := []Category{}
 
-	rows, err := pdb.db.QueryContext(ctx, `SELECT category_id, name FROM categories`)
+	rows, err := pdb.db.QueryContext(ctx, `SELECT category_id, name, COALESCE(description, '') FROM categories`)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query categories: %v", err)
+		return nil, fmt.Errorf("failed to query categories: %w", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var category Category
-		if err := rows.Scan(&category.ID, &category.Name); err != nil {
-			return nil, fmt.Errorf("failed to scan category: %v", err)
+		if err := rows.Scan(&category.ID, &category.Name, &category.Description); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
 		}
 		categories = append(categories, category)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows iteration error: %v", err)
+		return nil, fmt.Errorf("rows iteration error: %w", err)
 	}
 
 	return categories, nil
@@ -326,7 +520,7 @@ func (pdb *PostgresDatabase) GetDetailProductSeller(ctx context.Context, sellerI
 	// Query ดึงสินค้าทั้งหมดของผู้ขายโดยเรียงตามวันที่ล่าสุดลงไป
 	rows, err := pdb.db.QueryContext(ctx, `
 		SELECT p.product_id, p.name, p.description, p.brand, p.model_number, p.sku, p.price,
-		       p.availability, p.recommendation, p.seller_id, p.product_type, p.created_at, p.updated_at,
+		       p.availability, p.recommendation, p.seller_id, p.product_type, p.created_at, p.updated_at, p.rating_average, p.rating_count,
 		       c.category_id, c.name as category_name
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.category_id
@@ -334,7 +528,7 @@ func (pdb *PostgresDatabase) GetDetailProductSeller(ctx context.Context, sellerI
 		ORDER BY p.created_at DESC
 	`, sellerID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get products for seller: %v", err)
+		return nil, fmt.Errorf("failed to get products for seller: %w", err)
 	}
 	defer rows.Close()
 
@@ -347,11 +541,11 @@ func (pdb *PostgresDatabase) GetDetailProductSeller(ctx context.Context, sellerI
 			&product.ID, &product.Name, &product.Description, &product.Brand,
 			&product.ModelNumber, &product.SKU, &product.Price, &product.Availability,
 			&product.Recommendation, &product.SellerID, &product.ProductType,
-			&product.CreatedAt, &product.UpdatedAt,
+			&product.CreatedAt, &product.UpdatedAt, &product.RatingAverage, &product.RatingCount,
 			&category.ID, &category.Name,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan product row: %v", err)
+			return nil, fmt.Errorf("failed to scan product row: %w", err)
 		}
 
 		// ใส่ข้อมูลหมวดหมู่
@@ -365,19 +559,19 @@ func (pdb *PostgresDatabase) GetDetailProductSeller(ctx context.Context, sellerI
 		`, product.ID).Scan(&product.Inventory.Quantity, &product.Inventory.UpdatedAt)
 
 		if err != nil && err != sql.ErrNoRows {
-			return nil, fmt.Errorf("failed to get inventory: %v", err)
+			return nil, fmt.Errorf("failed to get inventory: %w", err)
 		}
 
 		// ดึงข้อมูลรูปภาพ
 		product.Images, err = pdb.GetProductImages(ctx, product.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get product images: %v", err)
+			return nil, fmt.Errorf("failed to get product images: %w", err)
 		}
 
 		// ดึงข้อมูลตัวเลือก
 		product.Options, err = pdb.getProductOptions(ctx, product.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get product options: %v", err)
+			return nil, fmt.Errorf("failed to get product options: %w", err)
 		}
 
 		// เพิ่มสินค้าที่อ่านได้ลงในรายการ
@@ -385,9 +579,15 @@ func (pdb *PostgresDatabase) GetDetailProductSeller(ctx context.Context, sellerI
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %v", err)
+		return nil, fmt.Errorf("rows error: %w", err)
 	}
 
+	if err := pdb.localizeProducts(ctx, products); err != nil {
+		return nil, err
+	}
+	if err := pdb.applySales(ctx, products); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
@@ -397,15 +597,16 @@ func (pdb *PostgresDatabase) GetNewProductSeller(ctx context.Context) ([]Product
 	// Query ดึงสินค้าที่ใหม่ล่าสุดจากแต่ละ Seller
 	rows, err := pdb.db.QueryContext(ctx, `
 		SELECT DISTINCT ON (p.seller_id) p.product_id, p.name, p.description, p.brand, p.model_number, p.sku, p.price,
-		       p.availability, p.recommendation, p.seller_id, p.product_type, p.created_at, p.updated_at,
+		       p.availability, p.recommendation, p.seller_id, p.product_type, p.created_at, p.updated_at, p.rating_average, p.rating_count,
 		       c.category_id, c.name as category_name
 		FROM products p
+		JOIN sellers s ON p.seller_id = s.seller_id
 		LEFT JOIN categories c ON p.category_id = c.category_id
-		WHERE p.availability = 'active'
+		WHERE p.availability = 'active' AND s.verification_status = 'approved'
 		ORDER BY p.seller_id, p.created_at DESC
 	`)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get new products from each seller: %v", err)
+		return nil, fmt.Errorf("failed to get new products from each seller: %w", err)
 	}
 	defer rows.Close()
 
@@ -418,11 +619,11 @@ func (pdb *PostgresDatabase) GetNewProductSeller(ctx context.Context) ([]Product
 			&product.ID, &product.Name, &product.Description, &product.Brand,
 			&product.ModelNumber, &product.SKU, &product.Price, &product.Availability,
 			&product.Recommendation, &product.SellerID, &product.ProductType,
-			&product.CreatedAt, &product.UpdatedAt,
+			&product.CreatedAt, &product.UpdatedAt, &product.RatingAverage, &product.RatingCount,
 			&category.ID, &category.Name,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan product row: %v", err)
+			return nil, fmt.Errorf("failed to scan product row: %w", err)
 		}
 
 		// ใส่ข้อมูลหมวดหมู่
@@ -436,19 +637,19 @@ func (pdb *PostgresDatabase) GetNewProductSeller(ctx context.Context) ([]Product
 		`, product.ID).Scan(&product.Inventory.Quantity, &product.Inventory.UpdatedAt)
 
 		if err != nil && err != sql.ErrNoRows {
-			return nil, fmt.Errorf("failed to get inventory: %v", err)
+			return nil, fmt.Errorf("failed to get inventory: %w", err)
 		}
 
 		// ดึงข้อมูลรูปภาพ
 		product.Images, err = pdb.GetProductImages(ctx, product.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get product images: %v", err)
+			return nil, fmt.Errorf("failed to get product images: %w", err)
 		}
 
 		// ดึงข้อมูลตัวเลือก
 		product.Options, err = pdb.getProductOptions(ctx, product.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get product options: %v", err)
+			return nil, fmt.Errorf("failed to get product options: %w", err)
 		}
 
 		// เพิ่มสินค้าที่อ่านได้ลงในรายการ
@@ -456,9 +657,15 @@ func (pdb *PostgresDatabase) GetNewProductSeller(ctx context.Context) ([]Product
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %v", err)
+		return nil, fmt.Errorf("rows error: %w", err)
 	}
 
+	if err := pdb.localizeProducts(ctx, products); err != nil {
+		return nil, err
+	}
+	if err := pdb.applySales(ctx, products); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
@@ -467,33 +674,39 @@ func (pdb *PostgresDatabase) GetShopDetail(ctx context.Context, sellerID string)
 
 	// Query ดึงข้อมูลของร้านค้า (Seller)
 	err := pdb.db.QueryRowContext(ctx, `
-		SELECT seller_id, name, created_at, updated_at, logo, description, address, phone, email
+		SELECT seller_id, name, created_at, updated_at, logo, description, address, phone, email, verification_status, vat_registered
 		FROM sellers
 		WHERE seller_id = $1
 	`, sellerID).Scan(
 		&seller.SellerID, &seller.Name, &seller.CreatedAt, &seller.UpdatedAt,
 		&seller.Logo, &seller.Description, &seller.Address, &seller.Phone, &seller.Email,
+		&seller.VerificationStatus, &seller.VATRegistered,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return Seller{}, fmt.Errorf("shop not found")
+			return Seller{}, notFound("shop not found")
 		}
-		return Seller{}, fmt.Errorf("failed to get shop details: %v", err)
+		return Seller{}, fmt.Errorf("failed to get shop details: %w", err)
 	}
 
-	return seller, nil
+	sellers := []Seller{seller}
+	if err := pdb.localizeSellers(ctx, sellers); err != nil {
+		return Seller{}, err
+	}
+	return sellers[0], nil
 }
 
 func (pdb *PostgresDatabase) GetAllShops(ctx context.Context) ([]Seller, error) {
 	var sellers []Seller
 
-	// Query ดึงข้อมูลของร้านค้าทั้งหมด
+	// Query ดึงข้อมูลของร้านค้าทั้งหมดที่ผ่านการยืนยันแล้ว
 	rows, err := pdb.db.QueryContext(ctx, `
-		SELECT seller_id, name, created_at, updated_at, logo, description, address, phone, email
+		SELECT seller_id, name, created_at, updated_at, logo, description, address, phone, email, verification_status, vat_registered
 		FROM sellers
+		WHERE verification_status = 'approved'
 	`)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all shop details: %v", err)
+		return nil, fmt.Errorf("failed to get all shop details: %w", err)
 	}
 	defer rows.Close()
 
@@ -503,104 +716,55 @@ func (pdb *PostgresDatabase) GetAllShops(ctx context.Context) ([]Seller, error)
 		err := rows.Scan(
 			&seller.SellerID, &seller.Name, &seller.CreatedAt, &seller.UpdatedAt,
 			&seller.Logo, &seller.Description, &seller.Address, &seller.Phone, &seller.Email,
+			&seller.VerificationStatus, &seller.VATRegistered,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan shop row: %v", err)
+			return nil, fmt.Errorf("failed to scan shop row: %w", err)
 		}
 		sellers = append(sellers, seller)
 	}
 
 	// ตรวจสอบ error ที่เกิดขึ้นระหว่างการ loop
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %v", err)
-	}
-
-	return sellers, nil
-}
-
-func (pdb *PostgresDatabase) GetRecommendedProduct(ctx context.Context) ([]ProductItem, error) {
-	var products []ProductItem
-
-	rows, err := pdb.db.QueryContext(ctx, `
-		SELECT p.product_id, p.name, p.description, p.brand, p.model_number, p.sku, p.price,
-		       p.availability, p.recommendation, p.seller_id, p.created_at, p.updated_at,
-		       c.category_id, c.name as category_name
-		FROM products p
-		LEFT JOIN categories c ON p.category_id = c.category_id
-		WHERE p.recommendation = 'recommended'
-		LIMIT 3
-	`)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get recommended products: %v", err)
+		return nil, fmt.Errorf("rows error: %w", err)
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var product ProductItem
-		var category Category
 
-		err := rows.Scan(
-			&product.ID, &product.Name, &product.Description, &product.Brand,
-			&product.ModelNumber, &product.SKU, &product.Price, &product.Availability,
-			&product.Recommendation, &product.SellerID,
-			&product.CreatedAt, &product.UpdatedAt,
-			&category.ID, &category.Name,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan product row: %v", err)
-		}
-
-		// เพิ่มดีบักเพื่อตรวจสอบค่า recommendation
-		fmt.Printf("Product ID: %s, Name: %s, Recommendation: %s\n", product.ID, product.Name, product.Recommendation)
-
-		// ใส่ข้อมูลหมวดหมู่ของสินค้า
-		product.Categories = []Category{category}
-		// ดึงข้อมูลรูปภาพ
-		product.Images, err = pdb.GetProductImages(ctx, product.ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get product images: %v", err)
-		}
-		// ดึงข้อมูลตัวเลือก
-		product.Options, err = pdb.getProductOptions(ctx, product.ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get product options: %v", err)
-		}
-		products = append(products, product)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows iteration error: %v", err)
+	if err := pdb.localizeSellers(ctx, sellers); err != nil {
+		return nil, err
 	}
-
-	return products, nil
+	return sellers, nil
 }
 
 func (pdb *PostgresDatabase) GetProduct(ctx context.Context, id string) (ProductItem, error) {
 	var product ProductItem
 	var category Category
+	var dimensions ProductDimensions
 
 	// ดึงข้อมูลหลักของสินค้าและหมวดหมู่
 	err := pdb.db.QueryRowContext(ctx, `
 		SELECT p.product_id, p.name, p.description, p.brand, p.model_number, p.sku, p.price, 
-		       p.availability, p.recommendation, p.seller_id, p.product_type, p.created_at, p.updated_at,
-		       c.category_id, c.name as category_name
+		       p.availability, p.recommendation, p.seller_id, p.product_type, p.created_at, p.updated_at, p.rating_average, p.rating_count,
+		       c.category_id, c.name as category_name,
+		       COALESCE(p.weight_grams, 0), COALESCE(p.length_cm, 0), COALESCE(p.width_cm, 0), COALESCE(p.height_cm, 0)
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.category_id
 		WHERE p.product_id = $1
 	`, id).Scan(
 		&product.ID, &product.Name, &product.Description, &product.Brand,
 		&product.ModelNumber, &product.SKU, &product.Price, &product.Availability,
-		&product.Recommendation, &product.SellerID, &product.ProductType, &product.CreatedAt, &product.UpdatedAt,
-		&category.ID, &category.Name)
+		&product.Recommendation, &product.SellerID, &product.ProductType, &product.CreatedAt, &product.UpdatedAt, &product.RatingAverage, &product.RatingCount,
+		&category.ID, &category.Name,
+		&dimensions.WeightGrams, &dimensions.LengthCm, &dimensions.WidthCm, &dimensions.HeightCm)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return ProductItem{}, fmt.Errorf("product not found")
+			return ProductItem{}, notFound("product not found")
 		}
-		return ProductItem{}, fmt.Errorf("failed to get product: %v", err)
+		return ProductItem{}, fmt.Errorf("failed to get product: %w", err)
 	}
 
 	product.Categories = []Category{category}
+	product.Dimensions = &dimensions
 
 	// ดึงข้อมูล inventory
 	err = pdb.db.QueryRowContext(ctx, `
@@ -610,29 +774,72 @@ func (pdb *PostgresDatabase) GetProduct(ctx context.Context, id string) (Product
 	`, id).Scan(&product.Inventory.Quantity, &product.Inventory.UpdatedAt)
 
 	if err != nil && err != sql.ErrNoRows {
-		return ProductItem{}, fmt.Errorf("failed to get inventory: %v", err)
+		return ProductItem{}, fmt.Errorf("failed to get inventory: %w", err)
 	}
 
 	// ดึงข้อมูลรูปภาพ
 	product.Images, err = pdb.GetProductImages(ctx, id)
 	if err != nil {
-		return ProductItem{}, fmt.Errorf("failed to get product images: %v", err)
+		return ProductItem{}, fmt.Errorf("failed to get product images: %w", err)
 	}
 
 	// ดึงข้อมูลตัวเลือก
 	product.Options, err = pdb.getProductOptions(ctx, id)
 	if err != nil {
-		return ProductItem{}, fmt.Errorf("failed to get product options: %v", err)
+		return ProductItem{}, fmt.Errorf("failed to get product options: %w", err)
+	}
+
+	// ดึงเฉพาะตัวเลือกที่เปิดขายอยู่สำหรับหน้ารายละเอียดสินค้า
+	variants, err := pdb.GetProductVariants(ctx, id)
+	if err != nil {
+		return ProductItem{}, err
+	}
+	product.VariantOptions = variants.Options
+	for _, variant := range variants.Variants {
+		if variant.IsActive {
+			product.Variants = append(product.Variants, variant)
+		}
+	}
+
+	product.Translations, err = pdb.getProductTranslations(ctx, id)
+	if err != nil {
+		return ProductItem{}, err
 	}
 
-	return product, nil
+	products := []ProductItem{product}
+	if err := pdb.localizeProducts(ctx, products); err != nil {
+		return ProductItem{}, err
+	}
+	if err := pdb.applySales(ctx, products); err != nil {
+		return ProductItem{}, err
+	}
+	return products[0], nil
 }
 
 func (pdb *PostgresDatabase) AddProduct(ctx context.Context, product NewProduct) (Product, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Product{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	createdProduct, err := insertProduct(ctx, tx, product)
+	if err != nil {
+		return Product{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Product{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return createdProduct, nil
+}
+
+// insertProduct เพิ่มสินค้าพร้อม inventory ตารางตามประเภทสินค้า และตัวเลือก ภายใน transaction เดียว
+func insertProduct(ctx context.Context, tx *sql.Tx, product NewProduct) (Product, error) {
 	var createdProduct Product
 
 	// เพิ่มสินค้าในตาราง products
-	err := pdb.db.QueryRowContext(ctx, `
+	err := tx.QueryRowContext(ctx, `
 		INSERT INTO products (name, description, brand, model_number, sku, price, availability, recommendation, seller_id, product_type, category_id) 
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) 
 		RETURNING product_id, name, description, brand, model_number, sku, price, availability, recommendation, seller_id, product_type, category_id, created_at, updated_at
@@ -645,190 +852,197 @@ func (pdb *PostgresDatabase) AddProduct(ctx context.Context, product NewProduct)
 		&createdProduct.CreatedAt, &createdProduct.UpdatedAt)
 
 	if err != nil {
-		return Product{}, fmt.Errorf("failed to add product: %v", err)
+		return Product{}, fmt.Errorf("failed to add product: %w", err)
 	}
 
+	err = publish(ctx, tx, ProductCreated{
+		ProductID: createdProduct.ID,
+		SellerID:  createdProduct.SellerID,
+		SKU:       createdProduct.SKU,
+		Name:      createdProduct.Name,
+		Price:     createdProduct.Price,
+	})
+	if err != nil {
+		return Product{}, err
+	}
+
+	if err := saveProductDimensions(ctx, tx, createdProduct.ID, product.Dimensions); err != nil {
+		return Product{}, err
+	}
+	createdProduct.Dimensions = &product.Dimensions
+
 	// เพิ่มสินค้านั้นในตาราง inventory
-	_, err = pdb.db.ExecContext(ctx, `
+	_, err = tx.ExecContext(ctx, `
 		INSERT INTO inventory (product_id, quantity) 
 		VALUES ($1, $2)
 	`, createdProduct.ID, product.Quantity) // ใช้ quantity ที่ได้รับจาก NewProduct
 
 	if err != nil {
-		return Product{}, fmt.Errorf("failed to add product to inventory: %v", err)
+		return Product{}, fmt.Errorf("failed to add product to inventory: %w", err)
 	}
 
 	// เช็คประเภทของสินค้าที่เพิ่ม และเพิ่มเข้าไปในตารางที่เกี่ยวข้อง
 	switch product.ProductType {
 	case "food":
-		_, err = pdb.db.ExecContext(ctx, `
+		_, err = tx.ExecContext(ctx, `
 			INSERT INTO foods (product_id, name, description, brand, price) 
 			VALUES ($1, $2, $3, $4, $5)
 		`, createdProduct.ID, createdProduct.Name, createdProduct.Description, createdProduct.Brand, createdProduct.Price)
 	case "medicine":
-		_, err = pdb.db.ExecContext(ctx, `
+		_, err = tx.ExecContext(ctx, `
 			INSERT INTO medicines (product_id, name, description, brand, price)
 			VALUES ($1, $2, $3, $4, $5)
 		`, createdProduct.ID, createdProduct.Name, createdProduct.Description, createdProduct.Brand, createdProduct.Price)
 	case "toy":
-		_, err = pdb.db.ExecContext(ctx, `
+		_, err = tx.ExecContext(ctx, `
 			INSERT INTO toys (product_id, name, description, brand, price)
 			VALUES ($1, $2, $3, $4, $5)
 		`, createdProduct.ID, createdProduct.Name, createdProduct.Description, createdProduct.Brand, createdProduct.Price)
 	case "shelter":
-		_, err = pdb.db.ExecContext(ctx, `
+		_, err = tx.ExecContext(ctx, `
 			INSERT INTO shelters (product_id, name, description, brand, price)
 			VALUES ($1, $2, $3, $4, $5)
 		`, createdProduct.ID, createdProduct.Name, createdProduct.Description, createdProduct.Brand, createdProduct.Price)
 	}
 
 	if err != nil {
-		return Product{}, fmt.Errorf("failed to add product to related table: %v", err)
+		return Product{}, fmt.Errorf("failed to add product to related table: %w", err)
 	}
 
 	// เพิ่มข้อมูลในตาราง product_options ถ้ามี options ให้เพิ่ม
-	_, err = pdb.db.ExecContext(ctx, `
-		INSERT INTO product_options (product_id, optname, values)
-		VALUES ($1, $2, $3)
-	`, createdProduct.ID, product.OptName, product.Values)
-	if err != nil {
-		return Product{}, fmt.Errorf("failed to add product option: %v", err)
+	if product.OptName != "" {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO product_options (product_id, optname, values)
+			VALUES ($1, $2, $3)
+		`, createdProduct.ID, product.OptName, product.Values)
+		if err != nil {
+			return Product{}, fmt.Errorf("failed to add product option: %w", err)
+		}
 	}
 
 	return createdProduct, nil
 }
 
 func (pdb *PostgresDatabase) UpdateProduct(ctx context.Context, id string, update UpdateProduct) (Product, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Product{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if update.Dimensions != nil {
+		if err := saveProductDimensions(ctx, tx, id, *update.Dimensions); err != nil {
+			return Product{}, err
+		}
+	}
+
+	// ราคาเดิมใช้ตัดสินว่าต้องเขียน ProductPriceChanged หรือไม่
 	var updatedProduct Product
-	err := pdb.db.QueryRowContext(ctx, `
+	var dimensions ProductDimensions
+	var oldPrice Money
+	err = tx.QueryRowContext(ctx, `
+		WITH old AS (SELECT price FROM products WHERE product_id = $4 FOR UPDATE)
 		UPDATE products 
 		SET price = $1, availability = $2, recommendation = $3, updated_at = NOW() 
 		WHERE product_id = $4
-		RETURNING product_id, name, description, brand, model_number, sku, price, availability, recommendation, seller_id, product_type, category_id, created_at, updated_at
+		RETURNING product_id, name, description, brand, model_number, sku, price, availability, recommendation, seller_id, product_type, category_id, created_at, updated_at,
+		          COALESCE(weight_grams, 0), COALESCE(length_cm, 0), COALESCE(width_cm, 0), COALESCE(height_cm, 0),
+		          (SELECT price FROM old)
 	`,
 		update.Price, update.Availability, update.Recommendation, id,
 	).Scan(
 		&updatedProduct.ID, &updatedProduct.Name, &updatedProduct.Description, &updatedProduct.Brand,
 		&updatedProduct.ModelNumber, &updatedProduct.SKU, &updatedProduct.Price, &updatedProduct.Availability,
 		&updatedProduct.Recommendation, &updatedProduct.SellerID, &updatedProduct.ProductType, &updatedProduct.CategoryID,
-		&updatedProduct.CreatedAt, &updatedProduct.UpdatedAt)
+		&updatedProduct.CreatedAt, &updatedProduct.UpdatedAt,
+		&dimensions.WeightGrams, &dimensions.LengthCm, &dimensions.WidthCm, &dimensions.HeightCm,
+		&oldPrice)
 	if err != nil {
-		return Product{}, fmt.Errorf("failed to update product: %v", err)
+		return Product{}, fmt.Errorf("failed to update product: %w", err)
+	}
+	updatedProduct.Dimensions = &dimensions
+
+	if err := publishProductUpdate(ctx, tx, updatedProduct.ID, updatedProduct.SellerID, oldPrice, updatedProduct.Price); err != nil {
+		return Product{}, err
+	}
+
+	if err := saveProductTranslations(ctx, tx, id, update.Translations); err != nil {
+		return Product{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Product{}, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	return updatedProduct, nil
 }
 
 func (pdb *PostgresDatabase) DeleteProduct(ctx context.Context, id string) error {
-	result, err := pdb.db.ExecContext(ctx, "DELETE FROM products WHERE product_id = $1", id)
+	tx, err := pdb.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to delete product: %v", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	var sellerID string
+	err = tx.QueryRowContext(ctx, "DELETE FROM products WHERE product_id = $1 RETURNING seller_id", id).Scan(&sellerID)
+	if err == sql.ErrNoRows {
+		return notFound("product not found")
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %v", err)
+		return fmt.Errorf("failed to delete product: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("product not found")
+	if err := publish(ctx, tx, ProductDeleted{ProductID: id, SellerID: sellerID}); err != nil {
+		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
 func (pdb *PostgresDatabase) GetProducts(ctx context.Context, params ProductQueryParams) (*ProductResponse, error) {
+	sort, sortColumn, orderDirection := productSort(params)
 	query := `
         SELECT p.product_id, p.name, p.description, p.brand, p.model_number, p.sku, p.price, 
-               p.availability, p.recommendation, p.seller_id, p.product_type, p.created_at, p.updated_at,
+               p.availability, p.recommendation, p.seller_id, p.product_type, p.created_at, p.updated_at, p.rating_average, p.rating_count,
                c.category_id, c.name as category_name,
-               i.quantity, i.updated_at as inventory_updated_at
+               i.quantity, i.updated_at as inventory_updated_at,
+               (` + sortColumn + `)::text AS sort_key
         FROM products p
+        JOIN sellers s ON p.seller_id = s.seller_id
         LEFT JOIN categories c ON p.category_id = c.category_id
-        LEFT JOIN inventory i ON p.product_id = i.product_id
-        WHERE 1=1`
+        LEFT JOIN inventory i ON p.product_id = i.product_id` + activeSaleJoin + `
+        WHERE s.verification_status = 'approved'`
 
 	args := []interface{}{}
 	placeholderCount := 1
 
 	// Handle cursor parameter
+	// cursor เก็บค่าของคอลัมน์ที่ใช้เรียงของสินค้าตัวสุดท้าย หน้าถัดไปจึงเริ่มต่อจากตำแหน่งนั้นตามการเรียงเดียวกัน
 	if params.Cursor != "" {
 		cursor, err := decodeCursor(params.Cursor)
 		if err != nil {
-			return nil, fmt.Errorf("invalid cursor: %v", err)
+			return nil, err
+		}
+		if cursor.Sort != sort || cursor.Order != orderDirection {
+			return nil, invalid("cursor was issued for a different sort order")
 		}
-		query += fmt.Sprintf(" AND (p.created_at, p.product_id) > ($%d, $%d)", placeholderCount, placeholderCount+1)
-		args = append(args, cursor.CreatedAt, cursor.ProductID)
+		comparison := ">"
+		if orderDirection == "DESC" {
+			comparison = "<"
+		}
+		query += fmt.Sprintf(" AND ((%[1]s) %[2]s $%[3]d OR ((%[1]s) = $%[3]d AND p.product_id > $%[4]d))",
+			sortColumn, comparison, placeholderCount, placeholderCount+1)
+		args = append(args, cursor.SortKey, cursor.ProductID)
 		placeholderCount += 2
 	}
 
-	// Handle search parameter
-	if params.Search != "" {
-		// Remove whitespace from the search term
-		searchTerm := strings.ReplaceAll(params.Search, " ", "")
+	query, args = appendProductFilters(query, args, params)
+	placeholderCount = len(args) + 1
 
-		// Modify the query to ignore whitespace in the 'name' field
-		query += fmt.Sprintf(" AND REPLACE(p.name, ' ', '') ILIKE $%d", placeholderCount)
-		args = append(args, "%"+searchTerm+"%")
-		placeholderCount++
-	}
-
-	// Handle category_id parameter
-	if params.CategoryID != 0 {
-		query += fmt.Sprintf(" AND p.category_id = $%d", placeholderCount)
-		args = append(args, params.CategoryID)
-		placeholderCount++
-	}
-
-	// Handle seller_id parameter
-	if params.SellerID != "" {
-		query += fmt.Sprintf(" AND p.seller_id = $%d", placeholderCount)
-		args = append(args, params.SellerID)
-		placeholderCount++
-	}
-
-	// Handle status parameter (availability)
-	if params.Availability != "" {
-		query += fmt.Sprintf(" AND p.availability = $%d", placeholderCount)
-		args = append(args, params.Availability)
-		placeholderCount++
-	}
-
-	if params.Recommendation != "" {
-		query += fmt.Sprintf(" AND p.recommendation = $%d", placeholderCount)
-		args = append(args, params.Recommendation)
-		placeholderCount++
-	}
-
-	// Handle product_type parameter
-	if params.ProductType != "" {
-		query += fmt.Sprintf(" AND p.product_type = $%d", placeholderCount)
-		args = append(args, params.ProductType)
-		placeholderCount++
-	}
-
-	// Handle ORDER BY with sort and order
-	sortFields := map[string]string{
-		"name":       "p.name",
-		"price":      "p.price",
-		"created_at": "p.created_at",
-	}
-
-	orderDirections := map[string]string{
-		"asc":  "ASC",
-		"desc": "DESC",
-	}
-
-	sortColumn, ok := sortFields[params.Sort]
-	if !ok {
-		sortColumn = "p.price"
-	}
-
-	orderDirection, ok := orderDirections[strings.ToLower(params.Order)]
-	if !ok {
-		orderDirection = "ASC"
-	}
-
-	query += fmt.Sprintf(" ORDER BY %s %s, p.product_id ASC", sortColumn, orderDirection)
+	query += productOrderBy(params)
 
 	// Set limit
 	limit := 20
@@ -842,23 +1056,25 @@ func (pdb *PostgresDatabase) GetProducts(ctx context.Context, params ProductQuer
 	// Execute query and process results
 	rows, err := pdb.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get products: %v", err)
+		return nil, fmt.Errorf("failed to get products: %w", err)
 	}
 	defer rows.Close()
 
 	var products []ProductItem
+	var sortKeys []string
 	for rows.Next() {
 		var product ProductItem
 		var category Category
 		var inventory Inventory
+		var sortKey string
 		if err := rows.Scan(
 			&product.ID, &product.Name, &product.Description, &product.Brand,
 			&product.ModelNumber, &product.SKU, &product.Price, &product.Availability,
 			&product.Recommendation, &product.SellerID, &product.ProductType,
-			&product.CreatedAt, &product.UpdatedAt,
+			&product.CreatedAt, &product.UpdatedAt, &product.RatingAverage, &product.RatingCount,
 			&category.ID, &category.Name,
-			&inventory.Quantity, &inventory.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan product: %v", err)
+			&inventory.Quantity, &inventory.UpdatedAt, &sortKey); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
 		product.Categories = []Category{category}
 		product.Inventory = inventory
@@ -866,21 +1082,29 @@ func (pdb *PostgresDatabase) GetProducts(ctx context.Context, params ProductQuer
 		// Fetch images and options for the product
 		product.Images, err = pdb.GetProductImages(ctx, product.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get product images: %v", err)
+			return nil, fmt.Errorf("failed to get product images: %w", err)
 		}
 		product.Options, err = pdb.getProductOptions(ctx, product.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get product options: %v", err)
+			return nil, fmt.Errorf("failed to get product options: %w", err)
 		}
 
 		products = append(products, product)
+		sortKeys = append(sortKeys, sortKey)
 		if len(products) == limit+1 {
 			break
 		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate over products: %v", err)
+		return nil, fmt.Errorf("failed to iterate over products: %w", err)
+	}
+
+	if err := pdb.localizeProducts(ctx, products); err != nil {
+		return nil, err
+	}
+	if err := pdb.applySales(ctx, products); err != nil {
+		return nil, err
 	}
 
 	response := &ProductResponse{
@@ -890,7 +1114,7 @@ func (pdb *PostgresDatabase) GetProducts(ctx context.Context, params ProductQuer
 
 	if len(products) > limit {
 		lastProduct := products[limit-1]
-		response.NextCursor = encodeCursor(Cursor{CreatedAt: lastProduct.CreatedAt, ProductID: lastProduct.ID})
+		response.NextCursor = encodeCursor(Cursor{Sort: sort, Order: orderDirection, SortKey: sortKeys[limit-1], ProductID: lastProduct.ID})
 	} else {
 		response.NextCursor = ""
 	}
@@ -898,6 +1122,110 @@ func (pdb *PostgresDatabase) GetProducts(ctx context.Context, params ProductQuer
 	return response, nil
 }
 
+// appendProductFilters เพิ่มเงื่อนไขจาก ProductQueryParams ต่อท้าย query ที่มี WHERE อยู่แล้ว
+// ใช้ร่วมกันระหว่างการแสดงรายการสินค้าและการส่งออกแคตตาล็อก
+func appendProductFilters(query string, args []interface{}, params ProductQueryParams) (string, []interface{}) {
+	placeholderCount := len(args) + 1
+
+	// Handle search parameter
+	if params.Search != "" {
+		// Remove whitespace from the search term
+		searchTerm := strings.ReplaceAll(params.Search, " ", "")
+
+		// Modify the query to ignore whitespace in the 'name' field
+		// ค้นหาจากชื่อหลักและชื่อที่แปลไว้ทุกภาษา
+		query += fmt.Sprintf(` AND (REPLACE(p.name, ' ', '') ILIKE $%[1]d OR EXISTS (
+			SELECT 1 FROM product_translations pt
+			WHERE pt.product_id = p.product_id AND REPLACE(pt.name, ' ', '') ILIKE $%[1]d))`, placeholderCount)
+		args = append(args, "%"+searchTerm+"%")
+		placeholderCount++
+	}
+
+	// Handle category_id parameter
+	if params.CategoryID != 0 {
+		query += fmt.Sprintf(" AND p.category_id = $%d", placeholderCount)
+		args = append(args, params.CategoryID)
+		placeholderCount++
+	}
+
+	// Handle seller_id parameter
+	if params.SellerID != "" {
+		query += fmt.Sprintf(" AND p.seller_id = $%d", placeholderCount)
+		args = append(args, params.SellerID)
+		placeholderCount++
+	}
+
+	// Handle status parameter (availability)
+	if params.Availability != "" {
+		query += fmt.Sprintf(" AND p.availability = $%d", placeholderCount)
+		args = append(args, params.Availability)
+		placeholderCount++
+	}
+
+	if params.Recommendation != "" {
+		query += fmt.Sprintf(" AND p.recommendation = $%d", placeholderCount)
+		args = append(args, params.Recommendation)
+		placeholderCount++
+	}
+
+	// Handle product_type parameter
+	if params.ProductType != "" {
+		query += fmt.Sprintf(" AND p.product_type = $%d", placeholderCount)
+		args = append(args, params.ProductType)
+		placeholderCount++
+	}
+
+	// Handle min_rating parameter
+	if params.MinRating > 0 {
+		query += fmt.Sprintf(" AND p.rating_average >= $%d", placeholderCount)
+		args = append(args, params.MinRating)
+		placeholderCount++
+	}
+
+	if params.OnSale {
+		query += " AND EXISTS (SELECT 1 FROM active_product_sales aps WHERE aps.product_id = p.product_id)"
+	}
+
+	return query, args
+}
+
+// activeSaleJoin ต่อราคาลดที่ใช้อยู่ของสินค้าเป็น aps ทุก query ที่ใช้ productOrderBy ต้อง join ไว้
+// สินค้าหนึ่งชิ้นมีราคาลดได้ครั้งละหนึ่งช่วงเวลา จึงไม่ทำให้แถวซ้ำ
+const activeSaleJoin = `
+        LEFT JOIN active_product_sales aps ON aps.product_id = p.product_id`
+
+// productSortFields คือคอลัมน์ที่ใช้เรียงสินค้าตาม sort ต้องไม่เป็น NULL เพราะ cursor เทียบค่าด้วย = และ < >
+var productSortFields = map[string]string{
+	"name":       "p.name",
+	"price":      "p.price",
+	"created_at": "p.created_at",
+	"rating":     "p.rating_average",
+	// ราคาที่ขายจริง ณ ตอนนี้ คือราคาลดถ้าอยู่ในช่วงลดราคา ค่านี้อยู่ใน cursor ด้วย
+	// ถ้าราคาลดเริ่มหรือหมดระหว่างเปลี่ยนหน้า สินค้านั้นอาจย้ายไปอยู่หน้าอื่น
+	"effective_price": "COALESCE(aps.sale_price, p.price)",
+}
+
+// productSort คืนชื่อ sort ที่ใช้จริง คอลัมน์ และทิศทาง ถ้าไม่ระบุหรือไม่รู้จักจะเรียงตามราคาจากน้อยไปมาก
+func productSort(params ProductQueryParams) (sort, column, direction string) {
+	sort = params.Sort
+	column, ok := productSortFields[sort]
+	if !ok {
+		sort, column = "price", productSortFields["price"]
+	}
+
+	direction = "ASC"
+	if strings.ToLower(params.Order) == "desc" {
+		direction = "DESC"
+	}
+	return sort, column, direction
+}
+
+// productOrderBy คืน ORDER BY ตาม sort และ order โดยเรียงด้วย product_id เป็นลำดับสุดท้ายเสมอ
+func productOrderBy(params ProductQueryParams) string {
+	_, sortColumn, orderDirection := productSort(params)
+	return fmt.Sprintf(" ORDER BY %s %s, p.product_id ASC", sortColumn, orderDirection)
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -905,35 +1233,37 @@ func min(a, b int) int {
 	return b
 }
 
+// Cursor ชี้ไปที่สินค้าตัวสุดท้ายของหน้าก่อน SortKey คือค่าของคอลัมน์ที่ใช้เรียงในรูปข้อความของ PostgreSQL
+// Sort และ Order เก็บไว้เพื่อไม่ให้ใช้ cursor ต่อกับการเรียงแบบอื่น
 type Cursor struct {
-	CreatedAt time.Time
-	ProductID string
+	Sort      string `json:"sort"`
+	Order     string `json:"order"`
+	SortKey   string `json:"key"`
+	ProductID string `json:"id"`
 }
 
 func (pdb *PostgresDatabase) GetAllProductImages(ctx context.Context) ([]ProductImage, error) {
 	rows, err := pdb.db.QueryContext(ctx, `
-		SELECT image_id, product_id, image_url, is_primary, sort_order, created_at 
+		SELECT `+productImageColumns+`
 		FROM product_images
 		ORDER BY product_id ASC, sort_order ASC
 	`)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get all product images: %v", err)
+		return nil, fmt.Errorf("failed to get all product images: %w", err)
 	}
 	defer rows.Close()
 
 	var images []ProductImage
 	for rows.Next() {
-		var image ProductImage
-		if err := rows.Scan(
-			&image.ID, &image.ProductID, &image.ImageURL, &image.IsPrimary,
-			&image.SortOrder, &image.CreatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan product image: %v", err)
+		image, err := scanProductImage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product image: %w", err)
 		}
 		images = append(images, image)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate over product images: %v", err)
+		return nil, fmt.Errorf("failed to iterate over product images: %w", err)
 	}
 
 	return images, nil
@@ -941,87 +1271,177 @@ func (pdb *PostgresDatabase) GetAllProductImages(ctx context.Context) ([]Product
 
 func (pdb *PostgresDatabase) GetProductImages(ctx context.Context, productID string) ([]ProductImage, error) {
 	rows, err := pdb.db.QueryContext(ctx, `
-		SELECT image_id, product_id, image_url, is_primary, sort_order, created_at 
+		SELECT `+productImageColumns+`
 		FROM product_images 
 		WHERE product_id = $1 
 		ORDER BY sort_order ASC
 	`, productID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product images: %v", err)
+		return nil, fmt.Errorf("failed to get product images: %w", err)
 	}
 	defer rows.Close()
 
 	var images []ProductImage
 	for rows.Next() {
-		var image ProductImage
-		if err := rows.Scan(
-			&image.ID, &image.ProductID, &image.ImageURL, &image.IsPrimary,
-			&image.SortOrder, &image.CreatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan product image: %v", err)
+		image, err := scanProductImage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product image: %w", err)
 		}
 		images = append(images, image)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate over product images: %v", err)
+		return nil, fmt.Errorf("failed to iterate over product images: %w", err)
 	}
 
 	return images, nil
 }
 
 func (pdb *PostgresDatabase) AddProductImage(ctx context.Context, productID string, image NewProductImage) (ProductImage, error) {
-	var createdImage ProductImage
-	err := pdb.db.QueryRowContext(ctx, `
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ProductImage{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	order, err := lockProductImageOrder(ctx, tx, productID)
+	if err != nil {
+		return ProductImage{}, err
+	}
+
+	// เพิ่มไว้ท้ายสุดก่อน แล้วค่อยย้ายไปตำแหน่งที่ต้องการ เพื่อไม่ให้ sort_order ชนกัน
+	var imageID string
+	err = tx.QueryRowContext(ctx, `
         INSERT INTO product_images (product_id, image_url, is_primary, sort_order, alt_text) 
-        VALUES ($1, $2, $3, $4, $5) 
-        RETURNING image_id, product_id, image_url, is_primary, sort_order, alt_text, created_at
+        VALUES ($1, $2, FALSE, $3, $4) 
+        RETURNING image_id
     `,
-		productID, image.ImageURL, image.IsPrimary, image.SortOrder, image.AltText,
-	).Scan(
-		&createdImage.ID, &createdImage.ProductID, &createdImage.ImageURL,
-		&createdImage.IsPrimary, &createdImage.SortOrder, &createdImage.AltText, &createdImage.CreatedAt)
+		productID, image.ImageURL, len(order), image.AltText,
+	).Scan(&imageID)
+	if err != nil {
+		return ProductImage{}, fmt.Errorf("failed to add product image: %w", err)
+	}
+
+	if image.SortOrder != nil && *image.SortOrder < len(order) {
+		if err := writeProductImageOrder(ctx, tx, productID, moveImageID(append(order, imageID), imageID, *image.SortOrder)); err != nil {
+			return ProductImage{}, err
+		}
+	}
+
+	// รูปแรกของสินค้าเป็นรูปหลักเสมอ
+	if image.IsPrimary || len(order) == 0 {
+		if err := setPrimaryProductImage(ctx, tx, productID, imageID); err != nil {
+			return ProductImage{}, err
+		}
+	}
+
+	createdImage, err := getProductImage(ctx, tx, productID, imageID)
 	if err != nil {
-		return ProductImage{}, fmt.Errorf("failed to add product image: %v", err)
+		return ProductImage{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ProductImage{}, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	return createdImage, nil
 }
 
 func (pdb *PostgresDatabase) UpdateProductImage(ctx context.Context, productID string, imageID string, update UpdateProductImage) (ProductImage, error) {
-	var updatedImage ProductImage
-	err := pdb.db.QueryRowContext(ctx, `
-		UPDATE product_images 
-		SET is_primary = $1, sort_order = $2, updated_at = NOW() 
-		WHERE product_id = $3 AND image_id = $4 
-		RETURNING image_id, product_id, image_url, is_primary, sort_order, created_at
-	`,
-		update.IsPrimary, update.SortOrder, productID, imageID,
-	).Scan(
-		&updatedImage.ID, &updatedImage.ProductID, &updatedImage.ImageURL,
-		&updatedImage.IsPrimary, &updatedImage.SortOrder, &updatedImage.CreatedAt)
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ProductImage{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	order, err := lockProductImageOrder(ctx, tx, productID)
+	if err != nil {
+		return ProductImage{}, err
+	}
+	if !containsID(order, imageID) {
+		return ProductImage{}, notFound("product image not found")
+	}
+
+	if update.SortOrder != nil {
+		if err := writeProductImageOrder(ctx, tx, productID, moveImageID(order, imageID, *update.SortOrder)); err != nil {
+			return ProductImage{}, err
+		}
+	}
+
+	if update.IsPrimary != nil {
+		if *update.IsPrimary {
+			err = setPrimaryProductImage(ctx, tx, productID, imageID)
+		} else {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE product_images SET is_primary = FALSE, updated_at = NOW()
+				WHERE product_id = $1 AND image_id = $2 AND is_primary
+			`, productID, imageID)
+		}
+		if err != nil {
+			return ProductImage{}, fmt.Errorf("failed to update product image: %w", err)
+		}
+	}
+
+	if update.AltText != nil {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE product_images SET alt_text = $1, updated_at = NOW()
+			WHERE product_id = $2 AND image_id = $3
+		`, *update.AltText, productID, imageID)
+		if err != nil {
+			return ProductImage{}, fmt.Errorf("failed to update product image: %w", err)
+		}
+	}
+
+	updatedImage, err := getProductImage(ctx, tx, productID, imageID)
 	if err != nil {
-		return ProductImage{}, fmt.Errorf("failed to update product image: %v", err)
+		return ProductImage{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ProductImage{}, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	return updatedImage, nil
 }
 
 func (pdb *PostgresDatabase) DeleteProductImage(ctx context.Context, productID string, imageID string) error {
-	result, err := pdb.db.ExecContext(ctx, `
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	order, err := lockProductImageOrder(ctx, tx, productID)
+	if err != nil {
+		return err
+	}
+
+	var wasPrimary bool
+	err = tx.QueryRowContext(ctx, `
 		DELETE FROM product_images 
 		WHERE product_id = $1 AND image_id = $2
-	`, productID, imageID)
+		RETURNING is_primary
+	`, productID, imageID).Scan(&wasPrimary)
+	if err == sql.ErrNoRows {
+		return notFound("product image not found")
+	}
 	if err != nil {
-		return fmt.Errorf("failed to delete product image: %v", err)
+		return fmt.Errorf("failed to delete product image: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %v", err)
+	remaining := removeID(order, imageID)
+	if err := writeProductImageOrder(ctx, tx, productID, remaining); err != nil {
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("product image not found")
+	// ถ้าลบรูปหลักไป ให้รูปแรกที่เหลืออยู่เป็นรูปหลักแทน
+	if wasPrimary && len(remaining) > 0 {
+		if err := setPrimaryProductImage(ctx, tx, productID, remaining[0]); err != nil {
+			return err
+		}
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
@@ -1042,7 +1462,7 @@ func (pdb *PostgresDatabase) getProductOptions(ctx context.Context, productID st
         WHERE product_id = $1
     `, productID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get product options: %v", err)
+		return nil, fmt.Errorf("failed to get product options: %w", err)
 	}
 	defer rows.Close()
 
@@ -1050,36 +1470,33 @@ func (pdb *PostgresDatabase) getProductOptions(ctx context.Context, productID st
 	for rows.Next() {
 		var option ProductOption
 		if err := rows.Scan(&option.OptName, &option.Values); err != nil {
-			return nil, fmt.Errorf("failed to scan product option: %v", err)
+			return nil, fmt.Errorf("failed to scan product option: %w", err)
 		}
 		options = append(options, option)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("failed to iterate over product options: %v", err)
+		return nil, fmt.Errorf("failed to iterate over product options: %w", err)
 	}
 
 	return options, nil
 }
 
 func encodeCursor(c Cursor) string {
-	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s,%s", c.CreatedAt.Format(time.RFC3339Nano), c.ProductID)))
+	b, _ := json.Marshal(c)
+	return base64.StdEncoding.EncodeToString(b)
 }
 
 func decodeCursor(s string) (Cursor, error) {
 	b, err := base64.StdEncoding.DecodeString(s)
 	if err != nil {
-		return Cursor{}, err
+		return Cursor{}, invalid("invalid cursor format")
 	}
-	parts := strings.Split(string(b), ",")
-	if len(parts) != 2 {
-		return Cursor{}, fmt.Errorf("invalid cursor format")
-	}
-	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
-	if err != nil {
-		return Cursor{}, err
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ProductID == "" {
+		return Cursor{}, invalid("invalid cursor format")
 	}
-	return Cursor{CreatedAt: createdAt, ProductID: parts[1]}, nil
+	return c, nil
 }
 
 func (pdb *PostgresDatabase) Reconnect(connStr string) error {
@@ -1089,7 +1506,7 @@ func (pdb *PostgresDatabase) Reconnect(connStr string) error {
 
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %v", err)
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	// ตั้งค่า connection pool
@@ -1101,7 +1518,7 @@ func (pdb *PostgresDatabase) Reconnect(connStr string) error {
 	defer cancel()
 
 	if err := db.PingContext(ctx); err != nil {
-		return fmt.Errorf("failed to ping database: %v", err)
+		return fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	pdb.db = db
@@ -1128,8 +1545,8 @@ func (s *Store) GetDetailProductSeller(ctx context.Context, sellerID string) ([]
 	return s.db.GetDetailProductSeller(ctx, sellerID)
 }
 
-func (s *Store) GetRecommendedProduct(ctx context.Context) ([]ProductItem, error) {
-	return s.db.GetRecommendedProduct(ctx)
+func (s *Store) GetRecommendedProduct(ctx context.Context, req RecommendationRequest) ([]RecommendedProduct, error) {
+	return s.db.GetRecommendedProduct(ctx, req)
 }
 
 func (s *Store) GetShopDetail(ctx context.Context, sellerID string) (Seller, error) {
@@ -0,0 +1,33 @@
+package ecommerce
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	want := Cursor{Sort: "price", Order: "DESC", SortKey: "199.50", ProductID: "7f1c2d3e-0000-4000-8000-000000000001"}
+	got, err := decodeCursor(encodeCursor(want))
+	if err != nil || got != want {
+		t.Fatalf("decodeCursor(encodeCursor(%+v)) = %+v, %v", want, got, err)
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "%%%"},
+		{"not json", base64.StdEncoding.EncodeToString([]byte("price"))},
+		{"missing product", base64.StdEncoding.EncodeToString([]byte(`{"sort":"price"}`))},
+		// cursor ที่ถูกเข้ารหัสซ้ำอีกชั้นแบบที่ handler เคยทำ
+		{"encoded twice", base64.StdEncoding.EncodeToString([]byte(encodeCursor(want)))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.cursor); !errors.Is(err, ErrValidation) {
+				t.Errorf("decodeCursor(%q) error = %v, want ErrValidation", tt.cursor, err)
+			}
+		})
+	}
+}
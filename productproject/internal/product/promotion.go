@@ -0,0 +1,588 @@
+// promotion.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ค่าที่อนุญาตตาม ENUM ในฐานข้อมูล
+var (
+	promotionTypes  = []string{"percentage", "fixed_amount", "free_shipping", "buy_x_get_y"}
+	promotionScopes = []string{"all", "product", "category", "seller", "product_type"}
+)
+
+type Promotion struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Code        string `json:"code,omitempty"` // ว่างคือโปรโมชันอัตโนมัติที่ไม่ต้องใช้โค้ด
+	Type        string `json:"type"`           // 'percentage', 'fixed_amount', 'free_shipping', 'buy_x_get_y'
+
+	DiscountPercent string `json:"discount_percent,omitempty"` // percentage เช่น "12.5"
+	DiscountAmount  *Money `json:"discount_amount,omitempty"`  // fixed_amount
+	MaxDiscount     *Money `json:"max_discount,omitempty"`     // เพดานส่วนลดของ percentage
+	BuyQuantity     int    `json:"buy_quantity,omitempty"`     // buy_x_get_y: ซื้อ X ชิ้น
+	GetQuantity     int    `json:"get_quantity,omitempty"`     // buy_x_get_y: ฟรี Y ชิ้นที่ราคาถูกที่สุด
+	MinSpend        Money  `json:"min_spend"`                  // ยอดขั้นต่ำของสินค้าที่เข้าเงื่อนไข
+
+	Scope       string   `json:"scope"`        // 'all', 'product', 'category', 'seller', 'product_type'
+	ScopeValues []string `json:"scope_values"` // รหัสสินค้า หมวดหมู่ ร้านค้า หรือประเภทสินค้าตาม scope
+
+	UsageLimit   int        `json:"usage_limit,omitempty"`    // จำนวนครั้งที่ใช้ได้ทั้งหมด 0 คือไม่จำกัด
+	PerUserLimit int        `json:"per_user_limit,omitempty"` // จำนวนครั้งที่ผู้ใช้แต่ละคนใช้ได้ 0 คือไม่จำกัด
+	UsageCount   int        `json:"usage_count"`
+	StartsAt     time.Time  `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	IsActive     bool       `json:"is_active"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// NewPromotion ใช้ทั้งสร้างและแก้ไขโปรโมชัน การแก้ไขจะแทนที่ค่าเดิมทั้งหมด
+type NewPromotion struct {
+	Name            string     `json:"name" binding:"notblank,max=255"`
+	Description     string     `json:"description"`
+	Code            string     `json:"code" binding:"omitempty,promotion_code"`
+	Type            string     `json:"type" binding:"promotion_type"`
+	DiscountPercent string     `json:"discount_percent" binding:"omitempty,percent"`
+	DiscountAmount  *Money     `json:"discount_amount" binding:"omitempty,gt=0,lte=99999999.99"`
+	MaxDiscount     *Money     `json:"max_discount" binding:"omitempty,gt=0,lte=99999999.99"`
+	BuyQuantity     int        `json:"buy_quantity" binding:"gte=0"`
+	GetQuantity     int        `json:"get_quantity" binding:"gte=0"`
+	MinSpend        Money      `json:"min_spend" binding:"gte=0,lte=99999999.99"`
+	Scope           string     `json:"scope" binding:"omitempty,promotion_scope"` // ค่าเริ่มต้นคือ 'all'
+	ScopeValues     []string   `json:"scope_values" binding:"dive,notblank"`
+	UsageLimit      int        `json:"usage_limit" binding:"gte=0"`
+	PerUserLimit    int        `json:"per_user_limit" binding:"gte=0"`
+	StartsAt        *time.Time `json:"starts_at"` // ค่าเริ่มต้นคือเวลาปัจจุบัน
+	EndsAt          *time.Time `json:"ends_at"`
+	IsActive        *bool      `json:"is_active"` // ค่าเริ่มต้นคือ true
+}
+
+var (
+	promotionCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,50}$`)
+	percentPattern       = regexp.MustCompile(`^[0-9]{1,3}(\.[0-9]{1,2})?$`)
+)
+
+func validPercent(percent string) bool {
+	if !percentPattern.MatchString(percent) {
+		return false
+	}
+	value, err := strconv.ParseFloat(percent, 64)
+	return err == nil && value > 0 && value <= 100
+}
+
+// Validate ตรวจ binding tag และ field ที่จำเป็นตามชนิดของโปรโมชัน
+func (p NewPromotion) Validate() error {
+	if err := ValidateStruct(p); err != nil {
+		return err
+	}
+
+	var fields []FieldError
+	switch p.Type {
+	case "percentage":
+		if p.DiscountPercent == "" {
+			fields = append(fields, FieldError{Field: "discount_percent", Message: "is required for percentage promotions"})
+		}
+	case "fixed_amount":
+		if p.DiscountAmount == nil {
+			fields = append(fields, FieldError{Field: "discount_amount", Message: "is required for fixed_amount promotions"})
+		}
+	case "buy_x_get_y":
+		if p.BuyQuantity == 0 {
+			fields = append(fields, FieldError{Field: "buy_quantity", Message: "is required for buy_x_get_y promotions"})
+		}
+		if p.GetQuantity == 0 {
+			fields = append(fields, FieldError{Field: "get_quantity", Message: "is required for buy_x_get_y promotions"})
+		}
+	}
+	if p.Scope != "" && p.Scope != "all" && len(p.ScopeValues) == 0 {
+		fields = append(fields, FieldError{Field: "scope_values", Message: "is required when scope is " + p.Scope})
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		fields = append(fields, FieldError{Field: "ends_at", Message: "must be after starts_at"})
+	}
+	if len(fields) > 0 {
+		return invalidFields(fields...)
+	}
+	return nil
+}
+
+// PromotionResult อธิบายว่าโปรโมชันหนึ่งรายการใช้กับตะกร้าได้หรือไม่ และเพราะอะไร
+type PromotionResult struct {
+	PromotionID  string `json:"promotion_id"`
+	Name         string `json:"name"`
+	Code         string `json:"code,omitempty"`
+	Type         string `json:"type"`
+	Applied      bool   `json:"applied"`
+	Discount     Money  `json:"discount"`
+	FreeShipping bool   `json:"free_shipping,omitempty"`
+	// ร้านที่ได้ส่งฟรี คือร้านของสินค้าที่เข้าเงื่อนไข โปรโมชันที่จำกัดร้านจึงไม่ทำให้ร้านอื่นส่งฟรีด้วย
+	FreeShippingSellerIDs []string `json:"free_shipping_seller_ids,omitempty"`
+	Reason                string   `json:"reason"`
+
+	itemIDs []string // รายการในตะกร้าที่เข้าเงื่อนไข ใช้กระจายส่วนลดเพื่อคิดภาษีรายบรรทัด (ดู tax.go)
+}
+
+// promotionUsage คือจำนวนครั้งที่ใช้โปรโมชันไปแล้วทั้งหมด และของผู้ใช้ที่กำลังดูตะกร้า
+type promotionUsage struct {
+	total, user int
+}
+
+// evaluatePromotions คำนวณส่วนลดของตะกร้า โปรโมชันที่ใช้ได้ทั้งหมดใช้ร่วมกันได้
+// โดยเรียงจากส่วนลดมากไปน้อย และส่วนลดรวมจะไม่เกินยอดรวมของตะกร้า
+// โปรโมชันอัตโนมัติจะแสดงเฉพาะเมื่อมีสินค้าในตะกร้าเข้าเงื่อนไข ส่วนโค้ดที่ผู้ใช้ใส่จะแสดงผลเสมอ
+func evaluatePromotions(cart *Cart, promotions []Promotion, usage map[string]promotionUsage, now time.Time) {
+	results := make([]PromotionResult, 0, len(promotions))
+	for _, promotion := range promotions {
+		result, eligible := evaluatePromotion(promotion, cart.Items, usage[promotion.ID], now)
+		if promotion.Code == "" && !eligible {
+			continue
+		}
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Applied != results[j].Applied {
+			return results[i].Applied
+		}
+		return results[i].Discount.Cmp(results[j].Discount) > 0
+	})
+
+	remaining := cart.Subtotal
+	cart.FreeShippingSellerIDs = []string{}
+	for i := range results {
+		if !results[i].Applied {
+			continue
+		}
+		if results[i].Discount.Cmp(remaining) > 0 {
+			results[i].Discount = remaining
+			results[i].Reason += "; discount reduced because the cart total is already fully discounted"
+		}
+		remaining = remaining.Sub(results[i].Discount)
+		cart.Discount = cart.Discount.Add(results[i].Discount)
+		for _, sellerID := range results[i].FreeShippingSellerIDs {
+			if !containsID(cart.FreeShippingSellerIDs, sellerID) {
+				cart.FreeShippingSellerIDs = append(cart.FreeShippingSellerIDs, sellerID)
+			}
+		}
+	}
+
+	cart.Promotions = results
+	cart.Total = cart.Subtotal.Sub(cart.Discount)
+}
+
+// evaluatePromotion คืนผลของโปรโมชันเดียว eligible เป็น true เมื่อโปรโมชันอยู่ในช่วงเวลาที่ใช้ได้
+// และมีสินค้าในตะกร้าเข้าเงื่อนไข แม้ยอดซื้อยังไม่ถึงขั้นต่ำ
+func evaluatePromotion(promotion Promotion, items []CartItem, usage promotionUsage, now time.Time) (PromotionResult, bool) {
+	result := PromotionResult{
+		PromotionID: promotion.ID,
+		Name:        promotion.Name,
+		Code:        promotion.Code,
+		Type:        promotion.Type,
+	}
+	reject := func(format string, args ...interface{}) (PromotionResult, bool) {
+		result.Reason = fmt.Sprintf(format, args...)
+		return result, false
+	}
+
+	switch {
+	case !promotion.IsActive:
+		return reject("promotion is not active")
+	case now.Before(promotion.StartsAt):
+		return reject("promotion starts at %s", promotion.StartsAt.Format(time.RFC3339))
+	case promotion.EndsAt != nil && !now.Before(*promotion.EndsAt):
+		return reject("promotion ended at %s", promotion.EndsAt.Format(time.RFC3339))
+	case promotion.UsageLimit > 0 && usage.total >= promotion.UsageLimit:
+		return reject("promotion has reached its usage limit")
+	case promotion.PerUserLimit > 0 && usage.user >= promotion.PerUserLimit:
+		return reject("you have already used this promotion %d time(s)", usage.user)
+	}
+
+	var eligible []CartItem
+	var eligibleSubtotal Money
+	for _, item := range items {
+		if promotionCovers(promotion, item) {
+			eligible = append(eligible, item)
+			result.itemIDs = append(result.itemIDs, item.ID)
+			eligibleSubtotal = eligibleSubtotal.Add(item.LineTotal)
+		}
+	}
+	if len(eligible) == 0 {
+		return reject("no items in the cart are eligible for this promotion")
+	}
+	if eligibleSubtotal.Cmp(promotion.MinSpend) < 0 {
+		result.Reason = fmt.Sprintf("spend %s %s more on eligible items to use this promotion",
+			promotion.MinSpend.Sub(eligibleSubtotal), promotion.MinSpend.Currency())
+		return result, true
+	}
+
+	switch promotion.Type {
+	case "percentage":
+		discount, err := eligibleSubtotal.Percent(promotion.DiscountPercent)
+		if err != nil {
+			return reject("promotion is misconfigured")
+		}
+		result.Reason = fmt.Sprintf("%s%% off eligible items", promotion.DiscountPercent)
+		if promotion.MaxDiscount != nil && discount.Cmp(*promotion.MaxDiscount) > 0 {
+			discount = *promotion.MaxDiscount
+			result.Reason += fmt.Sprintf(", capped at %s %s", discount, discount.Currency())
+		}
+		result.Discount = discount
+	case "fixed_amount":
+		if promotion.DiscountAmount == nil {
+			return reject("promotion is misconfigured")
+		}
+		result.Discount = promotion.DiscountAmount.Min(eligibleSubtotal)
+		result.Reason = fmt.Sprintf("%s %s off eligible items", promotion.DiscountAmount, promotion.DiscountAmount.Currency())
+	case "free_shipping":
+		result.FreeShipping = true
+		for _, item := range eligible {
+			if !containsID(result.FreeShippingSellerIDs, item.SellerID) {
+				result.FreeShippingSellerIDs = append(result.FreeShippingSellerIDs, item.SellerID)
+			}
+		}
+		result.Reason = "free shipping"
+	case "buy_x_get_y":
+		discount, free := buyXGetYDiscount(eligible, promotion.BuyQuantity, promotion.GetQuantity)
+		if free == 0 {
+			result.Reason = fmt.Sprintf("add eligible items to reach %d to get %d free",
+				promotion.BuyQuantity+promotion.GetQuantity, promotion.GetQuantity)
+			return result, true
+		}
+		result.Discount = discount
+		result.Reason = fmt.Sprintf("buy %d get %d free: %d item(s) free", promotion.BuyQuantity, promotion.GetQuantity, free)
+	default:
+		return reject("promotion is misconfigured")
+	}
+
+	result.Applied = true
+	return result, true
+}
+
+func promotionCovers(promotion Promotion, item CartItem) bool {
+	switch promotion.Scope {
+	case "product":
+		return containsID(promotion.ScopeValues, item.ProductID)
+	case "category":
+		return containsID(promotion.ScopeValues, strconv.Itoa(item.CategoryID))
+	case "seller":
+		return containsID(promotion.ScopeValues, item.SellerID)
+	case "product_type":
+		return containsID(promotion.ScopeValues, item.ProductType)
+	}
+	return true
+}
+
+// buyXGetYDiscount ทุกๆ buy+get ชิ้นของสินค้าที่เข้าเงื่อนไข ชิ้นที่ราคาถูกที่สุด get ชิ้นจะได้ฟรี
+func buyXGetYDiscount(items []CartItem, buy, get int) (Money, int) {
+	if buy <= 0 || get <= 0 {
+		return Money{}, 0
+	}
+
+	var units []Money
+	for _, item := range items {
+		for i := 0; i < item.Quantity; i++ {
+			units = append(units, item.UnitPrice)
+		}
+	}
+	free := len(units) / (buy + get) * get
+	sort.Slice(units, func(i, j int) bool { return units[i].Cmp(units[j]) < 0 })
+
+	var discount Money
+	for _, price := range units[:free] {
+		discount = discount.Add(price)
+	}
+	return discount, free
+}
+
+// normalizePromotionCode โค้ดไม่สนตัวพิมพ์เล็กใหญ่ จึงเก็บและค้นหาเป็นตัวพิมพ์ใหญ่เสมอ
+func normalizePromotionCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+const promotionColumns = `pr.promotion_id, pr.name, COALESCE(pr.description, ''), COALESCE(pr.code, ''), pr.type,
+		       COALESCE(pr.discount_percent::text, ''), pr.discount_amount, pr.max_discount,
+		       COALESCE(pr.buy_quantity, 0), COALESCE(pr.get_quantity, 0), pr.min_spend,
+		       pr.scope, pr.scope_values, COALESCE(pr.usage_limit, 0), COALESCE(pr.per_user_limit, 0),
+		       (SELECT COUNT(*) FROM promotion_redemptions r WHERE r.promotion_id = pr.promotion_id),
+		       pr.starts_at, pr.ends_at, pr.is_active, pr.created_at, pr.updated_at`
+
+func scanPromotion(row rowScanner) (Promotion, error) {
+	var p Promotion
+	err := row.Scan(&p.ID, &p.Name, &p.Description, &p.Code, &p.Type,
+		&p.DiscountPercent, &p.DiscountAmount, &p.MaxDiscount,
+		&p.BuyQuantity, &p.GetQuantity, &p.MinSpend,
+		&p.Scope, pq.Array(&p.ScopeValues), &p.UsageLimit, &p.PerUserLimit,
+		&p.UsageCount, &p.StartsAt, &p.EndsAt, &p.IsActive, &p.CreatedAt, &p.UpdatedAt)
+	if p.ScopeValues == nil {
+		p.ScopeValues = []string{}
+	}
+	return p, err
+}
+
+func (pdb *PostgresDatabase) queryPromotions(ctx context.Context, query string, args ...interface{}) ([]Promotion, error) {
+	rows, err := pdb.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promotions: %w", err)
+	}
+	defer rows.Close()
+
+	promotions := []Promotion{}
+	for rows.Next() {
+		promotion, err := scanPromotion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan promotion: %w", err)
+		}
+		promotions = append(promotions, promotion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return promotions, nil
+}
+
+func (pdb *PostgresDatabase) GetPromotions(ctx context.Context) ([]Promotion, error) {
+	return pdb.queryPromotions(ctx, `
+		SELECT `+promotionColumns+`
+		FROM promotions pr
+		ORDER BY pr.created_at DESC
+	`)
+}
+
+func (pdb *PostgresDatabase) GetPromotion(ctx context.Context, id string) (Promotion, error) {
+	promotion, err := scanPromotion(pdb.db.QueryRowContext(ctx, `
+		SELECT `+promotionColumns+`
+		FROM promotions pr
+		WHERE pr.promotion_id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return Promotion{}, notFound("promotion not found")
+	}
+	if err != nil {
+		return Promotion{}, fmt.Errorf("failed to get promotion: %w", err)
+	}
+	return promotion, nil
+}
+
+// promotionArgs เรียงค่าตามคอลัมน์ใน INSERT และ UPDATE โดยใส่ค่าเริ่มต้นให้ field ที่ไม่ได้ส่งมา
+func promotionArgs(p NewPromotion) []interface{} {
+	scope := p.Scope
+	if scope == "" || scope == "all" {
+		scope, p.ScopeValues = "all", nil
+	}
+	startsAt := time.Now()
+	if p.StartsAt != nil {
+		startsAt = *p.StartsAt
+	}
+	isActive := p.IsActive == nil || *p.IsActive
+	nullInt := func(v int) sql.NullInt64 { return sql.NullInt64{Int64: int64(v), Valid: v > 0} }
+
+	return []interface{}{
+		p.Name, p.Description, nullString(normalizePromotionCode(p.Code)), p.Type,
+		nullString(p.DiscountPercent), p.DiscountAmount, p.MaxDiscount,
+		nullInt(p.BuyQuantity), nullInt(p.GetQuantity), p.MinSpend,
+		scope, pq.Array(append([]string{}, p.ScopeValues...)), nullInt(p.UsageLimit), nullInt(p.PerUserLimit),
+		startsAt, p.EndsAt, isActive,
+	}
+}
+
+func (pdb *PostgresDatabase) CreatePromotion(ctx context.Context, promotion NewPromotion) (Promotion, error) {
+	var id string
+	err := pdb.db.QueryRowContext(ctx, `
+		INSERT INTO promotions (name, description, code, type,
+		                        discount_percent, discount_amount, max_discount,
+		                        buy_quantity, get_quantity, min_spend,
+		                        scope, scope_values, usage_limit, per_user_limit,
+		                        starts_at, ends_at, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING promotion_id
+	`, promotionArgs(promotion)...).Scan(&id)
+	if err != nil {
+		return Promotion{}, fmt.Errorf("failed to create promotion: %w", err)
+	}
+	return pdb.GetPromotion(ctx, id)
+}
+
+func (pdb *PostgresDatabase) UpdatePromotion(ctx context.Context, id string, promotion NewPromotion) (Promotion, error) {
+	result, err := pdb.db.ExecContext(ctx, `
+		UPDATE promotions
+		SET name = $1, description = $2, code = $3, type = $4,
+		    discount_percent = $5, discount_amount = $6, max_discount = $7,
+		    buy_quantity = $8, get_quantity = $9, min_spend = $10,
+		    scope = $11, scope_values = $12, usage_limit = $13, per_user_limit = $14,
+		    starts_at = $15, ends_at = $16, is_active = $17, updated_at = NOW()
+		WHERE promotion_id = $18
+	`, append(promotionArgs(promotion), id)...)
+	if err != nil {
+		return Promotion{}, fmt.Errorf("failed to update promotion: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return Promotion{}, fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return Promotion{}, notFound("promotion not found")
+	}
+	return pdb.GetPromotion(ctx, id)
+}
+
+// DeletePromotion ลบได้เฉพาะโปรโมชันที่ยังไม่เคยถูกใช้ ถ้าเคยใช้แล้วให้ปิดด้วย is_active แทน
+func (pdb *PostgresDatabase) DeletePromotion(ctx context.Context, id string) error {
+	result, err := pdb.db.ExecContext(ctx, `DELETE FROM promotions WHERE promotion_id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete promotion: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return notFound("promotion not found")
+	}
+	return nil
+}
+
+// ApplyPromotionCode เก็บโค้ดไว้กับตะกร้าแม้ยังใช้ไม่ได้ เพื่อให้ตะกร้าอธิบายเหตุผลและใช้ได้ทันทีเมื่อครบเงื่อนไข
+func (pdb *PostgresDatabase) ApplyPromotionCode(ctx context.Context, userID, code string) (Cart, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var promotionID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT promotion_id FROM promotions WHERE code = $1
+	`, normalizePromotionCode(code)).Scan(&promotionID)
+	if err == sql.ErrNoRows {
+		return Cart{}, notFound("promotion code %s not found", normalizePromotionCode(code))
+	}
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to get promotion: %w", err)
+	}
+
+	var cartID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO carts (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET updated_at = NOW()
+		RETURNING cart_id
+	`, userID).Scan(&cartID)
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO cart_promotion_codes (cart_id, promotion_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, cartID, promotionID)
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to apply promotion code: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Cart{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pdb.GetCart(ctx, userID)
+}
+
+func (pdb *PostgresDatabase) RemovePromotionCode(ctx context.Context, userID, code string) (Cart, error) {
+	result, err := pdb.db.ExecContext(ctx, `
+		DELETE FROM cart_promotion_codes cp
+		USING carts c, promotions pr
+		WHERE c.cart_id = cp.cart_id AND pr.promotion_id = cp.promotion_id
+		  AND c.user_id = $1 AND pr.code = $2
+	`, userID, normalizePromotionCode(code))
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to remove promotion code: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return Cart{}, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return Cart{}, notFound("promotion code %s is not applied to the cart", normalizePromotionCode(code))
+	}
+	return pdb.GetCart(ctx, userID)
+}
+
+// applyCartPromotions ดึงโปรโมชันอัตโนมัติที่เปิดอยู่และโค้ดที่ผู้ใช้ใส่ไว้ แล้วคำนวณส่วนลดของตะกร้า
+func (pdb *PostgresDatabase) applyCartPromotions(ctx context.Context, userID string, cart *Cart) error {
+	promotions, err := pdb.queryPromotions(ctx, `
+		SELECT `+promotionColumns+`
+		FROM promotions pr
+		WHERE (pr.code IS NULL AND pr.is_active AND pr.starts_at <= NOW() AND (pr.ends_at IS NULL OR pr.ends_at > NOW()))
+		   OR pr.promotion_id IN (SELECT promotion_id FROM cart_promotion_codes WHERE cart_id = $1)
+		ORDER BY pr.created_at
+	`, cart.ID)
+	if err != nil {
+		return err
+	}
+
+	usage := map[string]promotionUsage{}
+	if len(promotions) > 0 {
+		ids := make([]string, len(promotions))
+		for i, promotion := range promotions {
+			ids[i] = promotion.ID
+		}
+		rows, err := pdb.db.QueryContext(ctx, `
+			SELECT promotion_id, COUNT(*), COUNT(*) FILTER (WHERE user_id = $2)
+			FROM promotion_redemptions
+			WHERE promotion_id = ANY($1::uuid[])
+			GROUP BY promotion_id
+		`, pq.Array(ids), userID)
+		if err != nil {
+			return fmt.Errorf("failed to get promotion usage: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id string
+			var u promotionUsage
+			if err := rows.Scan(&id, &u.total, &u.user); err != nil {
+				return fmt.Errorf("failed to scan promotion usage: %w", err)
+			}
+			usage[id] = u
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("rows iteration error: %w", err)
+		}
+	}
+
+	evaluatePromotions(cart, promotions, usage, time.Now())
+	return nil
+}
+
+func (s *Store) GetPromotions(ctx context.Context) ([]Promotion, error) {
+	return s.db.GetPromotions(ctx)
+}
+
+func (s *Store) GetPromotion(ctx context.Context, id string) (Promotion, error) {
+	return s.db.GetPromotion(ctx, id)
+}
+
+func (s *Store) CreatePromotion(ctx context.Context, promotion NewPromotion) (Promotion, error) {
+	return s.db.CreatePromotion(ctx, promotion)
+}
+
+func (s *Store) UpdatePromotion(ctx context.Context, id string, promotion NewPromotion) (Promotion, error) {
+	return s.db.UpdatePromotion(ctx, id, promotion)
+}
+
+func (s *Store) DeletePromotion(ctx context.Context, id string) error {
+	return s.db.DeletePromotion(ctx, id)
+}
+
+func (s *Store) ApplyPromotionCode(ctx context.Context, userID, code string) (Cart, error) {
+	return s.db.ApplyPromotionCode(ctx, userID, code)
+}
+
+func (s *Store) RemovePromotionCode(ctx context.Context, userID, code string) (Cart, error) {
+	return s.db.RemovePromotionCode(ctx, userID, code)
+}
@@ -0,0 +1,302 @@
+package ecommerce
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testCartItem(id, productID, sellerID string, categoryID int, unitPrice int64, quantity int) CartItem {
+	return CartItem{
+		ID:         id,
+		ProductID:  productID,
+		SellerID:   sellerID,
+		CategoryID: categoryID,
+		UnitPrice:  NewMoney(unitPrice, DefaultCurrency),
+		Quantity:   quantity,
+		LineTotal:  NewMoney(unitPrice*int64(quantity), DefaultCurrency),
+	}
+}
+
+func TestEvaluatePromotion(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ended := now.Add(-time.Minute)
+	items := []CartItem{
+		testCartItem("a", "p1", "s1", 1, 10000, 1),
+		testCartItem("b", "p2", "s2", 2, 5000, 2),
+	}
+	active := func(p Promotion) Promotion {
+		p.ID, p.Name, p.IsActive, p.StartsAt = "promo", "Promo", true, now.Add(-time.Hour)
+		if p.Scope == "" {
+			p.Scope = "all"
+		}
+		return p
+	}
+	money := func(minor int64) *Money { return moneyPtr(NewMoney(minor, DefaultCurrency)) }
+
+	tests := []struct {
+		name         string
+		promotion    Promotion
+		usage        promotionUsage
+		wantEligible bool
+		wantApplied  bool
+		wantDiscount int64
+		wantItemIDs  []string
+		wantSellers  []string
+	}{
+		{
+			name:         "percentage of whole cart",
+			promotion:    active(Promotion{Type: "percentage", DiscountPercent: "10"}),
+			wantEligible: true, wantApplied: true, wantDiscount: 2000, wantItemIDs: []string{"a", "b"},
+		},
+		{
+			name:         "percentage capped by max discount",
+			promotion:    active(Promotion{Type: "percentage", DiscountPercent: "10", MaxDiscount: money(1500)}),
+			wantEligible: true, wantApplied: true, wantDiscount: 1500, wantItemIDs: []string{"a", "b"},
+		},
+		{
+			name:         "seller scope counts only that seller",
+			promotion:    active(Promotion{Type: "percentage", DiscountPercent: "10", Scope: "seller", ScopeValues: []string{"s2"}}),
+			wantEligible: true, wantApplied: true, wantDiscount: 1000, wantItemIDs: []string{"b"},
+		},
+		{
+			name:         "fixed amount limited to eligible items",
+			promotion:    active(Promotion{Type: "fixed_amount", DiscountAmount: money(15000), Scope: "product", ScopeValues: []string{"p1"}}),
+			wantEligible: true, wantApplied: true, wantDiscount: 10000, wantItemIDs: []string{"a"},
+		},
+		{
+			name:         "category scope",
+			promotion:    active(Promotion{Type: "fixed_amount", DiscountAmount: money(500), Scope: "category", ScopeValues: []string{"2"}}),
+			wantEligible: true, wantApplied: true, wantDiscount: 500, wantItemIDs: []string{"b"},
+		},
+		{
+			name:      "no eligible items",
+			promotion: active(Promotion{Type: "fixed_amount", DiscountAmount: money(500), Scope: "category", ScopeValues: []string{"9"}}),
+		},
+		{
+			name:         "min spend on eligible items not reached",
+			promotion:    active(Promotion{Type: "fixed_amount", DiscountAmount: money(500), MinSpend: NewMoney(15000, DefaultCurrency), Scope: "seller", ScopeValues: []string{"s1"}}),
+			wantEligible: true, wantItemIDs: []string{"a"},
+		},
+		{
+			name:         "free shipping only for sellers of eligible items",
+			promotion:    active(Promotion{Type: "free_shipping", Scope: "product", ScopeValues: []string{"p2"}}),
+			wantEligible: true, wantApplied: true, wantItemIDs: []string{"b"}, wantSellers: []string{"s2"},
+		},
+		{
+			name:         "buy x get y with mixed prices",
+			promotion:    active(Promotion{Type: "buy_x_get_y", BuyQuantity: 2, GetQuantity: 1}),
+			wantEligible: true, wantApplied: true, wantDiscount: 5000, wantItemIDs: []string{"a", "b"},
+		},
+		{
+			name:         "buy x get y short of the required quantity",
+			promotion:    active(Promotion{Type: "buy_x_get_y", BuyQuantity: 3, GetQuantity: 1}),
+			wantEligible: true, wantItemIDs: []string{"a", "b"},
+		},
+		{
+			name: "inactive",
+			promotion: func() Promotion {
+				p := active(Promotion{Type: "percentage", DiscountPercent: "10"})
+				p.IsActive = false
+				return p
+			}(),
+		},
+		{
+			name: "not started",
+			promotion: func() Promotion {
+				p := active(Promotion{Type: "percentage", DiscountPercent: "10"})
+				p.StartsAt = now.Add(time.Hour)
+				return p
+			}(),
+		},
+		{
+			name:      "ended",
+			promotion: active(Promotion{Type: "percentage", DiscountPercent: "10", EndsAt: &ended}),
+		},
+		{
+			name:      "usage limit reached",
+			promotion: active(Promotion{Type: "percentage", DiscountPercent: "10", UsageLimit: 5}),
+			usage:     promotionUsage{total: 5},
+		},
+		{
+			name:      "per user limit reached",
+			promotion: active(Promotion{Type: "percentage", DiscountPercent: "10", PerUserLimit: 1}),
+			usage:     promotionUsage{total: 2, user: 1},
+		},
+		{
+			name:      "misconfigured fixed amount",
+			promotion: active(Promotion{Type: "fixed_amount"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, eligible := evaluatePromotion(tt.promotion, items, tt.usage, now)
+			if eligible != tt.wantEligible || got.Applied != tt.wantApplied {
+				t.Fatalf("eligible, applied = %t, %t, want %t, %t (%s)", eligible, got.Applied, tt.wantEligible, tt.wantApplied, got.Reason)
+			}
+			if got.Discount.Minor() != tt.wantDiscount {
+				t.Errorf("Discount = %d, want %d", got.Discount.Minor(), tt.wantDiscount)
+			}
+			if !tt.wantEligible {
+				return
+			}
+			if !reflect.DeepEqual(got.itemIDs, tt.wantItemIDs) {
+				t.Errorf("itemIDs = %v, want %v", got.itemIDs, tt.wantItemIDs)
+			}
+			if !reflect.DeepEqual(got.FreeShippingSellerIDs, tt.wantSellers) {
+				t.Errorf("FreeShippingSellerIDs = %v, want %v", got.FreeShippingSellerIDs, tt.wantSellers)
+			}
+		})
+	}
+}
+
+func TestEvaluatePromotions(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	promotion := func(id, code, promotionType string, discount int64, scope string, values ...string) Promotion {
+		p := Promotion{ID: id, Name: id, Code: code, Type: promotionType, Scope: scope, ScopeValues: values, IsActive: true, StartsAt: now.Add(-time.Hour)}
+		switch promotionType {
+		case "percentage":
+			p.DiscountPercent = "10"
+		case "fixed_amount":
+			p.DiscountAmount = moneyPtr(NewMoney(discount, DefaultCurrency))
+		}
+		return p
+	}
+	newCart := func() *Cart {
+		items := []CartItem{
+			testCartItem("a", "p1", "s1", 1, 10000, 1),
+			testCartItem("b", "p2", "s2", 2, 5000, 2),
+		}
+		return &Cart{Items: items, Subtotal: NewMoney(20000, DefaultCurrency)}
+	}
+
+	tests := []struct {
+		name          string
+		promotions    []Promotion
+		wantIDs       []string
+		wantDiscounts []int64
+		wantDiscount  int64
+		wantSellers   []string
+	}{
+		{
+			name: "stacks applied promotions from largest discount",
+			promotions: []Promotion{
+				promotion("ten-percent", "", "percentage", 0, "all"),
+				promotion("fifty-off", "", "fixed_amount", 5000, "all"),
+				promotion("ship-s2", "", "free_shipping", 0, "seller", "s2"),
+			},
+			wantIDs:       []string{"fifty-off", "ten-percent", "ship-s2"},
+			wantDiscounts: []int64{5000, 2000, 0},
+			wantDiscount:  7000,
+			wantSellers:   []string{"s2"},
+		},
+		{
+			name: "shows codes that do not apply and hides automatic ones",
+			promotions: []Promotion{
+				promotion("automatic", "", "fixed_amount", 500, "category", "9"),
+				promotion("code", "SAVE", "fixed_amount", 500, "category", "9"),
+				promotion("fixed", "", "fixed_amount", 1000, "product", "p1"),
+			},
+			wantIDs:       []string{"fixed", "code"},
+			wantDiscounts: []int64{1000, 0},
+			wantDiscount:  1000,
+			wantSellers:   []string{},
+		},
+		{
+			name: "total discount never exceeds the subtotal",
+			promotions: []Promotion{
+				promotion("first", "", "fixed_amount", 15000, "all"),
+				promotion("second", "", "fixed_amount", 12000, "all"),
+			},
+			wantIDs:       []string{"first", "second"},
+			wantDiscounts: []int64{15000, 5000},
+			wantDiscount:  20000,
+			wantSellers:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart := newCart()
+			evaluatePromotions(cart, tt.promotions, nil, now)
+
+			ids := make([]string, len(cart.Promotions))
+			discounts := make([]int64, len(cart.Promotions))
+			for i, result := range cart.Promotions {
+				ids[i], discounts[i] = result.PromotionID, result.Discount.Minor()
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) || !reflect.DeepEqual(discounts, tt.wantDiscounts) {
+				t.Fatalf("promotions = %v %v, want %v %v", ids, discounts, tt.wantIDs, tt.wantDiscounts)
+			}
+			if cart.Discount.Minor() != tt.wantDiscount || cart.Total.Minor() != cart.Subtotal.Minor()-tt.wantDiscount {
+				t.Errorf("Discount, Total = %d, %d, want %d, %d", cart.Discount.Minor(), cart.Total.Minor(), tt.wantDiscount, cart.Subtotal.Minor()-tt.wantDiscount)
+			}
+			if !reflect.DeepEqual(cart.FreeShippingSellerIDs, tt.wantSellers) {
+				t.Errorf("FreeShippingSellerIDs = %v, want %v", cart.FreeShippingSellerIDs, tt.wantSellers)
+			}
+		})
+	}
+}
+
+func TestBuyXGetYDiscount(t *testing.T) {
+	tests := []struct {
+		name         string
+		items        []CartItem
+		buy, get     int
+		wantDiscount int64
+		wantFree     int
+	}{
+		{
+			name:  "cheapest item is free",
+			items: []CartItem{testCartItem("a", "p1", "s1", 1, 30000, 1), testCartItem("b", "p2", "s1", 1, 10000, 1)},
+			buy:   1, get: 1,
+			wantDiscount: 10000, wantFree: 1,
+		},
+		{
+			name: "cheapest units across lines",
+			items: []CartItem{
+				testCartItem("a", "p1", "s1", 1, 30000, 2),
+				testCartItem("b", "p2", "s1", 1, 10000, 1),
+				testCartItem("c", "p3", "s2", 1, 20000, 3),
+			},
+			buy: 2, get: 1,
+			wantDiscount: 30000, wantFree: 2,
+		},
+		{
+			name:  "incomplete group is not free",
+			items: []CartItem{testCartItem("a", "p1", "s1", 1, 30000, 2), testCartItem("b", "p2", "s1", 1, 10000, 3)},
+			buy:   2, get: 1,
+			wantDiscount: 10000, wantFree: 1,
+		},
+		{
+			name:  "several free per group",
+			items: []CartItem{testCartItem("a", "p1", "s1", 1, 30000, 2), testCartItem("b", "p2", "s1", 1, 9900, 2)},
+			buy:   1, get: 2,
+			wantDiscount: 19800, wantFree: 2,
+		},
+		{
+			name:  "not enough items",
+			items: []CartItem{testCartItem("a", "p1", "s1", 1, 30000, 2)},
+			buy:   2, get: 1,
+		},
+		{
+			name:  "misconfigured quantities",
+			items: []CartItem{testCartItem("a", "p1", "s1", 1, 30000, 4)},
+			buy:   0, get: 1,
+		},
+		{
+			name: "empty cart",
+			buy:  1, get: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discount, free := buyXGetYDiscount(tt.items, tt.buy, tt.get)
+			if discount.Minor() != tt.wantDiscount || free != tt.wantFree {
+				t.Errorf("buyXGetYDiscount(buy %d, get %d) = %d, %d, want %d, %d", tt.buy, tt.get, discount.Minor(), free, tt.wantDiscount, tt.wantFree)
+			}
+		})
+	}
+}
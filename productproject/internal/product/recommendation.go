@@ -0,0 +1,375 @@
+// recommendation.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// ชื่อกลยุทธ์การแนะนำสินค้า แสดงใน recommendation_source ของแต่ละรายการ
+const (
+	StrategyAlsoBought        = "also_bought"
+	StrategyRecentlyViewed    = "recently_viewed"
+	StrategyPopularInCategory = "popular_in_category"
+	StrategyCurated           = "curated"
+)
+
+const (
+	defaultRecommendationLimit = 3
+	MaxRecommendationLimit     = 20
+)
+
+// สินค้าที่แนะนำได้ต้องเปิดขายและมาจากร้านที่ผ่านการยืนยันแล้ว (ต้อง JOIN sellers s)
+const recommendableProduct = `p.availability = 'active' AND s.verification_status = 'approved'`
+
+type RecommendationRequest struct {
+	UserID     string `json:"user_id"`
+	SessionID  string `json:"session_id"`
+	ProductID  string `json:"product_id"`  // สินค้าที่กำลังดูอยู่ ถ้ามี
+	CategoryID int    `json:"category_id"` // หมวดหมู่ที่กำลังดูอยู่ ถ้ามี
+	Limit      int    `json:"limit"`
+}
+
+type RecommendedProduct struct {
+	ProductItem
+	Source string `json:"recommendation_source"`
+}
+
+// RecommendationStrategy คืนรหัสสินค้าเรียงจากเหมาะสมที่สุด ไม่เกิน limit รายการ
+// exclude คือสินค้าที่กลยุทธ์ก่อนหน้าเลือกไปแล้วหรือสินค้าที่กำลังดูอยู่
+type RecommendationStrategy interface {
+	Name() string
+	Recommend(ctx context.Context, req RecommendationRequest, exclude []string, limit int) ([]string, error)
+}
+
+// SetRecommendationStrategies กำหนดลำดับกลยุทธ์ใหม่ กลยุทธ์ถัดไปจะเติมเมื่อข้อมูลของกลยุทธ์ก่อนหน้าไม่พอ
+func (pdb *PostgresDatabase) SetRecommendationStrategies(strategies ...RecommendationStrategy) {
+	pdb.recommendationStrategies = strategies
+}
+
+func defaultRecommendationStrategies(pdb *PostgresDatabase) []RecommendationStrategy {
+	return []RecommendationStrategy{
+		&alsoBoughtStrategy{pdb: pdb, minSupport: 2},
+		&recentlyViewedStrategy{pdb: pdb},
+		&popularInCategoryStrategy{pdb: pdb},
+		&curatedStrategy{pdb: pdb},
+	}
+}
+
+func (pdb *PostgresDatabase) GetRecommendedProduct(ctx context.Context, req RecommendationRequest) ([]RecommendedProduct, error) {
+	limit := defaultRecommendationLimit
+	if req.Limit > 0 && req.Limit <= MaxRecommendationLimit {
+		limit = req.Limit
+	}
+
+	exclude := []string{}
+	if req.ProductID != "" {
+		exclude = append(exclude, req.ProductID)
+	}
+	seen := map[string]bool{}
+	for _, id := range exclude {
+		seen[id] = true
+	}
+
+	var ids, sources []string
+	for _, strategy := range pdb.recommendationStrategies {
+		if len(ids) >= limit {
+			break
+		}
+
+		picked, err := strategy.Recommend(ctx, req, exclude, limit-len(ids))
+		if err != nil {
+			// กลยุทธ์ที่ล้มเหลวจะถูกข้ามไป เพื่อให้กลยุทธ์ถัดไปยังทำงานได้
+			log.Printf("recommendation strategy %s failed: %v", strategy.Name(), err)
+			continue
+		}
+
+		for _, id := range picked {
+			if seen[id] || len(ids) >= limit {
+				continue
+			}
+			seen[id] = true
+			exclude = append(exclude, id)
+			ids = append(ids, id)
+			sources = append(sources, strategy.Name())
+		}
+	}
+
+	items, err := pdb.getProductItemsByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// คงลำดับตามที่กลยุทธ์เลือกไว้
+	products := []RecommendedProduct{}
+	for i, id := range ids {
+		item, ok := items[id]
+		if !ok {
+			continue
+		}
+		products = append(products, RecommendedProduct{ProductItem: item, Source: sources[i]})
+	}
+
+	return products, nil
+}
+
+func (pdb *PostgresDatabase) RecordProductView(ctx context.Context, productID, userID, sessionID string) error {
+	_, err := pdb.db.ExecContext(ctx, `
+		INSERT INTO product_views (product_id, user_id, session_id)
+		VALUES ($1, $2, $3)
+	`, productID, nullString(userID), nullString(sessionID))
+	if err != nil {
+		return fmt.Errorf("failed to record product view: %w", err)
+	}
+	return nil
+}
+
+// curatedStrategy สินค้าที่ร้านค้าหรือผู้ดูแลระบบตั้งค่าเป็น 'recommended'
+type curatedStrategy struct {
+	pdb *PostgresDatabase
+}
+
+func (s *curatedStrategy) Name() string { return StrategyCurated }
+
+func (s *curatedStrategy) Recommend(ctx context.Context, req RecommendationRequest, exclude []string, limit int) ([]string, error) {
+	return s.pdb.queryProductIDs(ctx, `
+		SELECT p.product_id
+		FROM products p
+		JOIN sellers s ON p.seller_id = s.seller_id
+		WHERE p.recommendation = 'recommended' AND `+recommendableProduct+`
+		  AND p.product_id <> ALL($1::uuid[])
+		ORDER BY p.rating_average DESC, p.created_at DESC
+		LIMIT $2
+	`, pq.Array(exclude), limit)
+}
+
+// popularInCategoryStrategy สินค้าขายดีใน 30 วันล่าสุดของหมวดหมู่ที่กำลังดู หรือทุกหมวดหมู่ถ้าไม่ระบุ
+type popularInCategoryStrategy struct {
+	pdb *PostgresDatabase
+}
+
+func (s *popularInCategoryStrategy) Name() string { return StrategyPopularInCategory }
+
+func (s *popularInCategoryStrategy) Recommend(ctx context.Context, req RecommendationRequest, exclude []string, limit int) ([]string, error) {
+	return s.pdb.queryProductIDs(ctx, `
+		WITH target AS (
+			SELECT COALESCE(NULLIF($1::int, 0), (SELECT category_id FROM products WHERE product_id = $2::uuid)) AS category_id
+		), sales AS (
+			SELECT oi.product_id, SUM(oi.quantity) AS units
+			FROM order_items oi
+			JOIN orders o ON o.order_id = oi.order_id
+			WHERE o.status IN ('paid', 'shipped', 'delivered') AND o.created_at >= NOW() - INTERVAL '30 days'
+			GROUP BY oi.product_id
+		)
+		SELECT p.product_id
+		FROM products p
+		JOIN sellers s ON p.seller_id = s.seller_id
+		JOIN sales ON sales.product_id = p.product_id
+		CROSS JOIN target t
+		WHERE `+recommendableProduct+`
+		  AND (t.category_id IS NULL OR p.category_id = t.category_id)
+		  AND p.product_id <> ALL($3::uuid[])
+		ORDER BY sales.units DESC, p.rating_average DESC
+		LIMIT $4
+	`, req.CategoryID, nullString(req.ProductID), pq.Array(exclude), limit)
+}
+
+// alsoBoughtStrategy "ลูกค้าที่ซื้อสินค้านี้ยังซื้อ" จากสินค้าที่อยู่ในคำสั่งซื้อเดียวกัน
+// ใช้สินค้าที่กำลังดูเป็นตัวตั้งต้น หรือสินค้าที่ผู้ใช้เคยซื้อล่าสุดถ้าไม่ได้ระบุ
+type alsoBoughtStrategy struct {
+	pdb        *PostgresDatabase
+	minSupport int // จำนวนคำสั่งซื้อขั้นต่ำที่ซื้อคู่กัน
+}
+
+func (s *alsoBoughtStrategy) Name() string { return StrategyAlsoBought }
+
+func (s *alsoBoughtStrategy) Recommend(ctx context.Context, req RecommendationRequest, exclude []string, limit int) ([]string, error) {
+	seeds := []string{}
+	if req.ProductID != "" {
+		seeds = append(seeds, req.ProductID)
+	} else if req.UserID != "" {
+		purchased, err := s.pdb.queryProductIDs(ctx, `
+			SELECT oi.product_id
+			FROM order_items oi
+			JOIN orders o ON o.order_id = oi.order_id
+			WHERE o.user_id = $1 AND oi.product_id IS NOT NULL AND o.status IN ('paid', 'shipped', 'delivered')
+			GROUP BY oi.product_id
+			ORDER BY MAX(o.created_at) DESC
+			LIMIT 10
+		`, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		seeds = purchased
+	}
+	if len(seeds) == 0 {
+		return nil, nil
+	}
+
+	return s.pdb.queryProductIDs(ctx, `
+		SELECT other.product_id
+		FROM order_items seed
+		JOIN orders o ON o.order_id = seed.order_id
+		JOIN order_items other ON other.order_id = seed.order_id AND other.product_id <> seed.product_id
+		JOIN products p ON p.product_id = other.product_id
+		JOIN sellers s ON p.seller_id = s.seller_id
+		WHERE seed.product_id = ANY($1::uuid[])
+		  AND o.status IN ('paid', 'shipped', 'delivered')
+		  AND `+recommendableProduct+`
+		  AND other.product_id <> ALL($1::uuid[])
+		  AND other.product_id <> ALL($2::uuid[])
+		GROUP BY other.product_id
+		HAVING COUNT(DISTINCT seed.order_id) >= $3
+		ORDER BY COUNT(DISTINCT seed.order_id) DESC, MAX(p.rating_average) DESC
+		LIMIT $4
+	`, pq.Array(seeds), pq.Array(exclude), s.minSupport, limit)
+}
+
+// recentlyViewedStrategy สินค้าที่คล้ายกับสินค้าที่ผู้ใช้หรือ session ดูล่าสุด
+// ให้คะแนนตามหมวดหมู่ ประเภท แบรนด์ และช่วงราคาที่ใกล้เคียงกัน
+type recentlyViewedStrategy struct {
+	pdb *PostgresDatabase
+}
+
+func (s *recentlyViewedStrategy) Name() string { return StrategyRecentlyViewed }
+
+func (s *recentlyViewedStrategy) Recommend(ctx context.Context, req RecommendationRequest, exclude []string, limit int) ([]string, error) {
+	if req.UserID == "" && req.SessionID == "" {
+		return nil, nil
+	}
+
+	return s.pdb.queryProductIDs(ctx, `
+		WITH recent AS (
+			SELECT product_id
+			FROM product_views
+			WHERE user_id = $1 OR session_id = $2
+			GROUP BY product_id
+			ORDER BY MAX(viewed_at) DESC
+			LIMIT 10
+		)
+		SELECT p.product_id
+		FROM recent r
+		JOIN products v ON v.product_id = r.product_id
+		JOIN products p ON p.product_id <> v.product_id
+		     AND (p.category_id = v.category_id OR p.product_type = v.product_type)
+		JOIN sellers s ON p.seller_id = s.seller_id
+		WHERE `+recommendableProduct+`
+		  AND p.product_id NOT IN (SELECT product_id FROM recent)
+		  AND p.product_id <> ALL($3::uuid[])
+		GROUP BY p.product_id
+		ORDER BY SUM(
+		    CASE WHEN p.category_id = v.category_id THEN 2 ELSE 0 END +
+		    CASE WHEN p.product_type = v.product_type THEN 1 ELSE 0 END +
+		    CASE WHEN p.brand = v.brand THEN 1 ELSE 0 END +
+		    CASE WHEN p.price BETWEEN v.price * 0.7 AND v.price * 1.3 THEN 1 ELSE 0 END
+		) DESC, MAX(p.rating_average) DESC
+		LIMIT $4
+	`, nullString(req.UserID), nullString(req.SessionID), pq.Array(exclude), limit)
+}
+
+func (pdb *PostgresDatabase) queryProductIDs(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := pdb.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query product ids: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan product id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return ids, nil
+}
+
+// getProductItemsByIDs ดึงข้อมูลสินค้าเต็มรูปแบบ คืนค่าเป็น map ตามรหัสสินค้า
+func (pdb *PostgresDatabase) getProductItemsByIDs(ctx context.Context, ids []string) (map[string]ProductItem, error) {
+	items := map[string]ProductItem{}
+	if len(ids) == 0 {
+		return items, nil
+	}
+
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT p.product_id, p.name, p.description, p.brand, p.model_number, p.sku, p.price,
+		       p.availability, p.recommendation, p.seller_id, p.product_type, p.category_id,
+		       p.created_at, p.updated_at, p.rating_average, p.rating_count,
+		       c.category_id, c.name as category_name,
+		       COALESCE(i.quantity, 0), i.updated_at
+		FROM products p
+		LEFT JOIN categories c ON p.category_id = c.category_id
+		LEFT JOIN inventory i ON p.product_id = i.product_id
+		WHERE p.product_id = ANY($1::uuid[])
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []ProductItem
+	for rows.Next() {
+		var product ProductItem
+		var category Category
+		var inventoryUpdatedAt sql.NullTime
+		if err := rows.Scan(
+			&product.ID, &product.Name, &product.Description, &product.Brand,
+			&product.ModelNumber, &product.SKU, &product.Price, &product.Availability,
+			&product.Recommendation, &product.SellerID, &product.ProductType, &product.CategoryID,
+			&product.CreatedAt, &product.UpdatedAt, &product.RatingAverage, &product.RatingCount,
+			&category.ID, &category.Name,
+			&product.Inventory.Quantity, &inventoryUpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		product.Categories = []Category{category}
+		product.Inventory.UpdatedAt = inventoryUpdatedAt.Time
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	for i, product := range products {
+		// ดึงข้อมูลรูปภาพ
+		product.Images, err = pdb.GetProductImages(ctx, product.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get product images: %w", err)
+		}
+		// ดึงข้อมูลตัวเลือก
+		product.Options, err = pdb.getProductOptions(ctx, product.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get product options: %w", err)
+		}
+		products[i] = product
+	}
+
+	if err := pdb.localizeProducts(ctx, products); err != nil {
+		return nil, err
+	}
+	if err := pdb.applySales(ctx, products); err != nil {
+		return nil, err
+	}
+	for _, product := range products {
+		items[product.ID] = product
+	}
+	return items, nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func (s *Store) RecordProductView(ctx context.Context, productID, userID, sessionID string) error {
+	return s.db.RecordProductView(ctx, productID, userID, sessionID)
+}
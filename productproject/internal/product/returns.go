@@ -0,0 +1,561 @@
+// returns.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// สถานะของคำขอคืนสินค้า ตรงกับ ENUM return_status
+const (
+	ReturnStatusRequested     = "requested"
+	ReturnStatusApproved      = "approved"
+	ReturnStatusRejected      = "rejected"
+	ReturnStatusCancelled     = "cancelled"
+	ReturnStatusReceived      = "received"
+	ReturnStatusRefundPending = "refund_pending"
+	ReturnStatusRefundFailed  = "refund_failed"
+	ReturnStatusRefunded      = "refunded"
+)
+
+// การกระทำของผู้ขายกับคำขอคืนสินค้า การคืนเงินแยกไปใช้ StartReturnRefund
+const (
+	ReturnActionApprove = "approve"
+	ReturnActionReject  = "reject"
+	ReturnActionReceive = "receive"
+)
+
+// returnStatusTransitions เก็บสถานะปลายทาง -> สถานะต้นทางที่อนุญาต
+// refund_pending เริ่มซ้ำได้ เพราะผู้ให้บริการชำระเงินไม่คืนเงินซ้ำเมื่อใช้เลขคำขอเดิม
+var returnStatusTransitions = map[string][]string{
+	ReturnStatusApproved:      {ReturnStatusRequested},
+	ReturnStatusRejected:      {ReturnStatusRequested},
+	ReturnStatusCancelled:     {ReturnStatusRequested},
+	ReturnStatusReceived:      {ReturnStatusApproved},
+	ReturnStatusRefundPending: {ReturnStatusReceived, ReturnStatusRefundFailed, ReturnStatusRefundPending},
+	ReturnStatusRefunded:      {ReturnStatusRefundPending},
+	ReturnStatusRefundFailed:  {ReturnStatusRefundPending},
+}
+
+var returnActionTargets = map[string]string{
+	ReturnActionApprove: ReturnStatusApproved,
+	ReturnActionReject:  ReturnStatusRejected,
+	ReturnActionReceive: ReturnStatusReceived,
+}
+
+// returnReasons ตรงกับ ENUM return_reason
+var returnReasons = []string{"damaged", "wrong_item", "not_as_described", "missing_parts", "changed_mind", "other"}
+
+// ReturnWindow คือระยะเวลาหลังได้รับสินค้าที่ยังขอคืนได้
+const ReturnWindow = 15 * 24 * time.Hour
+
+// MaxReturnPhotos คือจำนวนรูปประกอบสูงสุดต่อคำขอ
+const MaxReturnPhotos = 5
+
+type ReturnRequest struct {
+	ID              string               `json:"id"`
+	OrderID         string               `json:"order_id"`
+	OrderItemID     string               `json:"order_item_id"`
+	SellerID        string               `json:"seller_id"`
+	ProductName     string               `json:"product_name"`
+	VariantName     string               `json:"variant_name,omitempty"`
+	Quantity        int                  `json:"quantity"`
+	Reason          string               `json:"reason"`
+	Details         string               `json:"details"`
+	Status          string               `json:"status"`
+	RefundAmount    Money                `json:"refund_amount"`
+	RefundReference string               `json:"refund_reference,omitempty"`
+	SellerNote      string               `json:"seller_note"`
+	Photos          []string             `json:"photos"`
+	History         []ReturnStatusChange `json:"history"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+type ReturnStatusChange struct {
+	FromStatus  string    `json:"from_status,omitempty"` // ว่างสำหรับการสร้างคำขอ
+	ToStatus    string    `json:"to_status"`
+	ActorUserID string    `json:"actor_user_id,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NewReturnRequest รับเป็น multipart/form-data PhotoURLs เป็น URL ของรูปที่ handler อัปโหลดแล้ว
+type NewReturnRequest struct {
+	OrderItemID string   `json:"order_item_id" binding:"required,uuid"`
+	Quantity    int      `json:"quantity" binding:"min=1"`
+	Reason      string   `json:"reason" binding:"return_reason"`
+	Details     string   `json:"details" binding:"max=2000"`
+	PhotoURLs   []string `json:"-"`
+}
+
+func (r NewReturnRequest) Validate() error {
+	if err := ValidateStruct(r); err != nil {
+		return err
+	}
+	if r.Reason == "other" && r.Details == "" {
+		return invalidFields(FieldError{Field: "details", Message: "is required when reason is other"})
+	}
+	return nil
+}
+
+type ReturnAction struct {
+	Action string `json:"action"`                  // 'approve', 'reject', 'receive'
+	Note   string `json:"note" binding:"max=1000"` // จำเป็นเมื่อ action เป็น 'reject'
+}
+
+func (a ReturnAction) Validate() error {
+	if err := ValidateStruct(a); err != nil {
+		return err
+	}
+	if _, ok := returnActionTargets[a.Action]; !ok {
+		return invalidFields(FieldError{Field: "action", Message: "must be one of approve, reject, receive"})
+	}
+	if a.Action == ReturnActionReject && a.Note == "" {
+		return invalidFields(FieldError{Field: "note", Message: "is required when rejecting a return"})
+	}
+	return nil
+}
+
+// returnRefundAmount คิดยอดคืนเงินตามสัดส่วนของจำนวนที่คืนจากยอดหลังหักส่วนลดของบรรทัด
+// คำขอที่คืนครบจำนวนของบรรทัดจะได้ส่วนที่เหลือทั้งหมด ผลรวมการคืนเงินจึงเท่ากับยอดที่จ่ายพอดี
+func returnRefundAmount(paid Money, lineQuantity, quantity, alreadyReturned int, alreadyRefunded Money) (Money, error) {
+	if alreadyReturned+quantity == lineQuantity {
+		return paid.Sub(alreadyRefunded), nil
+	}
+	q := new(big.Rat).SetFrac64(paid.minor*int64(quantity), int64(lineQuantity))
+	minor, ok := roundHalfUp(q)
+	if !ok {
+		return Money{}, fmt.Errorf("amount is out of range")
+	}
+	return NewMoney(minor, paid.Currency()), nil
+}
+
+// CreateReturnRequest ขอคืนสินค้าจากบรรทัดของคำสั่งซื้อที่ได้รับแล้วและยังอยู่ในระยะเวลาที่คืนได้
+// จำนวนที่ขอคืนรวมกับคำขอเดิมที่ยังไม่ถูกปฏิเสธหรือยกเลิกต้องไม่เกินจำนวนที่ซื้อ
+func (pdb *PostgresDatabase) CreateReturnRequest(ctx context.Context, userID, orderID string, request NewReturnRequest) (ReturnRequest, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ReturnRequest{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// ล็อกคำสั่งซื้อเพื่อไม่ให้คำขอคืนพร้อมกันเกินจำนวนที่ซื้อ
+	var status string
+	var deliveredAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		SELECT status, delivered_at FROM orders WHERE order_id = $1 AND user_id = $2 FOR UPDATE
+	`, orderID, userID).Scan(&status, &deliveredAt)
+	if err == sql.ErrNoRows {
+		return ReturnRequest{}, notFound("order not found")
+	}
+	if err != nil {
+		return ReturnRequest{}, fmt.Errorf("failed to get order: %w", err)
+	}
+	if status != "delivered" {
+		return ReturnRequest{}, conflict("only delivered orders can be returned, this order is %s", status)
+	}
+	if deliveredAt.Valid && time.Since(deliveredAt.Time) > ReturnWindow {
+		return ReturnRequest{}, conflict("the return window of %d days has passed", int(ReturnWindow.Hours()/24))
+	}
+
+	var sellerID string
+	var lineQuantity int
+	var unitPrice, discount Money
+	err = tx.QueryRowContext(ctx, `
+		SELECT seller_id, quantity, unit_price, discount_amount
+		FROM order_items WHERE order_item_id = $1 AND order_id = $2
+	`, request.OrderItemID, orderID).Scan(&sellerID, &lineQuantity, &unitPrice, &discount)
+	if err == sql.ErrNoRows {
+		return ReturnRequest{}, notFound("order item not found")
+	}
+	if err != nil {
+		return ReturnRequest{}, fmt.Errorf("failed to get order item: %w", err)
+	}
+
+	var returned int
+	var refunded Money
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(quantity), 0), COALESCE(SUM(refund_amount), 0)
+		FROM return_requests
+		WHERE order_item_id = $1 AND status NOT IN ('rejected', 'cancelled')
+	`, request.OrderItemID).Scan(&returned, &refunded)
+	if err != nil {
+		return ReturnRequest{}, fmt.Errorf("failed to get returned quantity: %w", err)
+	}
+	if returned+request.Quantity > lineQuantity {
+		return ReturnRequest{}, conflict("only %d of this item can still be returned", lineQuantity-returned)
+	}
+
+	refundAmount, err := returnRefundAmount(unitPrice.Mul(lineQuantity).Sub(discount), lineQuantity, request.Quantity, returned, refunded)
+	if err != nil {
+		return ReturnRequest{}, err
+	}
+
+	var returnID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO return_requests (order_id, order_item_id, user_id, seller_id, quantity, reason, details, refund_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+		RETURNING return_id
+	`, orderID, request.OrderItemID, userID, sellerID, request.Quantity, request.Reason, request.Details, refundAmount).Scan(&returnID)
+	if err != nil {
+		return ReturnRequest{}, fmt.Errorf("failed to create return request: %w", err)
+	}
+
+	for i, url := range request.PhotoURLs {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO return_photos (return_id, photo_url, sort_order) VALUES ($1, $2, $3)
+		`, returnID, url, i)
+		if err != nil {
+			return ReturnRequest{}, fmt.Errorf("failed to add return photo: %w", err)
+		}
+	}
+
+	if err := recordReturnStatus(ctx, tx, returnID, "", ReturnStatusRequested, userID, ""); err != nil {
+		return ReturnRequest{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ReturnRequest{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pdb.getReturnRequest(ctx, `r.return_id = $1`, returnID)
+}
+
+// CancelReturnRequest ผู้ซื้อยกเลิกได้ก่อนผู้ขายพิจารณาเท่านั้น
+func (pdb *PostgresDatabase) CancelReturnRequest(ctx context.Context, userID, returnID string) (ReturnRequest, error) {
+	if _, err := pdb.getReturnRequest(ctx, `r.return_id = $1 AND r.user_id = $2`, returnID, userID); err != nil {
+		return ReturnRequest{}, err
+	}
+	return pdb.changeReturnStatus(ctx, returnID, ReturnStatusCancelled, userID, "", nil)
+}
+
+// ReviewReturnRequest ผู้ขายอนุมัติ ปฏิเสธ หรือยืนยันว่าได้รับสินค้าคืนแล้ว
+// เมื่อได้รับสินค้าคืนจะเพิ่มสต็อกของสินค้าหรือตัวเลือกกลับใน transaction เดียวกัน
+func (pdb *PostgresDatabase) ReviewReturnRequest(ctx context.Context, sellerID, returnID, actorID string, action ReturnAction) (ReturnRequest, error) {
+	target, ok := returnActionTargets[action.Action]
+	if !ok {
+		return ReturnRequest{}, invalid("invalid return action: %s", action.Action)
+	}
+	if _, err := pdb.getReturnRequest(ctx, `r.return_id = $1 AND r.seller_id = $2`, returnID, sellerID); err != nil {
+		return ReturnRequest{}, err
+	}
+
+	return pdb.changeReturnStatus(ctx, returnID, target, actorID, action.Note, func(tx *sql.Tx) error {
+		if action.Note != "" {
+			if _, err := tx.ExecContext(ctx, `UPDATE return_requests SET seller_note = $1 WHERE return_id = $2`, action.Note, returnID); err != nil {
+				return fmt.Errorf("failed to update return request: %w", err)
+			}
+		}
+		if target == ReturnStatusReceived {
+			return restockReturn(ctx, tx, returnID)
+		}
+		return nil
+	})
+}
+
+// restockReturn คืนสต็อกผ่านตาราง inventory หรือ variant_inventory ถ้าสินค้าถูกลบไปแล้วจะข้ามไป
+func restockReturn(ctx context.Context, tx *sql.Tx, returnID string) error {
+	var quantity int
+	var productID, variantID sql.NullString
+	err := tx.QueryRowContext(ctx, `
+		SELECT r.quantity, oi.product_id, oi.variant_id
+		FROM return_requests r JOIN order_items oi ON oi.order_item_id = r.order_item_id
+		WHERE r.return_id = $1
+	`, returnID).Scan(&quantity, &productID, &variantID)
+	if err != nil {
+		return fmt.Errorf("failed to get returned item: %w", err)
+	}
+
+	switch {
+	case variantID.Valid:
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO variant_inventory (variant_id, quantity)
+			SELECT variant_id, $2 FROM product_variants WHERE variant_id = $1
+			ON CONFLICT (variant_id) DO UPDATE SET quantity = variant_inventory.quantity + EXCLUDED.quantity, updated_at = NOW()
+		`, variantID.String, quantity)
+	case productID.Valid:
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO inventory (product_id, quantity)
+			SELECT product_id, $2 FROM products WHERE product_id = $1
+			ON CONFLICT (product_id) DO UPDATE SET quantity = inventory.quantity + EXCLUDED.quantity, updated_at = NOW()
+		`, productID.String, quantity)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restock returned item: %w", err)
+	}
+	return nil
+}
+
+// StartReturnRefund เปลี่ยนสถานะเป็น refund_pending ก่อนเรียกผู้ให้บริการชำระเงิน
+// แล้วผู้เรียกต้องบันทึกผลด้วย CompleteReturnRefund หรือ FailReturnRefund
+func (pdb *PostgresDatabase) StartReturnRefund(ctx context.Context, sellerID, returnID, actorID string) (ReturnRequest, error) {
+	if _, err := pdb.getReturnRequest(ctx, `r.return_id = $1 AND r.seller_id = $2`, returnID, sellerID); err != nil {
+		return ReturnRequest{}, err
+	}
+	return pdb.changeReturnStatus(ctx, returnID, ReturnStatusRefundPending, actorID, "", nil)
+}
+
+// CompleteReturnRefund บันทึกเลขอ้างอิงการคืนเงิน ถ้าทุกชิ้นในคำสั่งซื้อถูกคืนเงินแล้ว คำสั่งซื้อจะเป็น refunded
+func (pdb *PostgresDatabase) CompleteReturnRefund(ctx context.Context, returnID, actorID, reference string) (ReturnRequest, error) {
+	return pdb.changeReturnStatus(ctx, returnID, ReturnStatusRefunded, actorID, "", func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE return_requests SET refund_reference = $1 WHERE return_id = $2`, reference, returnID)
+		if err != nil {
+			return fmt.Errorf("failed to update return request: %w", err)
+		}
+		var orderID, previousStatus string
+		err = tx.QueryRowContext(ctx, `
+			WITH previous AS (
+				SELECT order_id, status FROM orders
+				WHERE order_id = (SELECT order_id FROM return_requests WHERE return_id = $1)
+				FOR UPDATE
+			)
+			UPDATE orders o SET status = 'refunded'
+			FROM previous p
+			WHERE o.order_id = p.order_id AND p.status <> 'refunded'
+			  AND NOT EXISTS (
+			      SELECT 1 FROM order_items oi
+			      WHERE oi.order_id = o.order_id
+			        AND oi.quantity > (SELECT COALESCE(SUM(r.quantity), 0) FROM return_requests r
+			                           WHERE r.order_item_id = oi.order_item_id AND r.status = 'refunded')
+			  )
+			RETURNING o.order_id, p.status
+		`, returnID).Scan(&orderID, &previousStatus)
+		if err == sql.ErrNoRows {
+			// ยังมีสินค้าในคำสั่งซื้อที่ไม่ได้คืนเงิน
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update order status: %w", err)
+		}
+		return publish(ctx, tx, OrderStatusChanged{OrderID: orderID, FromStatus: previousStatus, ToStatus: "refunded"})
+	})
+}
+
+// FailReturnRefund บันทึกว่าการคืนเงินไม่สำเร็จ ผู้ขายเริ่มคืนเงินใหม่ได้
+func (pdb *PostgresDatabase) FailReturnRefund(ctx context.Context, returnID, actorID, reason string) (ReturnRequest, error) {
+	return pdb.changeReturnStatus(ctx, returnID, ReturnStatusRefundFailed, actorID, reason, nil)
+}
+
+// changeReturnStatus ล็อกคำขอ ตรวจว่าสถานะปัจจุบันเปลี่ยนไปยัง target ได้ บันทึกประวัติ
+// และเรียก apply (ถ้ามี) ใน transaction เดียวกัน
+func (pdb *PostgresDatabase) changeReturnStatus(ctx context.Context, returnID, target, actorID, note string, apply func(*sql.Tx) error) (ReturnRequest, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ReturnRequest{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := transitionReturnStatus(ctx, tx, returnID, target, actorID, note); err != nil {
+		return ReturnRequest{}, err
+	}
+	if apply != nil {
+		if err := apply(tx); err != nil {
+			return ReturnRequest{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ReturnRequest{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pdb.getReturnRequest(ctx, `r.return_id = $1`, returnID)
+}
+
+// transitionReturnStatus ล็อกแถวของคำขอและเปลี่ยนสถานะเมื่อสถานะปัจจุบันอนุญาตเท่านั้น
+func transitionReturnStatus(ctx context.Context, tx *sql.Tx, returnID, target, actorID, note string) error {
+	var current string
+	err := tx.QueryRowContext(ctx, `
+		SELECT status FROM return_requests WHERE return_id = $1 FOR UPDATE
+	`, returnID).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return notFound("return request not found")
+		}
+		return fmt.Errorf("failed to get return status: %w", err)
+	}
+
+	if !containsID(returnStatusTransitions[target], current) {
+		return conflict("cannot change return status from %s to %s", current, target)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE return_requests SET status = $1 WHERE return_id = $2`, target, returnID)
+	if err != nil {
+		return fmt.Errorf("failed to update return status: %w", err)
+	}
+	return recordReturnStatus(ctx, tx, returnID, current, target, actorID, note)
+}
+
+func recordReturnStatus(ctx context.Context, tx *sql.Tx, returnID, from, to, actorID, note string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO return_status_history (return_id, from_status, to_status, actor_user_id, note)
+		VALUES ($1, NULLIF($2, '')::return_status, $3, $4, NULLIF($5, ''))
+	`, returnID, from, to, nullString(actorID), note)
+	if err != nil {
+		return fmt.Errorf("failed to record return status: %w", err)
+	}
+	return nil
+}
+
+const returnRequestQuery = `
+	SELECT r.return_id, r.order_id, r.order_item_id, r.seller_id, oi.product_name, COALESCE(oi.variant_name, ''),
+	       r.quantity, r.reason, COALESCE(r.details, ''), r.status, r.refund_amount,
+	       COALESCE(r.refund_reference, ''), COALESCE(r.seller_note, ''), r.created_at, r.updated_at
+	FROM return_requests r
+	JOIN order_items oi ON oi.order_item_id = r.order_item_id`
+
+func scanReturnRequest(row rowScanner) (ReturnRequest, error) {
+	var r ReturnRequest
+	err := row.Scan(&r.ID, &r.OrderID, &r.OrderItemID, &r.SellerID, &r.ProductName, &r.VariantName,
+		&r.Quantity, &r.Reason, &r.Details, &r.Status, &r.RefundAmount,
+		&r.RefundReference, &r.SellerNote, &r.CreatedAt, &r.UpdatedAt)
+	return r, err
+}
+
+func (pdb *PostgresDatabase) getReturnRequest(ctx context.Context, where string, args ...interface{}) (ReturnRequest, error) {
+	request, err := scanReturnRequest(pdb.db.QueryRowContext(ctx, returnRequestQuery+` WHERE `+where, args...))
+	if err == sql.ErrNoRows {
+		return ReturnRequest{}, notFound("return request not found")
+	}
+	if err != nil {
+		return ReturnRequest{}, fmt.Errorf("failed to get return request: %w", err)
+	}
+	if err := pdb.loadReturnDetails(ctx, &request); err != nil {
+		return ReturnRequest{}, err
+	}
+	return request, nil
+}
+
+func (pdb *PostgresDatabase) getReturnRequests(ctx context.Context, where string, args ...interface{}) ([]ReturnRequest, error) {
+	rows, err := pdb.db.QueryContext(ctx, returnRequestQuery+` WHERE `+where+` ORDER BY r.created_at DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get return requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []ReturnRequest{}
+	for rows.Next() {
+		request, err := scanReturnRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan return request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	rows.Close()
+
+	for i := range requests {
+		if err := pdb.loadReturnDetails(ctx, &requests[i]); err != nil {
+			return nil, err
+		}
+	}
+	return requests, nil
+}
+
+// loadReturnDetails ดึงรูปประกอบและประวัติสถานะของคำขอ
+func (pdb *PostgresDatabase) loadReturnDetails(ctx context.Context, request *ReturnRequest) error {
+	photoRows, err := pdb.db.QueryContext(ctx, `
+		SELECT photo_url FROM return_photos WHERE return_id = $1 ORDER BY sort_order
+	`, request.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get return photos: %w", err)
+	}
+	defer photoRows.Close()
+
+	request.Photos = []string{}
+	for photoRows.Next() {
+		var url string
+		if err := photoRows.Scan(&url); err != nil {
+			return fmt.Errorf("failed to scan return photo: %w", err)
+		}
+		request.Photos = append(request.Photos, url)
+	}
+	if err := photoRows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+	photoRows.Close()
+
+	historyRows, err := pdb.db.QueryContext(ctx, `
+		SELECT COALESCE(from_status::text, ''), to_status, COALESCE(actor_user_id::text, ''), COALESCE(note, ''), created_at
+		FROM return_status_history
+		WHERE return_id = $1
+		ORDER BY created_at, history_id
+	`, request.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get return history: %w", err)
+	}
+	defer historyRows.Close()
+
+	request.History = []ReturnStatusChange{}
+	for historyRows.Next() {
+		var change ReturnStatusChange
+		if err := historyRows.Scan(&change.FromStatus, &change.ToStatus, &change.ActorUserID, &change.Note, &change.CreatedAt); err != nil {
+			return fmt.Errorf("failed to scan return history: %w", err)
+		}
+		request.History = append(request.History, change)
+	}
+	if err := historyRows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+	return nil
+}
+
+func (pdb *PostgresDatabase) GetReturnRequests(ctx context.Context, userID string) ([]ReturnRequest, error) {
+	return pdb.getReturnRequests(ctx, `r.user_id = $1`, userID)
+}
+
+func (pdb *PostgresDatabase) GetReturnRequest(ctx context.Context, userID, returnID string) (ReturnRequest, error) {
+	return pdb.getReturnRequest(ctx, `r.return_id = $1 AND r.user_id = $2`, returnID, userID)
+}
+
+// GetShopReturnRequests คืนคำขอคืนสินค้าของร้าน กรองตามสถานะได้ (ว่างคือทั้งหมด)
+func (pdb *PostgresDatabase) GetShopReturnRequests(ctx context.Context, sellerID, status string) ([]ReturnRequest, error) {
+	return pdb.getReturnRequests(ctx, `r.seller_id = $1 AND ($2 = '' OR r.status::text = $2)`, sellerID, status)
+}
+
+func (pdb *PostgresDatabase) GetShopReturnRequest(ctx context.Context, sellerID, returnID string) (ReturnRequest, error) {
+	return pdb.getReturnRequest(ctx, `r.return_id = $1 AND r.seller_id = $2`, returnID, sellerID)
+}
+
+func (s *Store) CreateReturnRequest(ctx context.Context, userID, orderID string, request NewReturnRequest) (ReturnRequest, error) {
+	return s.db.CreateReturnRequest(ctx, userID, orderID, request)
+}
+
+func (s *Store) GetReturnRequests(ctx context.Context, userID string) ([]ReturnRequest, error) {
+	return s.db.GetReturnRequests(ctx, userID)
+}
+
+func (s *Store) GetReturnRequest(ctx context.Context, userID, returnID string) (ReturnRequest, error) {
+	return s.db.GetReturnRequest(ctx, userID, returnID)
+}
+
+func (s *Store) CancelReturnRequest(ctx context.Context, userID, returnID string) (ReturnRequest, error) {
+	return s.db.CancelReturnRequest(ctx, userID, returnID)
+}
+
+func (s *Store) GetShopReturnRequests(ctx context.Context, sellerID, status string) ([]ReturnRequest, error) {
+	return s.db.GetShopReturnRequests(ctx, sellerID, status)
+}
+
+func (s *Store) GetShopReturnRequest(ctx context.Context, sellerID, returnID string) (ReturnRequest, error) {
+	return s.db.GetShopReturnRequest(ctx, sellerID, returnID)
+}
+
+func (s *Store) ReviewReturnRequest(ctx context.Context, sellerID, returnID, actorID string, action ReturnAction) (ReturnRequest, error) {
+	return s.db.ReviewReturnRequest(ctx, sellerID, returnID, actorID, action)
+}
+
+func (s *Store) StartReturnRefund(ctx context.Context, sellerID, returnID, actorID string) (ReturnRequest, error) {
+	return s.db.StartReturnRefund(ctx, sellerID, returnID, actorID)
+}
+
+func (s *Store) CompleteReturnRefund(ctx context.Context, returnID, actorID, reference string) (ReturnRequest, error) {
+	return s.db.CompleteReturnRefund(ctx, returnID, actorID, reference)
+}
+
+func (s *Store) FailReturnRefund(ctx context.Context, returnID, actorID, reason string) (ReturnRequest, error) {
+	return s.db.FailReturnRefund(ctx, returnID, actorID, reason)
+}
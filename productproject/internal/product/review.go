@@ -0,0 +1,364 @@
+// review.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// การเรียงลำดับรีวิว
+const (
+	ReviewSortRecent     = "recent"
+	ReviewSortHelpful    = "helpful"
+	ReviewSortRatingHigh = "rating_high"
+	ReviewSortRatingLow  = "rating_low"
+)
+
+type Review struct {
+	ID              string     `json:"id"`
+	ProductID       string     `json:"product_id"`
+	UserID          string     `json:"user_id"`
+	ReviewerName    string     `json:"reviewer_name"`
+	Rating          int        `json:"rating"` // 1-5
+	Body            string     `json:"body"`
+	Images          []string   `json:"images"`
+	SellerReply     string     `json:"seller_reply"`
+	SellerRepliedAt *time.Time `json:"seller_replied_at"`
+	HelpfulCount    int        `json:"helpful_count"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// NewReview คือรีวิวที่ผู้ซื้อส่งมา รูปใช้กฎเดียวกับรูปสินค้า
+type NewReview struct {
+	Rating int      `json:"rating"`
+	Body   string   `json:"body" binding:"max=2000"`
+	Images []string `json:"images" binding:"dive,http_url,max=255"`
+}
+
+type ReviewReply struct {
+	Reply string `json:"reply"`
+}
+
+type ReviewQueryParams struct {
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
+	Sort  string `json:"sort"` // 'recent', 'helpful', 'rating_high', 'rating_low'
+}
+
+type ReviewResponse struct {
+	Items         []Review `json:"items"`
+	Page          int      `json:"page"`
+	Limit         int      `json:"limit"`
+	Total         int      `json:"total"`
+	RatingAverage float64  `json:"rating_average"`
+	RatingCount   int      `json:"rating_count"`
+}
+
+const reviewSelectQuery = `
+	SELECT r.review_id, r.product_id, r.user_id, COALESCE(u.full_name, ''), r.rating, COALESCE(r.body, ''),
+	       ARRAY(SELECT ri.image_url FROM review_images ri WHERE ri.review_id = r.review_id ORDER BY ri.sort_order),
+	       COALESCE(r.seller_reply, ''), r.seller_replied_at, r.helpful_count, r.created_at, r.updated_at
+	FROM product_reviews r
+	LEFT JOIN users u ON u.user_id = r.user_id`
+
+// HasDeliveredOrder ตรวจสอบว่าผู้ใช้เคยได้รับสินค้านี้จากคำสั่งซื้อที่จัดส่งสำเร็จแล้ว
+func (pdb *PostgresDatabase) HasDeliveredOrder(ctx context.Context, userID, productID string) (bool, error) {
+	var exists bool
+	err := pdb.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM order_items oi
+			JOIN orders o ON o.order_id = oi.order_id
+			WHERE oi.product_id = $1 AND o.user_id = $2 AND o.status = 'delivered'
+		)
+	`, productID, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check delivered orders: %w", err)
+	}
+	return exists, nil
+}
+
+func (pdb *PostgresDatabase) GetProductReviews(ctx context.Context, productID string, params ReviewQueryParams) (*ReviewResponse, error) {
+	sortOrders := map[string]string{
+		ReviewSortRecent:     "r.created_at DESC",
+		ReviewSortHelpful:    "r.helpful_count DESC, r.created_at DESC",
+		ReviewSortRatingHigh: "r.rating DESC, r.created_at DESC",
+		ReviewSortRatingLow:  "r.rating ASC, r.created_at DESC",
+	}
+	orderBy, ok := sortOrders[params.Sort]
+	if !ok {
+		orderBy = sortOrders[ReviewSortRecent]
+	}
+
+	limit := 10
+	if params.Limit > 0 && params.Limit <= 50 {
+		limit = params.Limit
+	}
+	page := 1
+	if params.Page > 0 {
+		page = params.Page
+	}
+
+	response := &ReviewResponse{Items: []Review{}, Page: page, Limit: limit}
+
+	// ดึงคะแนนรวมจากตาราง products พร้อมจำนวนรีวิวทั้งหมด
+	err := pdb.db.QueryRowContext(ctx, `
+		SELECT p.rating_average, p.rating_count,
+		       (SELECT COUNT(*) FROM product_reviews r WHERE r.product_id = p.product_id)
+		FROM products p
+		WHERE p.product_id = $1
+	`, productID).Scan(&response.RatingAverage, &response.RatingCount, &response.Total)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound("product not found")
+		}
+		return nil, fmt.Errorf("failed to get product rating: %w", err)
+	}
+
+	rows, err := pdb.db.QueryContext(ctx, reviewSelectQuery+`
+		WHERE r.product_id = $1
+		ORDER BY `+orderBy+`, r.review_id
+		LIMIT $2 OFFSET $3
+	`, productID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product reviews: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan review: %w", err)
+		}
+		response.Items = append(response.Items, review)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over reviews: %w", err)
+	}
+
+	return response, nil
+}
+
+func (pdb *PostgresDatabase) GetReview(ctx context.Context, productID, reviewID string) (Review, error) {
+	review, err := scanReview(pdb.db.QueryRowContext(ctx, reviewSelectQuery+`
+		WHERE r.product_id = $1 AND r.review_id = $2
+	`, productID, reviewID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Review{}, notFound("review not found")
+		}
+		return Review{}, fmt.Errorf("failed to get review: %w", err)
+	}
+	return review, nil
+}
+
+// AddReview เพิ่มรีวิวใหม่ หรือแก้ไขรีวิวเดิมของผู้ใช้สำหรับสินค้านี้
+func (pdb *PostgresDatabase) AddReview(ctx context.Context, productID, userID string, review NewReview) (Review, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var reviewID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO product_reviews (product_id, user_id, rating, body)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (product_id, user_id) DO UPDATE
+		SET rating = EXCLUDED.rating, body = EXCLUDED.body
+		RETURNING review_id
+	`, productID, userID, review.Rating, review.Body).Scan(&reviewID)
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to add review: %w", err)
+	}
+
+	// รูปภาพของรีวิวถูกแทนที่ทั้งชุดทุกครั้ง
+	if _, err := tx.ExecContext(ctx, `DELETE FROM review_images WHERE review_id = $1`, reviewID); err != nil {
+		return Review{}, fmt.Errorf("failed to replace review images: %w", err)
+	}
+	for i, imageURL := range review.Images {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO review_images (review_id, image_url, sort_order)
+			VALUES ($1, $2, $3)
+		`, reviewID, imageURL, i)
+		if err != nil {
+			return Review{}, fmt.Errorf("failed to add review image: %w", err)
+		}
+	}
+
+	if err := refreshProductRating(ctx, tx, productID); err != nil {
+		return Review{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Review{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return pdb.GetReview(ctx, productID, reviewID)
+}
+
+func (pdb *PostgresDatabase) ReplyToReview(ctx context.Context, productID, reviewID string, reply ReviewReply) (Review, error) {
+	result, err := pdb.db.ExecContext(ctx, `
+		UPDATE product_reviews
+		SET seller_reply = $1, seller_replied_at = NOW()
+		WHERE product_id = $2 AND review_id = $3
+	`, reply.Reply, productID, reviewID)
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to reply to review: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return Review{}, notFound("review not found")
+	}
+
+	return pdb.GetReview(ctx, productID, reviewID)
+}
+
+// VoteReviewHelpful บันทึกโหวตของผู้ใช้ (โหวตซ้ำจะไม่ถูกนับเพิ่ม)
+func (pdb *PostgresDatabase) VoteReviewHelpful(ctx context.Context, productID, reviewID, userID string) (Review, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO review_helpful_votes (review_id, user_id)
+		SELECT review_id, $3 FROM product_reviews WHERE product_id = $1 AND review_id = $2
+		ON CONFLICT (review_id, user_id) DO NOTHING
+	`, productID, reviewID, userID)
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to vote review: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE product_reviews SET helpful_count = helpful_count + 1 WHERE review_id = $1
+		`, reviewID)
+		if err != nil {
+			return Review{}, fmt.Errorf("failed to update helpful count: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Review{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return pdb.GetReview(ctx, productID, reviewID)
+}
+
+func (pdb *PostgresDatabase) UnvoteReviewHelpful(ctx context.Context, productID, reviewID, userID string) (Review, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM review_helpful_votes v
+		USING product_reviews r
+		WHERE v.review_id = r.review_id AND r.product_id = $1 AND r.review_id = $2 AND v.user_id = $3
+	`, productID, reviewID, userID)
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to remove review vote: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE product_reviews SET helpful_count = GREATEST(helpful_count - 1, 0) WHERE review_id = $1
+		`, reviewID)
+		if err != nil {
+			return Review{}, fmt.Errorf("failed to update helpful count: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Review{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return pdb.GetReview(ctx, productID, reviewID)
+}
+
+// refreshProductRating คำนวณคะแนนเฉลี่ยใหม่ภายใน transaction เดียวกับการเขียนรีวิว
+func refreshProductRating(ctx context.Context, tx *sql.Tx, productID string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE products p
+		SET rating_average = COALESCE(r.average, 0), rating_count = r.count
+		FROM (
+			SELECT ROUND(AVG(rating), 2) AS average, COUNT(*) AS count
+			FROM product_reviews
+			WHERE product_id = $1
+		) r
+		WHERE p.product_id = $1
+	`, productID)
+	if err != nil {
+		return fmt.Errorf("failed to refresh product rating: %w", err)
+	}
+	return nil
+}
+
+func scanReview(row rowScanner) (Review, error) {
+	var review Review
+	var repliedAt sql.NullTime
+	err := row.Scan(
+		&review.ID, &review.ProductID, &review.UserID, &review.ReviewerName, &review.Rating, &review.Body,
+		pq.Array(&review.Images), &review.SellerReply, &repliedAt, &review.HelpfulCount,
+		&review.CreatedAt, &review.UpdatedAt,
+	)
+	if err != nil {
+		return Review{}, err
+	}
+	if repliedAt.Valid {
+		review.SellerRepliedAt = &repliedAt.Time
+	}
+	if review.Images == nil {
+		review.Images = []string{}
+	}
+	return review, nil
+}
+
+func (s *Store) HasDeliveredOrder(ctx context.Context, userID, productID string) (bool, error) {
+	return s.db.HasDeliveredOrder(ctx, userID, productID)
+}
+
+func (s *Store) GetProductReviews(ctx context.Context, productID string, params ReviewQueryParams) (*ReviewResponse, error) {
+	return s.db.GetProductReviews(ctx, productID, params)
+}
+
+func (s *Store) GetReview(ctx context.Context, productID, reviewID string) (Review, error) {
+	return s.db.GetReview(ctx, productID, reviewID)
+}
+
+func (s *Store) AddReview(ctx context.Context, productID, userID string, review NewReview) (Review, error) {
+	return s.db.AddReview(ctx, productID, userID, review)
+}
+
+func (s *Store) ReplyToReview(ctx context.Context, productID, reviewID string, reply ReviewReply) (Review, error) {
+	return s.db.ReplyToReview(ctx, productID, reviewID, reply)
+}
+
+func (s *Store) VoteReviewHelpful(ctx context.Context, productID, reviewID, userID string) (Review, error) {
+	return s.db.VoteReviewHelpful(ctx, productID, reviewID, userID)
+}
+
+func (s *Store) UnvoteReviewHelpful(ctx context.Context, productID, reviewID, userID string) (Review, error) {
+	return s.db.UnvoteReviewHelpful(ctx, productID, reviewID, userID)
+}
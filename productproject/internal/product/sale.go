@@ -0,0 +1,230 @@
+// sale.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ProductSale คือราคาลดของสินค้าในช่วงเวลาหนึ่ง มีผลเองตาม starts_at และ ends_at โดยไม่ต้องเปิดหรือปิดด้วยมือ
+// ถ้ามี QuantityLimit จะเป็น flash sale ที่หยุดเมื่อขายครบจำนวน
+// ราคาลดใช้แทนราคาหลักของสินค้าเท่านั้น ตัวเลือกสินค้าที่กำหนดราคาเองยังใช้ราคาของตัวเลือก
+type ProductSale struct {
+	ID            string    `json:"sale_id"`
+	ProductID     string    `json:"product_id"`
+	SalePrice     Money     `json:"sale_price"`
+	StartsAt      time.Time `json:"starts_at"`
+	EndsAt        time.Time `json:"ends_at"`
+	QuantityLimit *int      `json:"quantity_limit,omitempty"`
+	SoldQuantity  int       `json:"sold_quantity"`
+	Status        string    `json:"status"` // 'scheduled', 'active', 'sold_out', 'ended'
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type NewProductSale struct {
+	SalePrice     Money      `json:"sale_price" binding:"gt=0,lte=99999999.99"`
+	StartsAt      *time.Time `json:"starts_at"` // ค่าเริ่มต้นคือเวลาปัจจุบัน
+	EndsAt        time.Time  `json:"ends_at" binding:"required"`
+	QuantityLimit *int       `json:"quantity_limit" binding:"omitempty,gt=0"` // กำหนดเมื่อเป็น flash sale
+}
+
+// Validate ตรวจ binding tag และช่วงเวลาของราคาลด
+func (s NewProductSale) Validate(now time.Time) error {
+	if err := ValidateStruct(s); err != nil {
+		return err
+	}
+
+	var fields []FieldError
+	startsAt := now
+	if s.StartsAt != nil {
+		startsAt = *s.StartsAt
+	}
+	if !s.EndsAt.After(startsAt) {
+		fields = append(fields, FieldError{Field: "ends_at", Message: "must be after starts_at"})
+	} else if !s.EndsAt.After(now) {
+		fields = append(fields, FieldError{Field: "ends_at", Message: "must be in the future"})
+	}
+	if len(fields) > 0 {
+		return invalidFields(fields...)
+	}
+	return nil
+}
+
+const productSaleColumns = `sale_id, product_id, sale_price, starts_at, ends_at, quantity_limit, sold_quantity,
+		       CASE
+		           WHEN ends_at <= NOW() THEN 'ended'
+		           WHEN starts_at > NOW() THEN 'scheduled'
+		           WHEN quantity_limit IS NOT NULL AND sold_quantity >= quantity_limit THEN 'sold_out'
+		           ELSE 'active'
+		       END,
+		       created_at`
+
+func scanProductSale(row rowScanner) (ProductSale, error) {
+	var sale ProductSale
+	err := row.Scan(&sale.ID, &sale.ProductID, &sale.SalePrice, &sale.StartsAt, &sale.EndsAt,
+		&sale.QuantityLimit, &sale.SoldQuantity, &sale.Status, &sale.CreatedAt)
+	return sale, err
+}
+
+// applySales แสดงราคาลดของสินค้าที่อยู่ในช่วงลดราคา Price จะเป็นราคาลดและ OriginalPrice เป็นราคาปกติ
+func (pdb *PostgresDatabase) applySales(ctx context.Context, products []ProductItem) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT product_id, sale_price, ends_at, quantity_limit - sold_quantity
+		FROM active_product_sales
+		WHERE product_id = ANY($1::uuid[])
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get product sales: %w", err)
+	}
+	defer rows.Close()
+
+	type activeSale struct {
+		price        Money
+		endsAt       time.Time
+		quantityLeft *int
+	}
+	sales := map[string]activeSale{}
+	for rows.Next() {
+		var id string
+		var sale activeSale
+		if err := rows.Scan(&id, &sale.price, &sale.endsAt, &sale.quantityLeft); err != nil {
+			return fmt.Errorf("failed to scan product sale: %w", err)
+		}
+		sales[id] = sale
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	for i := range products {
+		sale, ok := sales[products[i].ID]
+		if !ok {
+			continue
+		}
+		original, salePrice, endsAt := products[i].Price, sale.price, sale.endsAt
+		products[i].OriginalPrice = &original
+		products[i].SalePrice = &salePrice
+		products[i].SaleEndsAt = &endsAt
+		products[i].SaleQuantityLeft = sale.quantityLeft
+		products[i].Price = sale.price
+	}
+	return nil
+}
+
+// GetProductSales คืนราคาลดทั้งหมดของสินค้า รวมที่ยังไม่เริ่มและที่หมดเวลาแล้ว
+func (pdb *PostgresDatabase) GetProductSales(ctx context.Context, productID string) ([]ProductSale, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT `+productSaleColumns+`
+		FROM product_sales
+		WHERE product_id = $1
+		ORDER BY starts_at DESC
+	`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product sales: %w", err)
+	}
+	defer rows.Close()
+
+	sales := []ProductSale{}
+	for rows.Next() {
+		sale, err := scanProductSale(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product sale: %w", err)
+		}
+		sales = append(sales, sale)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return sales, nil
+}
+
+// CreateProductSale ราคาลดต้องต่ำกว่าราคาปกติและต้องไม่ซ้อนช่วงเวลากับราคาลดอื่นของสินค้าเดียวกัน
+func (pdb *PostgresDatabase) CreateProductSale(ctx context.Context, productID string, sale NewProductSale) (ProductSale, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ProductSale{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var price Money
+	err = tx.QueryRowContext(ctx, `SELECT price FROM products WHERE product_id = $1 FOR UPDATE`, productID).Scan(&price)
+	if err == sql.ErrNoRows {
+		return ProductSale{}, notFound("product not found")
+	}
+	if err != nil {
+		return ProductSale{}, fmt.Errorf("failed to get product: %w", err)
+	}
+	if sale.SalePrice.Cmp(price) >= 0 {
+		return ProductSale{}, invalidFields(FieldError{Field: "sale_price", Message: "must be lower than the regular price " + price.String()})
+	}
+
+	var overlapping int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM product_sales
+		WHERE product_id = $1 AND tstzrange(starts_at, ends_at) && tstzrange(COALESCE($2, NOW()), $3)
+	`, productID, sale.StartsAt, sale.EndsAt).Scan(&overlapping)
+	if err != nil {
+		return ProductSale{}, fmt.Errorf("failed to check product sales: %w", err)
+	}
+	if overlapping > 0 {
+		return ProductSale{}, conflict("product already has a sale during this period")
+	}
+
+	created, err := scanProductSale(tx.QueryRowContext(ctx, `
+		INSERT INTO product_sales (product_id, sale_price, starts_at, ends_at, quantity_limit)
+		VALUES ($1, $2, COALESCE($3, NOW()), $4, $5)
+		RETURNING `+productSaleColumns,
+		productID, sale.SalePrice, sale.StartsAt, sale.EndsAt, sale.QuantityLimit))
+	if err != nil {
+		return ProductSale{}, fmt.Errorf("failed to create product sale: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ProductSale{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return created, nil
+}
+
+// DeleteProductSale ยกเลิกราคาลด ถ้ากำลังลดราคาอยู่สินค้าจะกลับเป็นราคาปกติทันที
+func (pdb *PostgresDatabase) DeleteProductSale(ctx context.Context, productID, saleID string) error {
+	result, err := pdb.db.ExecContext(ctx, `
+		DELETE FROM product_sales WHERE product_id = $1 AND sale_id = $2
+	`, productID, saleID)
+	if err != nil {
+		return fmt.Errorf("failed to delete product sale: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return notFound("product sale not found")
+	}
+	return nil
+}
+
+func (s *Store) GetProductSales(ctx context.Context, productID string) ([]ProductSale, error) {
+	return s.db.GetProductSales(ctx, productID)
+}
+
+func (s *Store) CreateProductSale(ctx context.Context, productID string, sale NewProductSale) (ProductSale, error) {
+	return s.db.CreateProductSale(ctx, productID, sale)
+}
+
+func (s *Store) DeleteProductSale(ctx context.Context, productID, saleID string) error {
+	return s.db.DeleteProductSale(ctx, productID, saleID)
+}
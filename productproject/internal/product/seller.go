@@ -0,0 +1,343 @@
+// seller.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// สถานะการยืนยันตัวตนของผู้ขาย ตรงกับ ENUM seller_verification_status
+const (
+	SellerStatusApplied            = "applied"
+	SellerStatusDocumentsSubmitted = "documents_submitted"
+	SellerStatusInReview           = "in_review"
+	SellerStatusApproved           = "approved"
+	SellerStatusRejected           = "rejected"
+)
+
+// การกระทำของผู้ดูแลระบบเมื่อตรวจสอบใบสมัคร
+const (
+	SellerReviewStart   = "start"
+	SellerReviewApprove = "approve"
+	SellerReviewReject  = "reject"
+)
+
+// sellerStatusTransitions เก็บสถานะปลายทาง -> สถานะต้นทางที่อนุญาต
+var sellerStatusTransitions = map[string][]string{
+	SellerStatusDocumentsSubmitted: {SellerStatusApplied, SellerStatusDocumentsSubmitted, SellerStatusRejected},
+	SellerStatusInReview:           {SellerStatusDocumentsSubmitted},
+	SellerStatusApproved:           {SellerStatusDocumentsSubmitted, SellerStatusInReview},
+	SellerStatusRejected:           {SellerStatusDocumentsSubmitted, SellerStatusInReview},
+}
+
+var sellerReviewTargets = map[string]string{
+	SellerReviewStart:   SellerStatusInReview,
+	SellerReviewApprove: SellerStatusApproved,
+	SellerReviewReject:  SellerStatusRejected,
+}
+
+type SellerApplication struct {
+	Name        string `json:"name"`
+	Logo        string `json:"logo"`
+	Description string `json:"description"`
+	Address     string `json:"address"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email"`
+}
+
+type SellerDocument struct {
+	ID           string    `json:"id"`
+	SellerID     string    `json:"seller_id"`
+	DocumentType string    `json:"document_type"` // เช่น 'id_card', 'business_registration', 'bank_book'
+	DocumentURL  string    `json:"document_url"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type NewSellerDocument struct {
+	DocumentType string `json:"document_type"`
+	DocumentURL  string `json:"document_url"`
+}
+
+type SellerReview struct {
+	Action string `json:"action"` // 'start', 'approve', 'reject'
+	Reason string `json:"reason"` // จำเป็นเมื่อ action เป็น 'reject'
+}
+
+type SellerApplicationStatus struct {
+	Seller
+	OwnerUserID     string           `json:"owner_user_id"`
+	RejectionReason string           `json:"rejection_reason"`
+	SubmittedAt     *time.Time       `json:"submitted_at"`
+	ReviewedAt      *time.Time       `json:"reviewed_at"`
+	Documents       []SellerDocument `json:"documents"`
+}
+
+func (pdb *PostgresDatabase) ApplySeller(ctx context.Context, ownerUserID string, application SellerApplication) (SellerApplicationStatus, error) {
+	var sellerID string
+	err := pdb.db.QueryRowContext(ctx, `
+		INSERT INTO sellers (name, logo, description, address, phone, email, owner_user_id, verification_status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, 'applied')
+		RETURNING seller_id
+	`, application.Name, application.Logo, application.Description, application.Address,
+		application.Phone, application.Email, ownerUserID,
+	).Scan(&sellerID)
+	if err != nil {
+		return SellerApplicationStatus{}, fmt.Errorf("failed to create seller application: %w", err)
+	}
+
+	return pdb.GetSellerApplication(ctx, sellerID)
+}
+
+func (pdb *PostgresDatabase) GetSellerApplication(ctx context.Context, sellerID string) (SellerApplicationStatus, error) {
+	application, err := scanSellerApplication(pdb.db.QueryRowContext(ctx, sellerApplicationQuery+`
+		WHERE seller_id = $1
+	`, sellerID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return SellerApplicationStatus{}, notFound("shop not found")
+		}
+		return SellerApplicationStatus{}, fmt.Errorf("failed to get seller application: %w", err)
+	}
+
+	application.Documents, err = pdb.getSellerDocuments(ctx, sellerID)
+	if err != nil {
+		return SellerApplicationStatus{}, err
+	}
+
+	return application, nil
+}
+
+func (pdb *PostgresDatabase) SubmitSellerDocuments(ctx context.Context, sellerID string, documents []NewSellerDocument) (SellerApplicationStatus, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return SellerApplicationStatus{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := transitionSellerStatus(ctx, tx, sellerID, SellerStatusDocumentsSubmitted); err != nil {
+		return SellerApplicationStatus{}, err
+	}
+
+	for _, document := range documents {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO seller_documents (seller_id, document_type, document_url)
+			VALUES ($1, $2, $3)
+		`, sellerID, document.DocumentType, document.DocumentURL)
+		if err != nil {
+			return SellerApplicationStatus{}, fmt.Errorf("failed to add seller document: %w", err)
+		}
+	}
+
+	// ส่งเอกสารใหม่จะล้างเหตุผลการปฏิเสธครั้งก่อน
+	_, err = tx.ExecContext(ctx, `
+		UPDATE sellers SET submitted_at = NOW(), rejection_reason = NULL
+		WHERE seller_id = $1
+	`, sellerID)
+	if err != nil {
+		return SellerApplicationStatus{}, fmt.Errorf("failed to update seller application: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return SellerApplicationStatus{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return pdb.GetSellerApplication(ctx, sellerID)
+}
+
+func (pdb *PostgresDatabase) GetSellerReviewQueue(ctx context.Context) ([]SellerApplicationStatus, error) {
+	rows, err := pdb.db.QueryContext(ctx, sellerApplicationQuery+`
+		WHERE verification_status IN ('documents_submitted', 'in_review')
+		ORDER BY submitted_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller review queue: %w", err)
+	}
+	defer rows.Close()
+
+	applications := []SellerApplicationStatus{}
+	for rows.Next() {
+		application, err := scanSellerApplication(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan seller application: %w", err)
+		}
+		applications = append(applications, application)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	// ดึงเอกสารหลังปิด rows เพื่อไม่ให้ถือ connection ซ้อนกัน
+	for i := range applications {
+		applications[i].Documents, err = pdb.getSellerDocuments(ctx, applications[i].SellerID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return applications, nil
+}
+
+func (pdb *PostgresDatabase) ReviewSeller(ctx context.Context, sellerID, reviewerID string, review SellerReview) (SellerApplicationStatus, error) {
+	target, ok := sellerReviewTargets[review.Action]
+	if !ok {
+		return SellerApplicationStatus{}, invalid("invalid review action: %s", review.Action)
+	}
+
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return SellerApplicationStatus{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := transitionSellerStatus(ctx, tx, sellerID, target); err != nil {
+		return SellerApplicationStatus{}, err
+	}
+
+	switch target {
+	case SellerStatusInReview:
+		_, err = tx.ExecContext(ctx, `UPDATE sellers SET reviewed_by = $1 WHERE seller_id = $2`, reviewerID, sellerID)
+	case SellerStatusApproved:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE sellers SET reviewed_by = $1, reviewed_at = NOW(), rejection_reason = NULL
+			WHERE seller_id = $2
+		`, reviewerID, sellerID)
+		if err == nil {
+			// เจ้าของร้านที่ผ่านการอนุมัติจะได้รับบทบาท seller
+			_, err = tx.ExecContext(ctx, `
+				UPDATE users SET role = 'seller'
+				WHERE user_id = (SELECT owner_user_id FROM sellers WHERE seller_id = $1) AND role = 'customer'
+			`, sellerID)
+		}
+	case SellerStatusRejected:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE sellers SET reviewed_by = $1, reviewed_at = NOW(), rejection_reason = $2
+			WHERE seller_id = $3
+		`, reviewerID, review.Reason, sellerID)
+	}
+	if err != nil {
+		return SellerApplicationStatus{}, fmt.Errorf("failed to review seller: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return SellerApplicationStatus{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return pdb.GetSellerApplication(ctx, sellerID)
+}
+
+const sellerApplicationQuery = `
+	SELECT seller_id, name, created_at, updated_at,
+	       COALESCE(logo, ''), COALESCE(description, ''), COALESCE(address, ''),
+	       COALESCE(phone, ''), COALESCE(email, ''), verification_status, vat_registered,
+	       COALESCE(owner_user_id::text, ''), COALESCE(rejection_reason, ''),
+	       submitted_at, reviewed_at
+	FROM sellers`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSellerApplication(row rowScanner) (SellerApplicationStatus, error) {
+	var application SellerApplicationStatus
+	var submittedAt, reviewedAt sql.NullTime
+	err := row.Scan(
+		&application.SellerID, &application.Name, &application.CreatedAt, &application.UpdatedAt,
+		&application.Logo, &application.Description, &application.Address,
+		&application.Phone, &application.Email, &application.VerificationStatus, &application.VATRegistered,
+		&application.OwnerUserID, &application.RejectionReason,
+		&submittedAt, &reviewedAt,
+	)
+	if err != nil {
+		return SellerApplicationStatus{}, err
+	}
+	if submittedAt.Valid {
+		application.SubmittedAt = &submittedAt.Time
+	}
+	if reviewedAt.Valid {
+		application.ReviewedAt = &reviewedAt.Time
+	}
+	return application, nil
+}
+
+func (pdb *PostgresDatabase) getSellerDocuments(ctx context.Context, sellerID string) ([]SellerDocument, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT document_id, seller_id, document_type, document_url, created_at
+		FROM seller_documents
+		WHERE seller_id = $1
+		ORDER BY created_at ASC
+	`, sellerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seller documents: %w", err)
+	}
+	defer rows.Close()
+
+	documents := []SellerDocument{}
+	for rows.Next() {
+		var document SellerDocument
+		if err := rows.Scan(&document.ID, &document.SellerID, &document.DocumentType,
+			&document.DocumentURL, &document.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan seller document: %w", err)
+		}
+		documents = append(documents, document)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over seller documents: %w", err)
+	}
+
+	return documents, nil
+}
+
+// transitionSellerStatus ล็อกแถวของผู้ขายและเปลี่ยนสถานะเมื่อสถานะปัจจุบันอนุญาตเท่านั้น
+func transitionSellerStatus(ctx context.Context, tx *sql.Tx, sellerID, target string) error {
+	var current string
+	err := tx.QueryRowContext(ctx, `
+		SELECT verification_status FROM sellers WHERE seller_id = $1 FOR UPDATE
+	`, sellerID).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return notFound("shop not found")
+		}
+		return fmt.Errorf("failed to get seller status: %w", err)
+	}
+
+	allowed := false
+	for _, from := range sellerStatusTransitions[target] {
+		if from == current {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return conflict("cannot change shop status from %s to %s", current, target)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE sellers SET verification_status = $1 WHERE seller_id = $2`, target, sellerID)
+	if err != nil {
+		return fmt.Errorf("failed to update seller status: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) ApplySeller(ctx context.Context, ownerUserID string, application SellerApplication) (SellerApplicationStatus, error) {
+	return s.db.ApplySeller(ctx, ownerUserID, application)
+}
+
+func (s *Store) GetSellerApplication(ctx context.Context, sellerID string) (SellerApplicationStatus, error) {
+	return s.db.GetSellerApplication(ctx, sellerID)
+}
+
+func (s *Store) SubmitSellerDocuments(ctx context.Context, sellerID string, documents []NewSellerDocument) (SellerApplicationStatus, error) {
+	return s.db.SubmitSellerDocuments(ctx, sellerID, documents)
+}
+
+func (s *Store) GetSellerReviewQueue(ctx context.Context) ([]SellerApplicationStatus, error) {
+	return s.db.GetSellerReviewQueue(ctx)
+}
+
+func (s *Store) ReviewSeller(ctx context.Context, sellerID, reviewerID string, review SellerReview) (SellerApplicationStatus, error) {
+	return s.db.ReviewSeller(ctx, sellerID, reviewerID, review)
+}
@@ -0,0 +1,204 @@
+// shipment.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// สถานะการจัดส่งของแต่ละร้าน ตรงกับ ENUM shipment_status
+const (
+	ShipmentStatusPending   = "pending"
+	ShipmentStatusShipped   = "shipped"
+	ShipmentStatusDelivered = "delivered"
+)
+
+// shipmentStatusTransitions เก็บสถานะปลายทาง -> สถานะต้นทางที่อนุญาต
+// shipped ซ้ำได้เพื่อแก้ไขขนส่งหรือเลขพัสดุที่กรอกผิด
+var shipmentStatusTransitions = map[string][]string{
+	ShipmentStatusShipped:   {ShipmentStatusPending, ShipmentStatusShipped},
+	ShipmentStatusDelivered: {ShipmentStatusShipped},
+}
+
+// ShipmentUpdate คือการแจ้งสถานะการจัดส่งของร้าน
+type ShipmentUpdate struct {
+	Status         string `json:"status"`                            // 'shipped', 'delivered'
+	Carrier        string `json:"carrier" binding:"max=100"`         // จำเป็นเมื่อ status เป็น 'shipped'
+	TrackingNumber string `json:"tracking_number" binding:"max=100"` // จำเป็นเมื่อ status เป็น 'shipped'
+}
+
+func (u ShipmentUpdate) Validate() error {
+	if err := ValidateStruct(u); err != nil {
+		return err
+	}
+	if _, ok := shipmentStatusTransitions[u.Status]; !ok {
+		return invalidFields(FieldError{Field: "status", Message: "must be one of shipped, delivered"})
+	}
+	if u.Status == ShipmentStatusShipped {
+		var fields []FieldError
+		if strings.TrimSpace(u.Carrier) == "" {
+			fields = append(fields, FieldError{Field: "carrier", Message: "is required when status is shipped"})
+		}
+		if strings.TrimSpace(u.TrackingNumber) == "" {
+			fields = append(fields, FieldError{Field: "tracking_number", Message: "is required when status is shipped"})
+		}
+		if len(fields) > 0 {
+			return invalidFields(fields...)
+		}
+	}
+	return nil
+}
+
+// UpdateShipment ร้านแจ้งว่าส่งพัสดุแล้วหรือลูกค้าได้รับแล้ว ทำได้หลังชำระเงินเท่านั้น
+// สถานะของคำสั่งซื้อเป็น shipped เมื่อทุกร้านส่งแล้ว และ delivered เมื่อทุกร้านส่งถึงแล้ว
+// อีเมลแจ้งลูกค้าถูกเขียนลง outbox ใน transaction เดียวกัน
+func (pdb *PostgresDatabase) UpdateShipment(ctx context.Context, sellerID, orderID string, update ShipmentUpdate) (OrderShipment, error) {
+	targets, ok := shipmentStatusTransitions[update.Status]
+	if !ok {
+		return OrderShipment{}, invalid("invalid shipment status: %s", update.Status)
+	}
+
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return OrderShipment{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var orderStatus string
+	var userID sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT status, user_id FROM orders WHERE order_id = $1 FOR UPDATE
+	`, orderID).Scan(&orderStatus, &userID)
+	if err == sql.ErrNoRows {
+		return OrderShipment{}, notFound("order not found")
+	}
+	if err != nil {
+		return OrderShipment{}, fmt.Errorf("failed to get order: %w", err)
+	}
+	if orderStatus != "paid" && orderStatus != "shipped" {
+		return OrderShipment{}, conflict("cannot update shipment of a %s order", orderStatus)
+	}
+
+	var current string
+	err = tx.QueryRowContext(ctx, `
+		SELECT status FROM order_shipments WHERE order_id = $1 AND seller_id = $2
+	`, orderID, sellerID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return OrderShipment{}, notFound("shipment not found")
+	}
+	if err != nil {
+		return OrderShipment{}, fmt.Errorf("failed to get shipment: %w", err)
+	}
+	if !containsID(targets, current) {
+		return OrderShipment{}, conflict("cannot change shipment status from %s to %s", current, update.Status)
+	}
+
+	if update.Status == ShipmentStatusShipped {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE order_shipments
+			SET status = 'shipped', carrier = $3, tracking_number = $4, shipped_at = COALESCE(shipped_at, NOW())
+			WHERE order_id = $1 AND seller_id = $2
+		`, orderID, sellerID, strings.TrimSpace(update.Carrier), strings.TrimSpace(update.TrackingNumber))
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE order_shipments SET status = 'delivered', delivered_at = NOW()
+			WHERE order_id = $1 AND seller_id = $2
+		`, orderID, sellerID)
+	}
+	if err != nil {
+		return OrderShipment{}, fmt.Errorf("failed to update shipment: %w", err)
+	}
+
+	var newStatus string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE orders
+		SET status = CASE WHEN s.all_delivered THEN 'delivered'::order_status
+		                  WHEN s.all_shipped THEN 'shipped'::order_status
+		                  ELSE orders.status END,
+		    delivered_at = CASE WHEN s.all_delivered THEN NOW() ELSE orders.delivered_at END
+		FROM (
+			SELECT bool_and(status = 'delivered') AS all_delivered, bool_and(status <> 'pending') AS all_shipped
+			FROM order_shipments WHERE order_id = $1
+		) s
+		WHERE orders.order_id = $1
+		RETURNING orders.status
+	`, orderID).Scan(&newStatus)
+	if err != nil {
+		return OrderShipment{}, fmt.Errorf("failed to update order status: %w", err)
+	}
+	if newStatus != orderStatus {
+		if err := publish(ctx, tx, OrderStatusChanged{OrderID: orderID, FromStatus: orderStatus, ToStatus: newStatus}); err != nil {
+			return OrderShipment{}, err
+		}
+	}
+
+	// คำสั่งซื้อของผู้ใช้ที่ลบบัญชีไปแล้วไม่มีผู้รับอีเมล
+	if userID.Valid {
+		if err := enqueueShippingUpdate(ctx, tx, userID.String, orderID, sellerID); err != nil {
+			return OrderShipment{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return OrderShipment{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	shipment, err := scanOrderShipment(pdb.db.QueryRowContext(ctx, `
+		SELECT `+orderShipmentColumns+` FROM order_shipments WHERE order_id = $1 AND seller_id = $2
+	`, orderID, sellerID))
+	if err != nil {
+		return OrderShipment{}, fmt.Errorf("failed to get shipment: %w", err)
+	}
+	return shipment, nil
+}
+
+// enqueueShippingUpdate เขียนอีเมลแจ้งสถานะการจัดส่งตามสถานะล่าสุดของร้าน พร้อมรายการสินค้าของร้านนั้น
+func enqueueShippingUpdate(ctx context.Context, tx *sql.Tx, userID, orderID, sellerID string) error {
+	var email, locale string
+	payload := ShipmentEmail{OrderID: orderID}
+	err := tx.QueryRowContext(ctx, `
+		SELECT u.email, u.full_name, COALESCE(u.locale, $4), s.name, os.status, COALESCE(os.carrier, ''), COALESCE(os.tracking_number, '')
+		FROM users u
+		JOIN order_shipments os ON os.order_id = $2 AND os.seller_id = $3
+		JOIN sellers s ON s.seller_id = os.seller_id
+		WHERE u.user_id = $1
+	`, userID, orderID, sellerID, DefaultLocale).Scan(&email, &payload.CustomerName, &locale, &payload.ShopName,
+		&payload.Status, &payload.Carrier, &payload.TrackingNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get shipment recipient: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT product_name, COALESCE(variant_name, ''), quantity, unit_price
+		FROM order_items
+		WHERE order_id = $1 AND seller_id = $2
+		ORDER BY created_at, order_item_id
+	`, orderID, sellerID)
+	if err != nil {
+		return fmt.Errorf("failed to get order items: %w", err)
+	}
+	defer rows.Close()
+
+	payload.Items = []OrderEmailItem{}
+	for rows.Next() {
+		var item OrderEmailItem
+		var unitPrice Money
+		if err := rows.Scan(&item.ProductName, &item.VariantName, &item.Quantity, &unitPrice); err != nil {
+			return fmt.Errorf("failed to scan order item: %w", err)
+		}
+		item.LineTotal = unitPrice.Mul(item.Quantity)
+		payload.Items = append(payload.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+	rows.Close()
+
+	return enqueueEmail(ctx, tx, EmailShippingUpdate, locale, email, payload.CustomerName, payload)
+}
+
+func (s *Store) UpdateShipment(ctx context.Context, sellerID, orderID string, update ShipmentUpdate) (OrderShipment, error) {
+	return s.db.UpdateShipment(ctx, sellerID, orderID, update)
+}
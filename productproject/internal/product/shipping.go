@@ -0,0 +1,424 @@
+// shipping.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ProductDimensions คือน้ำหนักและขนาดของสินค้าหนึ่งชิ้นสำหรับคำนวณค่าจัดส่ง 0 คือไม่ได้ระบุ
+type ProductDimensions struct {
+	WeightGrams int `json:"weight_grams" binding:"gte=0,lte=1000000"`
+	LengthCm    int `json:"length_cm" binding:"gte=0,lte=1000"`
+	WidthCm     int `json:"width_cm" binding:"gte=0,lte=1000"`
+	HeightCm    int `json:"height_cm" binding:"gte=0,lte=1000"`
+}
+
+// chargeableWeight คืนน้ำหนักที่ใช้คิดค่าส่ง (กรัม) คือค่าที่มากกว่าระหว่างน้ำหนักจริงและน้ำหนักตามปริมาตร
+// น้ำหนักตามปริมาตรคิดเมื่อระบุขนาดครบทั้งสามด้าน สำคัญกับสินค้าชิ้นใหญ่แต่เบา เช่น คอนโดแมว (shelter)
+func (d ProductDimensions) chargeableWeight(divisor int) int {
+	weight := d.WeightGrams
+	if d.LengthCm > 0 && d.WidthCm > 0 && d.HeightCm > 0 && divisor > 0 {
+		volume := int64(d.LengthCm) * int64(d.WidthCm) * int64(d.HeightCm) * 1000
+		volumetric := int((volume + int64(divisor) - 1) / int64(divisor))
+		if volumetric > weight {
+			weight = volumetric
+		}
+	}
+	return weight
+}
+
+// ค่าที่อนุญาตตาม ENUM ในฐานข้อมูล
+var shippingRateTypes = []string{"flat", "weight_tiers"}
+
+// defaultShippingProfile ใช้กับร้านที่ยังไม่ได้ตั้งค่าการจัดส่ง
+var defaultShippingProfile = ShippingProfile{
+	RateType:           "flat",
+	FlatRate:           moneyPtr(NewMoney(5000, DefaultCurrency)),
+	DimensionalDivisor: 5000,
+	WeightTiers:        []ShippingWeightTier{},
+	IsDefault:          true,
+}
+
+func moneyPtr(m Money) *Money { return &m }
+
+type ShippingWeightTier struct {
+	MaxWeightGrams int   `json:"max_weight_grams" binding:"gt=0"`
+	Rate           Money `json:"rate" binding:"gte=0,lte=99999999.99"`
+}
+
+// ShippingProfile คือวิธีคิดค่าจัดส่งของร้าน แต่ละร้านส่งของแยกกันจึงคิดค่าส่งแยกกัน
+type ShippingProfile struct {
+	SellerID              string               `json:"seller_id"`
+	RateType              string               `json:"rate_type"`           // 'flat', 'weight_tiers'
+	FlatRate              *Money               `json:"flat_rate,omitempty"` // flat
+	WeightTiers           []ShippingWeightTier `json:"weight_tiers"`        // weight_tiers เรียงจากน้อยไปมาก
+	AdditionalKgRate      Money                `json:"additional_kg_rate"`  // ต่อกิโลกรัมที่เกินขั้นสูงสุด
+	FreeShippingThreshold *Money               `json:"free_shipping_threshold,omitempty"`
+	DimensionalDivisor    int                  `json:"dimensional_divisor"`
+	IsDefault             bool                 `json:"is_default"` // ร้านยังไม่ได้ตั้งค่า ใช้ค่าเริ่มต้นของระบบ
+	UpdatedAt             *time.Time           `json:"updated_at,omitempty"`
+}
+
+// NewShippingProfile แทนที่การตั้งค่าเดิมทั้งหมดของร้าน
+type NewShippingProfile struct {
+	RateType              string               `json:"rate_type" binding:"shipping_rate_type"`
+	FlatRate              *Money               `json:"flat_rate" binding:"omitempty,gte=0,lte=99999999.99"`
+	WeightTiers           []ShippingWeightTier `json:"weight_tiers" binding:"max=50,dive"`
+	AdditionalKgRate      Money                `json:"additional_kg_rate" binding:"gte=0,lte=99999999.99"`
+	FreeShippingThreshold *Money               `json:"free_shipping_threshold" binding:"omitempty,gt=0,lte=99999999.99"`
+	DimensionalDivisor    int                  `json:"dimensional_divisor" binding:"gte=0,lte=100000"` // 0 คือใช้ 5000
+}
+
+// Validate ตรวจ binding tag และ field ที่จำเป็นตามวิธีคิดค่าจัดส่ง
+func (p NewShippingProfile) Validate() error {
+	if err := ValidateStruct(p); err != nil {
+		return err
+	}
+
+	var fields []FieldError
+	switch p.RateType {
+	case "flat":
+		if p.FlatRate == nil {
+			fields = append(fields, FieldError{Field: "flat_rate", Message: "is required for flat shipping"})
+		}
+	case "weight_tiers":
+		if len(p.WeightTiers) == 0 {
+			fields = append(fields, FieldError{Field: "weight_tiers", Message: "is required for weight_tiers shipping"})
+		}
+		seen := map[int]bool{}
+		for _, tier := range p.WeightTiers {
+			if seen[tier.MaxWeightGrams] {
+				fields = append(fields, FieldError{Field: "weight_tiers", Message: fmt.Sprintf("max_weight_grams %d is duplicated", tier.MaxWeightGrams)})
+				break
+			}
+			seen[tier.MaxWeightGrams] = true
+		}
+	}
+	if len(fields) > 0 {
+		return invalidFields(fields...)
+	}
+	return nil
+}
+
+// Shipment คือพัสดุของร้านหนึ่งร้านในตะกร้า
+type Shipment struct {
+	SellerID           string `json:"seller_id"`
+	SellerName         string `json:"seller_name"`
+	ItemCount          int    `json:"item_count"`
+	Subtotal           Money  `json:"subtotal"`
+	WeightGrams        int    `json:"weight_grams"` // น้ำหนักที่ใช้คิดค่าส่ง รวมน้ำหนักตามปริมาตรแล้ว
+	RateType           string `json:"rate_type"`
+	Cost               Money  `json:"cost"`
+	FreeShipping       bool   `json:"free_shipping"`
+	FreeShippingReason string `json:"free_shipping_reason,omitempty"` // 'threshold' หรือ 'promotion'
+}
+
+type ShippingQuote struct {
+	Shipments []Shipment `json:"shipments"`
+	Total     Money      `json:"total"`
+}
+
+// shippingCost คิดค่าส่งของพัสดุหนึ่งชิ้นตามการตั้งค่าของร้าน ยังไม่รวมส่วนลดค่าส่ง
+func shippingCost(profile ShippingProfile, weightGrams int) Money {
+	if profile.RateType != "weight_tiers" || len(profile.WeightTiers) == 0 {
+		if profile.FlatRate == nil {
+			return Money{}
+		}
+		return *profile.FlatRate
+	}
+
+	for _, tier := range profile.WeightTiers {
+		if weightGrams <= tier.MaxWeightGrams {
+			return tier.Rate
+		}
+	}
+	// เกินขั้นสูงสุด คิดเพิ่มทุกกิโลกรัมที่เริ่มต้น
+	last := profile.WeightTiers[len(profile.WeightTiers)-1]
+	extraKg := (weightGrams - last.MaxWeightGrams + 999) / 1000
+	return last.Rate.Add(profile.AdditionalKgRate.Mul(extraKg))
+}
+
+// quoteShipments แบ่งสินค้าในตะกร้าตามร้านและคิดค่าส่งของแต่ละร้าน
+// โปรโมชันส่งฟรีใช้เฉพาะกับร้านที่อยู่ใน cart.FreeShippingSellerIDs
+func quoteShipments(cart Cart, profiles map[string]ShippingProfile, dimensions map[string]ProductDimensions, sellerNames map[string]string) ShippingQuote {
+	shipments := map[string]*Shipment{}
+	var order []string
+	for _, item := range cart.Items {
+		profile := profileOrDefault(profiles, item.SellerID)
+		shipment, ok := shipments[item.SellerID]
+		if !ok {
+			shipment = &Shipment{SellerID: item.SellerID, SellerName: sellerNames[item.SellerID], RateType: profile.RateType}
+			shipments[item.SellerID] = shipment
+			order = append(order, item.SellerID)
+		}
+		shipment.ItemCount += item.Quantity
+		shipment.Subtotal = shipment.Subtotal.Add(item.LineTotal)
+		shipment.WeightGrams += dimensions[item.ProductID].chargeableWeight(profile.DimensionalDivisor) * item.Quantity
+	}
+
+	quote := ShippingQuote{Shipments: []Shipment{}}
+	for _, sellerID := range order {
+		shipment := shipments[sellerID]
+		profile := profileOrDefault(profiles, sellerID)
+		shipment.Cost = shippingCost(profile, shipment.WeightGrams)
+
+		switch {
+		case containsID(cart.FreeShippingSellerIDs, sellerID):
+			shipment.FreeShipping, shipment.FreeShippingReason = true, "promotion"
+		case profile.FreeShippingThreshold != nil && shipment.Subtotal.Cmp(*profile.FreeShippingThreshold) >= 0:
+			shipment.FreeShipping, shipment.FreeShippingReason = true, "threshold"
+		}
+		if shipment.FreeShipping {
+			shipment.Cost = Money{}
+		}
+
+		quote.Total = quote.Total.Add(shipment.Cost)
+		quote.Shipments = append(quote.Shipments, *shipment)
+	}
+	return quote
+}
+
+func profileOrDefault(profiles map[string]ShippingProfile, sellerID string) ShippingProfile {
+	if profile, ok := profiles[sellerID]; ok {
+		return profile
+	}
+	profile := defaultShippingProfile
+	profile.SellerID = sellerID
+	return profile
+}
+
+// getShippingProfiles คืนการตั้งค่าของร้านที่ตั้งค่าไว้แล้ว ร้านที่ไม่มีใน map ให้ใช้ defaultShippingProfile
+func (pdb *PostgresDatabase) getShippingProfiles(ctx context.Context, sellerIDs []string) (map[string]ShippingProfile, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT seller_id, rate_type, flat_rate, additional_kg_rate, free_shipping_threshold, dimensional_divisor, updated_at
+		FROM shipping_profiles
+		WHERE seller_id = ANY($1::uuid[])
+	`, pq.Array(sellerIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipping profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := map[string]ShippingProfile{}
+	for rows.Next() {
+		var profile ShippingProfile
+		var updatedAt time.Time
+		if err := rows.Scan(&profile.SellerID, &profile.RateType, &profile.FlatRate, &profile.AdditionalKgRate,
+			&profile.FreeShippingThreshold, &profile.DimensionalDivisor, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan shipping profile: %w", err)
+		}
+		profile.UpdatedAt = &updatedAt
+		profile.WeightTiers = []ShippingWeightTier{}
+		profiles[profile.SellerID] = profile
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	rows.Close()
+
+	tierRows, err := pdb.db.QueryContext(ctx, `
+		SELECT seller_id, max_weight_grams, rate
+		FROM shipping_weight_tiers
+		WHERE seller_id = ANY($1::uuid[])
+		ORDER BY seller_id, max_weight_grams
+	`, pq.Array(sellerIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipping weight tiers: %w", err)
+	}
+	defer tierRows.Close()
+
+	for tierRows.Next() {
+		var sellerID string
+		var tier ShippingWeightTier
+		if err := tierRows.Scan(&sellerID, &tier.MaxWeightGrams, &tier.Rate); err != nil {
+			return nil, fmt.Errorf("failed to scan shipping weight tier: %w", err)
+		}
+		profile := profiles[sellerID]
+		profile.WeightTiers = append(profile.WeightTiers, tier)
+		profiles[sellerID] = profile
+	}
+	if err := tierRows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return profiles, nil
+}
+
+// GetShippingProfile คืนค่าเริ่มต้นของระบบ (IsDefault) ถ้าร้านยังไม่ได้ตั้งค่า
+func (pdb *PostgresDatabase) GetShippingProfile(ctx context.Context, sellerID string) (ShippingProfile, error) {
+	var exists bool
+	err := pdb.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM sellers WHERE seller_id = $1)`, sellerID).Scan(&exists)
+	if err != nil {
+		return ShippingProfile{}, fmt.Errorf("failed to get shop: %w", err)
+	}
+	if !exists {
+		return ShippingProfile{}, notFound("shop not found")
+	}
+
+	profiles, err := pdb.getShippingProfiles(ctx, []string{sellerID})
+	if err != nil {
+		return ShippingProfile{}, err
+	}
+	return profileOrDefault(profiles, sellerID), nil
+}
+
+func (pdb *PostgresDatabase) SetShippingProfile(ctx context.Context, sellerID string, profile NewShippingProfile) (ShippingProfile, error) {
+	if profile.DimensionalDivisor == 0 {
+		profile.DimensionalDivisor = defaultShippingProfile.DimensionalDivisor
+	}
+
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ShippingProfile{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO shipping_profiles (seller_id, rate_type, flat_rate, additional_kg_rate, free_shipping_threshold, dimensional_divisor)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (seller_id) DO UPDATE
+		SET rate_type = EXCLUDED.rate_type, flat_rate = EXCLUDED.flat_rate,
+		    additional_kg_rate = EXCLUDED.additional_kg_rate,
+		    free_shipping_threshold = EXCLUDED.free_shipping_threshold,
+		    dimensional_divisor = EXCLUDED.dimensional_divisor
+	`, sellerID, profile.RateType, profile.FlatRate, profile.AdditionalKgRate, profile.FreeShippingThreshold, profile.DimensionalDivisor)
+	if err != nil {
+		return ShippingProfile{}, fmt.Errorf("failed to save shipping profile: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM shipping_weight_tiers WHERE seller_id = $1`, sellerID); err != nil {
+		return ShippingProfile{}, fmt.Errorf("failed to save shipping weight tiers: %w", err)
+	}
+	tiers := append([]ShippingWeightTier(nil), profile.WeightTiers...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MaxWeightGrams < tiers[j].MaxWeightGrams })
+	for _, tier := range tiers {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO shipping_weight_tiers (seller_id, max_weight_grams, rate) VALUES ($1, $2, $3)
+		`, sellerID, tier.MaxWeightGrams, tier.Rate)
+		if err != nil {
+			return ShippingProfile{}, fmt.Errorf("failed to save shipping weight tiers: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ShippingProfile{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return pdb.GetShippingProfile(ctx, sellerID)
+}
+
+// getProductDimensions คืนน้ำหนักและขนาดของสินค้าตาม product_id
+func (pdb *PostgresDatabase) getProductDimensions(ctx context.Context, productIDs []string) (map[string]ProductDimensions, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT product_id, COALESCE(weight_grams, 0), COALESCE(length_cm, 0), COALESCE(width_cm, 0), COALESCE(height_cm, 0)
+		FROM products
+		WHERE product_id = ANY($1::uuid[])
+	`, pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product dimensions: %w", err)
+	}
+	defer rows.Close()
+
+	dimensions := map[string]ProductDimensions{}
+	for rows.Next() {
+		var id string
+		var d ProductDimensions
+		if err := rows.Scan(&id, &d.WeightGrams, &d.LengthCm, &d.WidthCm, &d.HeightCm); err != nil {
+			return nil, fmt.Errorf("failed to scan product dimensions: %w", err)
+		}
+		dimensions[id] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return dimensions, nil
+}
+
+// QuoteShipping คิดค่าจัดส่งของตะกร้าแยกตามร้าน
+func (pdb *PostgresDatabase) QuoteShipping(ctx context.Context, userID string) (ShippingQuote, error) {
+	cart, err := pdb.GetCart(ctx, userID)
+	if err != nil {
+		return ShippingQuote{}, err
+	}
+	return pdb.quoteCartShipping(ctx, cart)
+}
+
+func (pdb *PostgresDatabase) quoteCartShipping(ctx context.Context, cart Cart) (ShippingQuote, error) {
+	if len(cart.Items) == 0 {
+		return ShippingQuote{Shipments: []Shipment{}}, nil
+	}
+
+	var productIDs, sellerIDs []string
+	for _, item := range cart.Items {
+		productIDs = append(productIDs, item.ProductID)
+		if !containsID(sellerIDs, item.SellerID) {
+			sellerIDs = append(sellerIDs, item.SellerID)
+		}
+	}
+
+	profiles, err := pdb.getShippingProfiles(ctx, sellerIDs)
+	if err != nil {
+		return ShippingQuote{}, err
+	}
+	dimensions, err := pdb.getProductDimensions(ctx, productIDs)
+	if err != nil {
+		return ShippingQuote{}, err
+	}
+	sellerNames, err := pdb.getSellerNames(ctx, sellerIDs)
+	if err != nil {
+		return ShippingQuote{}, err
+	}
+	return quoteShipments(cart, profiles, dimensions, sellerNames), nil
+}
+
+func (pdb *PostgresDatabase) getSellerNames(ctx context.Context, sellerIDs []string) (map[string]string, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT seller_id, name FROM sellers WHERE seller_id = ANY($1::uuid[])
+	`, pq.Array(sellerIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shops: %w", err)
+	}
+	defer rows.Close()
+
+	names := map[string]string{}
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan shop: %w", err)
+		}
+		names[id] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return names, nil
+}
+
+// saveProductDimensions บันทึกน้ำหนักและขนาดของสินค้า ค่า 0 บันทึกเป็น NULL
+func saveProductDimensions(ctx context.Context, tx *sql.Tx, productID string, d ProductDimensions) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE products
+		SET weight_grams = NULLIF($1, 0), length_cm = NULLIF($2, 0), width_cm = NULLIF($3, 0), height_cm = NULLIF($4, 0)
+		WHERE product_id = $5
+	`, d.WeightGrams, d.LengthCm, d.WidthCm, d.HeightCm, productID)
+	if err != nil {
+		return fmt.Errorf("failed to save product dimensions: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) GetShippingProfile(ctx context.Context, sellerID string) (ShippingProfile, error) {
+	return s.db.GetShippingProfile(ctx, sellerID)
+}
+
+func (s *Store) SetShippingProfile(ctx context.Context, sellerID string, profile NewShippingProfile) (ShippingProfile, error) {
+	return s.db.SetShippingProfile(ctx, sellerID, profile)
+}
+
+func (s *Store) QuoteShipping(ctx context.Context, userID string) (ShippingQuote, error) {
+	return s.db.QuoteShipping(ctx, userID)
+}
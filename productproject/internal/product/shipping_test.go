@@ -0,0 +1,68 @@
+package ecommerce
+
+import "testing"
+
+func TestChargeableWeight(t *testing.T) {
+	tests := []struct {
+		name       string
+		dimensions ProductDimensions
+		divisor    int
+		want       int
+	}{
+		{name: "no dimensions uses actual weight", dimensions: ProductDimensions{WeightGrams: 1200}, divisor: 5000, want: 1200},
+		{name: "bulky and light uses volumetric weight", dimensions: ProductDimensions{WeightGrams: 1000, LengthCm: 30, WidthCm: 30, HeightCm: 30}, divisor: 5000, want: 5400},
+		{name: "small and heavy uses actual weight", dimensions: ProductDimensions{WeightGrams: 2000, LengthCm: 10, WidthCm: 10, HeightCm: 10}, divisor: 5000, want: 2000},
+		{name: "volumetric weight rounds up", dimensions: ProductDimensions{WeightGrams: 10, LengthCm: 7, WidthCm: 7, HeightCm: 7}, divisor: 5000, want: 69},
+		{name: "other divisor", dimensions: ProductDimensions{WeightGrams: 1000, LengthCm: 30, WidthCm: 30, HeightCm: 30}, divisor: 6000, want: 4500},
+		{name: "missing one side uses actual weight", dimensions: ProductDimensions{WeightGrams: 1000, LengthCm: 30, WidthCm: 30}, divisor: 5000, want: 1000},
+		{name: "no divisor uses actual weight", dimensions: ProductDimensions{WeightGrams: 1000, LengthCm: 30, WidthCm: 30, HeightCm: 30}, divisor: 0, want: 1000},
+		{name: "largest box does not overflow", dimensions: ProductDimensions{LengthCm: 1000, WidthCm: 1000, HeightCm: 1000}, divisor: 5000, want: 200000000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dimensions.chargeableWeight(tt.divisor); got != tt.want {
+				t.Errorf("chargeableWeight(%d) = %d, want %d", tt.divisor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShippingCost(t *testing.T) {
+	thb := func(minor int64) Money { return NewMoney(minor, DefaultCurrency) }
+	tiers := ShippingProfile{
+		RateType: "weight_tiers",
+		WeightTiers: []ShippingWeightTier{
+			{MaxWeightGrams: 1000, Rate: thb(3000)},
+			{MaxWeightGrams: 3000, Rate: thb(5000)},
+		},
+		AdditionalKgRate: thb(2000),
+	}
+
+	tests := []struct {
+		name    string
+		profile ShippingProfile
+		weight  int
+		want    int64
+	}{
+		{name: "flat rate", profile: ShippingProfile{RateType: "flat", FlatRate: moneyPtr(thb(5000))}, weight: 20000, want: 5000},
+		{name: "flat rate ignores tiers", profile: ShippingProfile{RateType: "flat", FlatRate: moneyPtr(thb(4000)), WeightTiers: tiers.WeightTiers}, weight: 500, want: 4000},
+		{name: "no flat rate is free", profile: ShippingProfile{RateType: "flat"}, weight: 500, want: 0},
+		{name: "tiers without tiers falls back to flat", profile: ShippingProfile{RateType: "weight_tiers", FlatRate: moneyPtr(thb(4500))}, weight: 500, want: 4500},
+		{name: "first tier", profile: tiers, weight: 500, want: 3000},
+		{name: "tier boundary is inclusive", profile: tiers, weight: 1000, want: 3000},
+		{name: "just above a tier", profile: tiers, weight: 1001, want: 5000},
+		{name: "last tier", profile: tiers, weight: 3000, want: 5000},
+		{name: "over the last tier starts a kilogram", profile: tiers, weight: 3001, want: 7000},
+		{name: "over by exactly one kilogram", profile: tiers, weight: 4000, want: 7000},
+		{name: "over by part of a second kilogram", profile: tiers, weight: 4001, want: 9000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shippingCost(tt.profile, tt.weight); got.Minor() != tt.want {
+				t.Errorf("shippingCost(%d g) = %d, want %d", tt.weight, got.Minor(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+// tax.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// VATRate คืออัตราภาษีมูลค่าเพิ่มเป็นเปอร์เซ็นต์ ราคาสินค้าและค่าจัดส่งในระบบเป็นราคารวม VAT แล้ว
+// ร้านที่ไม่ได้จดทะเบียน VAT ใช้อัตรา 0
+const VATRate = "7.00"
+
+const noVAT = "0.00"
+
+// ประเภทเอกสารที่ออกให้ผู้ซื้อ ตรงกับ ENUM invoice_document_type
+const (
+	DocumentTaxInvoice = "tax_invoice" // ใบกำกับภาษี/ใบเสร็จรับเงิน ของร้านที่จดทะเบียน VAT
+	DocumentReceipt    = "receipt"     // ใบเสร็จรับเงิน ของร้านที่ไม่ได้จดทะเบียน VAT
+)
+
+// documentPrefixes คือคำนำหน้าเลขที่เอกสาร เช่น TI2026-000001
+var documentPrefixes = map[string]string{
+	DocumentTaxInvoice: "TI",
+	DocumentReceipt:    "RC",
+}
+
+// invoiceableStatuses คือสถานะคำสั่งซื้อที่ชำระเงินแล้วและออกเอกสารได้
+var invoiceableStatuses = []string{"paid", "shipped", "delivered"}
+
+// SellerTaxSettings คือข้อมูลภาษีของร้าน การเปลี่ยนแปลงมีผลกับคำสั่งซื้อใหม่เท่านั้น
+type SellerTaxSettings struct {
+	VATRegistered bool   `json:"vat_registered"`
+	TaxID         string `json:"tax_id" binding:"omitempty,len=13,number"`    // เลขประจำตัวผู้เสียภาษี 13 หลัก
+	TaxBranch     string `json:"tax_branch" binding:"omitempty,len=5,number"` // รหัสสาขา 00000 คือสำนักงานใหญ่
+}
+
+// Validate ตรวจเลขประจำตัวผู้เสียภาษีด้วยหลักตรวจสอบ และกำหนดสาขาเป็นสำนักงานใหญ่ถ้าไม่ระบุ
+func (s *SellerTaxSettings) Validate() error {
+	if err := ValidateStruct(s); err != nil {
+		return err
+	}
+	if s.VATRegistered && s.TaxID == "" {
+		return invalidFields(FieldError{Field: "tax_id", Message: "is required for VAT-registered sellers"})
+	}
+	if s.TaxID != "" && !validTaxID(s.TaxID) {
+		return invalidFields(FieldError{Field: "tax_id", Message: "has an invalid check digit"})
+	}
+	if s.TaxBranch == "" {
+		s.TaxBranch = "00000"
+	}
+	return nil
+}
+
+// validTaxID ตรวจหลักสุดท้ายของเลขประจำตัวผู้เสียภาษี (ใช้สูตรเดียวกับเลขบัตรประชาชน)
+func validTaxID(id string) bool {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		sum += int(id[i]-'0') * (13 - i)
+	}
+	return (11-sum%11)%10 == int(id[12]-'0')
+}
+
+// TaxInvoice คือใบกำกับภาษีหรือใบเสร็จรับเงินของร้านหนึ่งร้านในคำสั่งซื้อ
+// ยอดเงินทั้งหมดคำนวณจากสำเนาราคาและภาษีในคำสั่งซื้อ จึงออกซ้ำได้ค่าเดิมเสมอ
+type TaxInvoice struct {
+	ID           string         `json:"id"`
+	Number       string         `json:"number"`
+	DocumentType string         `json:"document_type"`
+	OrderID      string         `json:"order_id"`
+	IssuedAt     time.Time      `json:"issued_at"`
+	Seller       InvoiceSeller  `json:"seller"`
+	Buyer        *PostalAddress `json:"buyer"` // ที่อยู่ใบเสร็จของคำสั่งซื้อ
+	Items        []OrderItem    `json:"items"`
+	Shipment     *OrderShipment `json:"shipment"`
+	Subtotal     Money          `json:"subtotal"`
+	Discount     Money          `json:"discount"`
+	NetTotal     Money          `json:"net_total"` // มูลค่าก่อนภาษี
+	VATTotal     Money          `json:"vat_total"`
+	Total        Money          `json:"total"`
+}
+
+// InvoiceSeller คือข้อมูลผู้ขาย ณ เวลาที่ออกเอกสาร
+type InvoiceSeller struct {
+	SellerID  string `json:"seller_id"`
+	Name      string `json:"name"`
+	Address   string `json:"address"`
+	TaxID     string `json:"tax_id,omitempty"`
+	TaxBranch string `json:"tax_branch,omitempty"`
+}
+
+// includedVAT คืนภาษีที่รวมอยู่ในราคา gross คือ gross * rate / (100 + rate) ปัดเศษแบบ half-up ที่หน่วยย่อย
+func includedVAT(gross Money, rate string) (Money, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid VAT rate %q", rate)
+	}
+	q := new(big.Rat).Mul(new(big.Rat).SetInt64(gross.minor), r)
+	q.Quo(q, new(big.Rat).Add(r, big.NewRat(100, 1)))
+	minor, ok := roundHalfUp(q)
+	if !ok {
+		return Money{}, fmt.Errorf("amount is out of range")
+	}
+	return Money{minor: minor, currency: gross.Currency()}, nil
+}
+
+// allocate แบ่ง amount ตามสัดส่วนของ weights ด้วยวิธีเศษเหลือมากที่สุด ผลรวมเท่ากับ amount พอดี
+// ถ้า amount ไม่เกินผลรวมของ weights จะไม่มีส่วนใดเกินน้ำหนักของตัวเอง
+func allocate(amount int64, weights []int64) []int64 {
+	shares := make([]int64, len(weights))
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 || amount <= 0 {
+		return shares
+	}
+
+	remainders := make([]int64, len(weights))
+	order := make([]int, len(weights))
+	var allocated int64
+	for i, w := range weights {
+		q, r := new(big.Int).QuoRem(new(big.Int).Mul(big.NewInt(amount), big.NewInt(w)), big.NewInt(total), new(big.Int))
+		shares[i], remainders[i], order[i] = q.Int64(), r.Int64(), i
+		allocated += shares[i]
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for k := 0; allocated < amount; k++ {
+		shares[order[k%len(order)]]++
+		allocated++
+	}
+	return shares
+}
+
+// allocateDiscounts กระจายส่วนลดของโปรโมชันที่ใช้ได้ไปยังรายการที่เข้าเงื่อนไข ตามสัดส่วนยอดที่ยังไม่ถูกหัก
+// ส่วนลดที่เกินยอดของรายการที่เข้าเงื่อนไข (เมื่อหลายโปรโมชันลดรายการเดียวกัน) จะกระจายไปยังรายการอื่น
+func allocateDiscounts(items []CartItem, promotions []PromotionResult) []Money {
+	remaining := make([]int64, len(items))
+	discounts := make([]Money, len(items))
+	for i, item := range items {
+		remaining[i] = item.LineTotal.minor
+		discounts[i] = NewMoney(0, item.LineTotal.Currency())
+	}
+
+	spread := func(amount int64, covered func(CartItem) bool) int64 {
+		weights := make([]int64, len(items))
+		var total int64
+		for i, item := range items {
+			if covered(item) {
+				weights[i] = remaining[i]
+				total += remaining[i]
+			}
+		}
+		if amount > total {
+			amount = total
+		}
+		for i, share := range allocate(amount, weights) {
+			remaining[i] -= share
+			discounts[i] = discounts[i].Add(NewMoney(share, discounts[i].Currency()))
+		}
+		return amount
+	}
+
+	for _, result := range promotions {
+		if !result.Applied || result.Discount.IsZero() {
+			continue
+		}
+		amount := result.Discount.minor
+		amount -= spread(amount, func(item CartItem) bool { return containsID(result.itemIDs, item.ID) })
+		spread(amount, func(CartItem) bool { return true })
+	}
+	return discounts
+}
+
+// getVATRegisteredSellers คืนร้านที่จดทะเบียน VAT จากรายการร้านที่ระบุ
+func getVATRegisteredSellers(ctx context.Context, q queryer, sellerIDs []string) (map[string]bool, error) {
+	registered := map[string]bool{}
+	for _, sellerID := range sellerIDs {
+		if _, ok := registered[sellerID]; ok {
+			continue
+		}
+		var vatRegistered bool
+		err := q.QueryRowContext(ctx, `SELECT vat_registered FROM sellers WHERE seller_id = $1`, sellerID).Scan(&vatRegistered)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to get seller VAT registration: %w", err)
+		}
+		registered[sellerID] = vatRegistered
+	}
+	return registered, nil
+}
+
+func vatRateFor(vatRegistered bool) string {
+	if vatRegistered {
+		return VATRate
+	}
+	return noVAT
+}
+
+func (pdb *PostgresDatabase) GetSellerTaxSettings(ctx context.Context, sellerID string) (SellerTaxSettings, error) {
+	var settings SellerTaxSettings
+	err := pdb.db.QueryRowContext(ctx, `
+		SELECT vat_registered, COALESCE(tax_id, ''), tax_branch FROM sellers WHERE seller_id = $1
+	`, sellerID).Scan(&settings.VATRegistered, &settings.TaxID, &settings.TaxBranch)
+	if err == sql.ErrNoRows {
+		return SellerTaxSettings{}, notFound("shop not found")
+	}
+	if err != nil {
+		return SellerTaxSettings{}, fmt.Errorf("failed to get tax settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (pdb *PostgresDatabase) SetSellerTaxSettings(ctx context.Context, sellerID string, settings SellerTaxSettings) (SellerTaxSettings, error) {
+	result, err := pdb.db.ExecContext(ctx, `
+		UPDATE sellers SET vat_registered = $2, tax_id = NULLIF($3, ''), tax_branch = $4 WHERE seller_id = $1
+	`, sellerID, settings.VATRegistered, settings.TaxID, settings.TaxBranch)
+	if err != nil {
+		return SellerTaxSettings{}, fmt.Errorf("failed to update tax settings: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return SellerTaxSettings{}, fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return SellerTaxSettings{}, notFound("shop not found")
+	}
+	return pdb.GetSellerTaxSettings(ctx, sellerID)
+}
+
+// GetTaxInvoice คืนเอกสารของร้านในคำสั่งซื้อของผู้ใช้ ครั้งแรกที่เรียกจะออกเลขที่เอกสารใหม่
+// เลขที่เรียงต่อกันต่อร้าน ประเภทเอกสาร และปีตามเขตเวลาของร้าน การล็อกแถวของคำสั่งซื้อและลำดับเลข
+// ทำให้คำขอพร้อมกันได้เอกสารใบเดียวกันและเลขไม่ข้าม ร้านที่เก็บ VAT ในคำสั่งซื้อจะได้ใบกำกับภาษี
+func (pdb *PostgresDatabase) GetTaxInvoice(ctx context.Context, userID, orderID, sellerID string) (TaxInvoice, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return TaxInvoice{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx, `
+		SELECT status FROM orders WHERE order_id = $1 AND user_id = $2 FOR UPDATE
+	`, orderID, userID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return TaxInvoice{}, notFound("order not found")
+	}
+	if err != nil {
+		return TaxInvoice{}, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	invoice, err := scanTaxInvoiceHeader(tx.QueryRowContext(ctx, `
+		SELECT `+taxInvoiceColumns+` FROM tax_invoices WHERE order_id = $1 AND seller_id = $2
+	`, orderID, sellerID))
+	if err == sql.ErrNoRows {
+		invoice, err = issueTaxInvoice(ctx, tx, orderID, sellerID, status)
+	} else if err != nil {
+		err = fmt.Errorf("failed to get tax invoice: %w", err)
+	}
+	if err != nil {
+		return TaxInvoice{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return TaxInvoice{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	order, err := pdb.GetOrder(ctx, userID, orderID)
+	if err != nil {
+		return TaxInvoice{}, err
+	}
+	fillTaxInvoice(&invoice, order)
+	return invoice, nil
+}
+
+// issueTaxInvoice ออกเลขที่เอกสารใหม่และบันทึกข้อมูลผู้ขาย ณ ตอนนี้ ต้องเรียกภายใต้การล็อกแถวของคำสั่งซื้อ
+func issueTaxInvoice(ctx context.Context, tx *sql.Tx, orderID, sellerID, status string) (TaxInvoice, error) {
+	if !containsID(invoiceableStatuses, status) {
+		return TaxInvoice{}, conflict("documents can only be issued for paid orders, this order is %s", status)
+	}
+
+	var itemCount int
+	var chargedVAT bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(BOOL_OR(vat_rate > 0), FALSE)
+		FROM order_items WHERE order_id = $1 AND seller_id = $2
+	`, orderID, sellerID).Scan(&itemCount, &chargedVAT)
+	if err != nil {
+		return TaxInvoice{}, fmt.Errorf("failed to get order items: %w", err)
+	}
+	if itemCount == 0 {
+		return TaxInvoice{}, notFound("order has no items from this shop")
+	}
+
+	var seller InvoiceSeller
+	var year int
+	err = tx.QueryRowContext(ctx, `
+		SELECT seller_id, name, COALESCE(address, ''), COALESCE(tax_id, ''), tax_branch,
+		       EXTRACT(YEAR FROM NOW() AT TIME ZONE timezone)::int
+		FROM sellers WHERE seller_id = $1
+	`, sellerID).Scan(&seller.SellerID, &seller.Name, &seller.Address, &seller.TaxID, &seller.TaxBranch, &year)
+	if err == sql.ErrNoRows {
+		return TaxInvoice{}, notFound("shop not found")
+	}
+	if err != nil {
+		return TaxInvoice{}, fmt.Errorf("failed to get seller: %w", err)
+	}
+
+	documentType := DocumentReceipt
+	if chargedVAT {
+		documentType = DocumentTaxInvoice
+		if seller.TaxID == "" {
+			return TaxInvoice{}, conflict("shop has not set its tax ID, a tax invoice cannot be issued yet")
+		}
+	} else {
+		seller.TaxID, seller.TaxBranch = "", ""
+	}
+
+	var number int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO invoice_sequences (seller_id, document_type, year, last_number)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (seller_id, document_type, year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number
+	`, sellerID, documentType, year).Scan(&number)
+	if err != nil {
+		return TaxInvoice{}, fmt.Errorf("failed to allocate invoice number: %w", err)
+	}
+
+	invoice, err := scanTaxInvoiceHeader(tx.QueryRowContext(ctx, `
+		INSERT INTO tax_invoices (order_id, seller_id, document_type, invoice_number,
+		                          seller_name, seller_address, seller_tax_id, seller_tax_branch)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''))
+		RETURNING `+taxInvoiceColumns,
+		orderID, sellerID, documentType, fmt.Sprintf("%s%d-%06d", documentPrefixes[documentType], year, number),
+		seller.Name, seller.Address, seller.TaxID, seller.TaxBranch))
+	if err != nil {
+		return TaxInvoice{}, fmt.Errorf("failed to create tax invoice: %w", err)
+	}
+	return invoice, nil
+}
+
+const taxInvoiceColumns = `invoice_id, order_id, document_type, invoice_number, issued_at, seller_id, seller_name,
+		       COALESCE(seller_address, ''), COALESCE(seller_tax_id, ''), COALESCE(seller_tax_branch, '')`
+
+func scanTaxInvoiceHeader(row rowScanner) (TaxInvoice, error) {
+	var invoice TaxInvoice
+	err := row.Scan(&invoice.ID, &invoice.OrderID, &invoice.DocumentType, &invoice.Number, &invoice.IssuedAt,
+		&invoice.Seller.SellerID, &invoice.Seller.Name, &invoice.Seller.Address, &invoice.Seller.TaxID, &invoice.Seller.TaxBranch)
+	return invoice, err
+}
+
+// fillTaxInvoice เติมรายการ ค่าจัดส่ง และยอดรวมของร้านจากคำสั่งซื้อ
+func fillTaxInvoice(invoice *TaxInvoice, order Order) {
+	invoice.Buyer = order.BillingAddress
+	invoice.Items = []OrderItem{}
+	for _, item := range order.Items {
+		if item.SellerID != invoice.Seller.SellerID {
+			continue
+		}
+		invoice.Items = append(invoice.Items, item)
+		invoice.Subtotal = invoice.Subtotal.Add(item.LineTotal)
+		invoice.Discount = invoice.Discount.Add(item.Discount)
+		invoice.VATTotal = invoice.VATTotal.Add(item.VATAmount)
+		invoice.Total = invoice.Total.Add(item.LineTotal.Sub(item.Discount))
+	}
+	for i, shipment := range order.Shipments {
+		if shipment.SellerID != invoice.Seller.SellerID {
+			continue
+		}
+		invoice.Shipment = &order.Shipments[i]
+		invoice.VATTotal = invoice.VATTotal.Add(shipment.VATAmount)
+		invoice.Total = invoice.Total.Add(shipment.Cost)
+	}
+	invoice.NetTotal = invoice.Total.Sub(invoice.VATTotal)
+}
+
+func (s *Store) GetSellerTaxSettings(ctx context.Context, sellerID string) (SellerTaxSettings, error) {
+	return s.db.GetSellerTaxSettings(ctx, sellerID)
+}
+
+func (s *Store) SetSellerTaxSettings(ctx context.Context, sellerID string, settings SellerTaxSettings) (SellerTaxSettings, error) {
+	return s.db.SetSellerTaxSettings(ctx, sellerID, settings)
+}
+
+func (s *Store) GetTaxInvoice(ctx context.Context, userID, orderID, sellerID string) (TaxInvoice, error) {
+	return s.db.GetTaxInvoice(ctx, userID, orderID, sellerID)
+}
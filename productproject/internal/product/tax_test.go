@@ -0,0 +1,226 @@
+package ecommerce
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIncludedVAT(t *testing.T) {
+	tests := []struct {
+		name    string
+		gross   int64
+		rate    string
+		want    int64
+		wantErr bool
+	}{
+		{name: "exact", gross: 10700, rate: "7", want: 700},
+		{name: "one satang", gross: 1, rate: "7", want: 0},
+		{name: "odd satang rounds up", gross: 101, rate: "7", want: 7},
+		{name: "odd satang rounds down", gross: 99, rate: "7", want: 6},
+		{name: "rounds just below one satang", gross: 15, rate: "7", want: 1},
+		{name: "large odd amount", gross: 10699, rate: "7", want: 700},
+		{name: "fractional rate", gross: 10150, rate: "1.5", want: 150},
+		{name: "not VAT registered", gross: 10700, rate: "0", want: 0},
+		{name: "negative amount", gross: -101, rate: "7", want: -7},
+		{name: "invalid rate", gross: 100, rate: "seven", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := includedVAT(NewMoney(tt.gross, DefaultCurrency), tt.rate)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("includedVAT(%d, %q) = %s, want error", tt.gross, tt.rate, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("includedVAT(%d, %q) error: %v", tt.gross, tt.rate, err)
+			}
+			if got.Minor() != tt.want {
+				t.Errorf("includedVAT(%d, %q) = %d, want %d", tt.gross, tt.rate, got.Minor(), tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		weights []int64
+		want    []int64
+	}{
+		{name: "even split", amount: 90, weights: []int64{1, 1, 1}, want: []int64{30, 30, 30}},
+		{name: "remainder goes to first equal weight", amount: 100, weights: []int64{1, 1, 1}, want: []int64{34, 33, 33}},
+		{name: "remainders to first equal weights", amount: 5, weights: []int64{1, 1, 1}, want: []int64{2, 2, 1}},
+		{name: "largest remainder wins", amount: 10, weights: []int64{33, 33, 34}, want: []int64{3, 3, 4}},
+		{name: "proportional", amount: 1000, weights: []int64{3000, 7000}, want: []int64{300, 700}},
+		{name: "zero weight gets nothing", amount: 7, weights: []int64{0, 5, 2}, want: []int64{0, 5, 2}},
+		{name: "amount equals total", amount: 199, weights: []int64{99, 100}, want: []int64{99, 100}},
+		{name: "zero amount", amount: 0, weights: []int64{1, 2}, want: []int64{0, 0}},
+		{name: "zero weights", amount: 10, weights: []int64{0, 0}, want: []int64{0, 0}},
+		{name: "no weights", amount: 10, weights: nil, want: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocate(tt.amount, tt.weights)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allocate(%d, %v) = %v, want %v", tt.amount, tt.weights, got, tt.want)
+			}
+
+			var sum, total int64
+			for _, w := range tt.weights {
+				total += w
+			}
+			for i, share := range got {
+				sum += share
+				if tt.amount <= total && share > tt.weights[i] {
+					t.Errorf("share %d = %d exceeds its weight %d", i, share, tt.weights[i])
+				}
+			}
+			if total > 0 && sum != tt.amount {
+				t.Errorf("allocate(%d, %v) sums to %d, want %d", tt.amount, tt.weights, sum, tt.amount)
+			}
+		})
+	}
+}
+
+func TestAllocateDiscounts(t *testing.T) {
+	item := func(id string, lineTotal int64) CartItem {
+		return CartItem{ID: id, LineTotal: NewMoney(lineTotal, DefaultCurrency)}
+	}
+	applied := func(discount int64, itemIDs ...string) PromotionResult {
+		return PromotionResult{Applied: true, Discount: NewMoney(discount, DefaultCurrency), itemIDs: itemIDs}
+	}
+
+	tests := []struct {
+		name       string
+		items      []CartItem
+		promotions []PromotionResult
+		want       []int64
+	}{
+		{
+			name:       "proportional to line totals",
+			items:      []CartItem{item("a", 3000), item("b", 7000)},
+			promotions: []PromotionResult{applied(1000, "a", "b")},
+			want:       []int64{300, 700},
+		},
+		{
+			name:       "odd satang by largest remainder",
+			items:      []CartItem{item("a", 100), item("b", 100), item("c", 100)},
+			promotions: []PromotionResult{applied(100, "a", "b", "c")},
+			want:       []int64{34, 33, 33},
+		},
+		{
+			name:       "scoped to eligible items",
+			items:      []CartItem{item("a", 3000), item("b", 7000)},
+			promotions: []PromotionResult{applied(1000, "a")},
+			want:       []int64{1000, 0},
+		},
+		{
+			name:       "stacked promotions overflow to other items",
+			items:      []CartItem{item("a", 3000), item("b", 7000)},
+			promotions: []PromotionResult{applied(2500, "a"), applied(2500, "a")},
+			want:       []int64{3000, 2000},
+		},
+		{
+			name:  "skips promotions that do not apply",
+			items: []CartItem{item("a", 3000), item("b", 7000)},
+			promotions: []PromotionResult{
+				{Applied: false, Discount: NewMoney(500, DefaultCurrency), itemIDs: []string{"a"}},
+				applied(0, "a"),
+			},
+			want: []int64{0, 0},
+		},
+		{
+			name:       "capped at cart total",
+			items:      []CartItem{item("a", 300), item("b", 700)},
+			promotions: []PromotionResult{applied(1000, "a", "b")},
+			want:       []int64{300, 700},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocateDiscounts(tt.items, tt.promotions)
+			minors := make([]int64, len(got))
+			var sum, discount int64
+			for i, m := range got {
+				minors[i] = m.Minor()
+				sum += m.Minor()
+			}
+			if !reflect.DeepEqual(minors, tt.want) {
+				t.Fatalf("allocateDiscounts() = %v, want %v", minors, tt.want)
+			}
+			for _, result := range tt.promotions {
+				if result.Applied {
+					discount += result.Discount.Minor()
+				}
+			}
+			if sum != discount {
+				t.Errorf("allocated %d in total, want the full discount %d", sum, discount)
+			}
+		})
+	}
+}
+
+func TestValidTaxID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"0105536092641", true},
+		{"1101700207030", true},
+		{"0000000000001", true}, // ผลรวมหาร 11 ลงตัว หลักตรวจสอบเป็น 1
+		{"9999999999994", true},
+		{"0105536092642", false},
+		{"1101700207031", false},
+		{"0000000000000", false},
+		{"9999999999990", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := validTaxID(tt.id); got != tt.want {
+				t.Errorf("validTaxID(%q) = %t, want %t", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSellerTaxSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  SellerTaxSettings
+		wantField string
+	}{
+		{"valid", SellerTaxSettings{VATRegistered: true, TaxID: "0105536092641"}, ""},
+		{"not registered without tax id", SellerTaxSettings{}, ""},
+		{"registered without tax id", SellerTaxSettings{VATRegistered: true}, "tax_id"},
+		{"signed number", SellerTaxSettings{TaxID: "+105536092641"}, "tax_id"},
+		{"decimal number", SellerTaxSettings{TaxID: "10553609264.1"}, "tax_id"},
+		{"wrong check digit", SellerTaxSettings{TaxID: "0105536092642"}, "tax_id"},
+		{"signed branch", SellerTaxSettings{TaxID: "0105536092641", TaxBranch: "-0001"}, "tax_branch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settings.Validate()
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				if tt.settings.TaxBranch != "00000" {
+					t.Errorf("TaxBranch = %q, want 00000", tt.settings.TaxBranch)
+				}
+				return
+			}
+			e, ok := err.(*Error)
+			if !ok || len(e.Fields) == 0 || e.Fields[0].Field != tt.wantField {
+				t.Errorf("Validate() error = %v, want a %s field error", err, tt.wantField)
+			}
+		})
+	}
+}
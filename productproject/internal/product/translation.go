@@ -0,0 +1,255 @@
+// translation.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ภาษาที่รองรับ ข้อความในคอลัมน์หลัก (products.name, categories.name, sellers.description)
+// เป็นภาษา DefaultLocale ภาษาอื่นเก็บในตาราง *_translations และถ้าไม่มีคำแปลจะใช้ข้อความหลักแทน
+const DefaultLocale = "th"
+
+var (
+	Locales            = []string{DefaultLocale, "en"}
+	translationLocales = []string{"en"}
+)
+
+// Translation คือคำแปลของสินค้าหรือหมวดหมู่ในภาษาหนึ่ง ช่องที่ว่างจะใช้ข้อความหลักแทน
+type Translation struct {
+	Name        string `json:"name" binding:"max=255"`
+	Description string `json:"description"`
+}
+
+type localeContextKey struct{}
+
+// WithLocale กำหนดภาษาที่ต้องการให้ทุก query ที่ใช้ ctx นี้ ภาษาที่ไม่รองรับจะใช้ DefaultLocale
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// localeFrom คืนภาษาของ request ถ้าเป็น DefaultLocale หรือไม่รองรับจะคืนค่าว่าง เพราะไม่ต้องแปล
+func localeFrom(ctx context.Context) string {
+	locale, _ := ctx.Value(localeContextKey{}).(string)
+	if !containsID(translationLocales, locale) {
+		return ""
+	}
+	return locale
+}
+
+// getTranslations ดึงคำแปลจากตาราง table (product_translations หรือ category_translations) ตาม key
+func (pdb *PostgresDatabase) getTranslations(ctx context.Context, table, key, locale string, ids interface{}) (map[string]Translation, error) {
+	rows, err := pdb.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %[2]s::text, COALESCE(name, ''), COALESCE(description, '')
+		FROM %[1]s
+		WHERE locale = $1 AND %[2]s::text = ANY($2::text[])
+	`, table, key), locale, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get translations: %w", err)
+	}
+	defer rows.Close()
+
+	translations := map[string]Translation{}
+	for rows.Next() {
+		var id string
+		var t Translation
+		if err := rows.Scan(&id, &t.Name, &t.Description); err != nil {
+			return nil, fmt.Errorf("failed to scan translation: %w", err)
+		}
+		translations[id] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return translations, nil
+}
+
+// localizeProducts แทนชื่อและคำอธิบายของสินค้าและหมวดหมู่ด้วยคำแปลตามภาษาใน ctx
+func (pdb *PostgresDatabase) localizeProducts(ctx context.Context, products []ProductItem) error {
+	locale := localeFrom(ctx)
+	if locale == "" || len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+	}
+	translations, err := pdb.getTranslations(ctx, "product_translations", "product_id", locale, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	// แปลหมวดหมู่ที่ไม่ซ้ำกันของทุกสินค้าในครั้งเดียว แล้วใส่คำแปลกลับตาม category_id
+	var categories []Category
+	seen := map[int]bool{}
+	for _, product := range products {
+		for _, category := range product.Categories {
+			if !seen[category.ID] {
+				seen[category.ID] = true
+				categories = append(categories, category)
+			}
+		}
+	}
+	if err := pdb.localizeCategories(ctx, categories); err != nil {
+		return err
+	}
+	localized := make(map[int]Category, len(categories))
+	for _, category := range categories {
+		localized[category.ID] = category
+	}
+
+	for i := range products {
+		t := translations[products[i].ID]
+		products[i].Name = translated(t.Name, products[i].Name)
+		products[i].Description = translated(t.Description, products[i].Description)
+		for j, category := range products[i].Categories {
+			products[i].Categories[j] = localized[category.ID]
+		}
+	}
+	return nil
+}
+
+// localizeCategories แทนชื่อและคำอธิบายของหมวดหมู่ด้วยคำแปลตามภาษาใน ctx
+func (pdb *PostgresDatabase) localizeCategories(ctx context.Context, categories []Category) error {
+	locale := localeFrom(ctx)
+	if locale == "" || len(categories) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(categories))
+	for i, category := range categories {
+		ids[i] = fmt.Sprint(category.ID)
+	}
+	translations, err := pdb.getTranslations(ctx, "category_translations", "category_id", locale, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	for i := range categories {
+		t := translations[fmt.Sprint(categories[i].ID)]
+		categories[i].Name = translated(t.Name, categories[i].Name)
+		categories[i].Description = translated(t.Description, categories[i].Description)
+	}
+	return nil
+}
+
+// localizeSellers แทนคำอธิบายร้านค้าด้วยคำแปลตามภาษาใน ctx ชื่อร้านไม่แปล
+func (pdb *PostgresDatabase) localizeSellers(ctx context.Context, sellers []Seller) error {
+	locale := localeFrom(ctx)
+	if locale == "" || len(sellers) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(sellers))
+	for i, seller := range sellers {
+		ids[i] = seller.SellerID
+	}
+
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT seller_id, description
+		FROM seller_translations
+		WHERE locale = $1 AND seller_id = ANY($2::uuid[]) AND COALESCE(description, '') <> ''
+	`, locale, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to get shop translations: %w", err)
+	}
+	defer rows.Close()
+
+	descriptions := map[string]string{}
+	for rows.Next() {
+		var id, description string
+		if err := rows.Scan(&id, &description); err != nil {
+			return fmt.Errorf("failed to scan shop translation: %w", err)
+		}
+		descriptions[id] = description
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	for i := range sellers {
+		sellers[i].Description = translated(descriptions[sellers[i].SellerID], sellers[i].Description)
+	}
+	return nil
+}
+
+// localizedProductNames คืนชื่อสินค้าตามภาษาใน ctx เฉพาะสินค้าที่มีคำแปล ใช้กับตะกร้าและ wishlist
+func (pdb *PostgresDatabase) localizedProductNames(ctx context.Context, productIDs []string) (map[string]string, error) {
+	names := map[string]string{}
+	locale := localeFrom(ctx)
+	if locale == "" || len(productIDs) == 0 {
+		return names, nil
+	}
+
+	translations, err := pdb.getTranslations(ctx, "product_translations", "product_id", locale, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	for id, t := range translations {
+		if t.Name != "" {
+			names[id] = t.Name
+		}
+	}
+	return names, nil
+}
+
+// getProductTranslations คืนคำแปลทุกภาษาของสินค้า ให้ผู้ขายเห็นค่าปัจจุบันก่อนแก้ไข
+func (pdb *PostgresDatabase) getProductTranslations(ctx context.Context, productID string) (map[string]Translation, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT locale, COALESCE(name, ''), COALESCE(description, '')
+		FROM product_translations
+		WHERE product_id = $1
+	`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product translations: %w", err)
+	}
+	defer rows.Close()
+
+	translations := map[string]Translation{}
+	for rows.Next() {
+		var locale string
+		var t Translation
+		if err := rows.Scan(&locale, &t.Name, &t.Description); err != nil {
+			return nil, fmt.Errorf("failed to scan product translation: %w", err)
+		}
+		translations[locale] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return translations, nil
+}
+
+// saveProductTranslations เพิ่มหรือแก้ไขคำแปลของสินค้า คำแปลที่ว่างทั้งชื่อและคำอธิบายจะถูกลบ
+func saveProductTranslations(ctx context.Context, tx *sql.Tx, productID string, translations map[string]Translation) error {
+	for locale, t := range translations {
+		var err error
+		if t.Name == "" && t.Description == "" {
+			_, err = tx.ExecContext(ctx, `
+				DELETE FROM product_translations WHERE product_id = $1 AND locale = $2
+			`, productID, locale)
+		} else {
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO product_translations (product_id, locale, name, description)
+				VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''))
+				ON CONFLICT (product_id, locale)
+				DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description, updated_at = NOW()
+			`, productID, locale, t.Name, t.Description)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to save product translation: %w", err)
+		}
+	}
+	return nil
+}
+
+func translated(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
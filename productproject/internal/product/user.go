@@ -0,0 +1,45 @@
+// user.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// บทบาทของผู้ใช้ ตรงกับ ENUM user_role
+const (
+	RoleCustomer = "customer"
+	RoleSeller   = "seller"
+	RoleAdmin    = "admin"
+)
+
+type User struct {
+	UserID   string `json:"user_id"`
+	Email    string `json:"email"`
+	FullName string `json:"full_name"`
+	Role     string `json:"role"`     // 'customer', 'seller', 'admin'
+	Timezone string `json:"timezone"` // ชื่อ IANA เช่น Asia/Bangkok ว่างได้ถ้าผู้ใช้ยังไม่ได้ตั้งค่า
+}
+
+// GetUserBySessionToken ค้นหาผู้ใช้จาก token ของ session ที่ยังไม่หมดอายุ
+func (pdb *PostgresDatabase) GetUserBySessionToken(ctx context.Context, token string) (User, error) {
+	var user User
+	err := pdb.db.QueryRowContext(ctx, `
+		SELECT u.user_id, u.email, u.full_name, u.role, COALESCE(u.timezone, '')
+		FROM user_sessions s
+		JOIN users u ON u.user_id = s.user_id
+		WHERE s.id_token = $1 AND s.expires_at > NOW() AND u.status = 'active'
+	`, token).Scan(&user.UserID, &user.Email, &user.FullName, &user.Role, &user.Timezone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return User{}, notFound("session not found or expired")
+		}
+		return User{}, fmt.Errorf("failed to get user session: %w", err)
+	}
+	return user, nil
+}
+
+func (s *Store) GetUserBySessionToken(ctx context.Context, token string) (User, error) {
+	return s.db.GetUserBySessionToken(ctx, token)
+}
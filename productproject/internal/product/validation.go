@@ -0,0 +1,170 @@
+// validation.go
+package ecommerce
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError คือข้อผิดพลาดของ field เดียว Field เป็นชื่อตาม json tag เพื่อให้หน้าเว็บแสดงข้างช่องกรอกได้
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// skuPattern ขึ้นต้นด้วยตัวอักษรหรือตัวเลข ตามด้วยตัวอักษร ตัวเลข . _ หรือ - ยาวรวมไม่เกิน 100 ตัว (ตามคอลัมน์ sku)
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,99}$`)
+
+// enumTags จับคู่ชื่อ tag กับค่าที่อนุญาตตาม ENUM ในฐานข้อมูล ชื่อ tag ตรงกับชื่อ type ใน init.sql
+var enumTags = map[string][]string{
+	"product_availability":   productAvailabilities,
+	"product_recommendation": productRecommendations,
+	"product_type":           productTypes,
+	"translation_locale":     translationLocales,
+	"promotion_type":         promotionTypes,
+	"promotion_scope":        promotionScopes,
+	"shipping_rate_type":     shippingRateTypes,
+	"return_reason":          returnReasons,
+}
+
+// validate อ่านกฎจาก binding tag ชุดเดียวกับที่ gin ใช้ตอน bind request
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	// Money ตรวจด้วย gte/lte ได้เหมือนตัวเลข ค่าที่แปลงเป็น float ใช้เทียบขอบเขตเท่านั้น ไม่ใช้คำนวณ
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		value, _ := strconv.ParseFloat(field.Interface().(Money).String(), 64)
+		return value
+	}, Money{})
+
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	v.RegisterValidation("sku", func(fl validator.FieldLevel) bool {
+		return skuPattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("rawjson", func(fl validator.FieldLevel) bool {
+		return json.Valid(fl.Field().Bytes())
+	})
+	v.RegisterValidation("promotion_code", func(fl validator.FieldLevel) bool {
+		return promotionCodePattern.MatchString(fl.Field().String())
+	})
+	v.RegisterValidation("percent", func(fl validator.FieldLevel) bool {
+		return validPercent(fl.Field().String())
+	})
+	v.RegisterValidation("currency_code", func(fl validator.FieldLevel) bool {
+		return validCurrencyCode(fl.Field().String())
+	})
+	v.RegisterValidation("exchange_rate", func(fl validator.FieldLevel) bool {
+		return validExchangeRate(fl.Field().String())
+	})
+	v.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		return phonePattern.MatchString(fl.Field().String())
+	})
+	for tag, values := range enumTags {
+		values := values
+		v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return containsID(values, fl.Field().String())
+		})
+	}
+	return v
+}
+
+// ValidateStruct ตรวจ struct ตาม binding tag และคืน *Error ชนิด ErrValidation พร้อมรายการ field ที่ไม่ผ่าน
+// ค่าที่ไม่ใช่ struct จะผ่านเสมอ
+func ValidateStruct(obj interface{}) error {
+	value := reflect.ValueOf(obj)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	err := validate.Struct(value.Interface())
+	if err == nil {
+		return nil
+	}
+	validationErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, FieldError{Field: fe.Field(), Message: fieldMessage(fe)})
+	}
+	return invalidFields(fields...)
+}
+
+// invalidFields รวม field ที่ไม่ผ่านเป็น error เดียว ข้อความหลักมาจาก field แรก
+func invalidFields(fields ...FieldError) error {
+	return &Error{
+		Kind:    ErrValidation,
+		Message: fmt.Sprintf("%s %s", fields[0].Field, fields[0].Message),
+		Fields:  fields,
+	}
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	if values, ok := enumTags[fe.Tag()]; ok {
+		return "must be one of " + strings.Join(values, ", ")
+	}
+
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required", "notblank":
+		return "is required"
+	case "max", "lte":
+		if isString {
+			return fmt.Sprintf("must not exceed %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "min", "gte":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "uuid":
+		return "must be a valid UUID"
+	case "http_url":
+		return "must be a valid http or https URL"
+	case "sku":
+		return "must be at most 100 letters, digits, '.', '_' or '-' and start with a letter or digit"
+	case "rawjson":
+		return "must be valid JSON"
+	case "promotion_code":
+		return "must be 3-50 letters, digits, '_' or '-'"
+	case "percent":
+		return "must be a number greater than 0 and at most 100 with at most 2 decimal places"
+	case "currency_code":
+		return "must be a 3-letter ISO 4217 code other than " + DefaultCurrency
+	case "exchange_rate":
+		return "must be a positive decimal with at most 6 decimal places"
+	case "len":
+		return fmt.Sprintf("must be exactly %s characters", fe.Param())
+	case "numeric", "number":
+		return "must contain only digits"
+	case "phone":
+		return "must be a Thai phone number starting with 0, such as 0812345678"
+	}
+	return "is invalid"
+}
@@ -0,0 +1,513 @@
+// variant.go
+package ecommerce
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// VariantOption คือแกนของตัวเลือก เช่น Name "น้ำหนัก" กับ Values ["2kg", "10kg"]
+type VariantOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type ProductVariant struct {
+	ID             string            `json:"id"`
+	ProductID      string            `json:"product_id"`
+	SKU            string            `json:"sku"`
+	Price          *Money            `json:"price"`           // ราคาเฉพาะตัวเลือก ถ้าเป็น null จะใช้ราคาของสินค้าหลัก
+	EffectivePrice Money             `json:"effective_price"` // ราคาที่ใช้ขายจริง
+	Options        map[string]string `json:"options"`         // เช่น {"น้ำหนัก": "2kg"}
+	IsActive       bool              `json:"is_active"`
+	SortOrder      int               `json:"sort_order"`
+	Inventory      Inventory         `json:"inventory"`
+	ImageIDs       []string          `json:"image_ids"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+type ProductVariants struct {
+	Options  []VariantOption  `json:"options"`
+	Variants []ProductVariant `json:"variants"`
+}
+
+type NewProductVariant struct {
+	SKU       string            `json:"sku"`
+	Price     *Money            `json:"price"`
+	Options   map[string]string `json:"options"`
+	Quantity  int               `json:"quantity"`
+	IsActive  *bool             `json:"is_active"` // ค่าเริ่มต้นคือ true
+	SortOrder int               `json:"sort_order"`
+	ImageIDs  []string          `json:"image_ids"`
+}
+
+// UpdateProductVariant แก้ไขเฉพาะ field ที่ส่งมา
+type UpdateProductVariant struct {
+	SKU             *string           `json:"sku"`
+	Price           *Money            `json:"price"`
+	UseProductPrice bool              `json:"use_product_price"` // ล้างราคาเฉพาะตัวเลือกให้กลับไปใช้ราคาของสินค้าหลัก
+	Options         map[string]string `json:"options"`
+	Quantity        *int              `json:"quantity"`
+	IsActive        *bool             `json:"is_active"`
+	SortOrder       *int              `json:"sort_order"`
+	ImageIDs        []string          `json:"image_ids"` // ถ้าส่งมาจะแทนที่รูปเดิมของตัวเลือกทั้งหมด
+}
+
+// ValidateVariantOptions ตรวจว่า options ระบุค่าครบทุกแกนและเป็นค่าที่แกนนั้นอนุญาต
+func ValidateVariantOptions(axes []VariantOption, options map[string]string) error {
+	if len(axes) == 0 {
+		return invalid("variant options must be defined before adding variants")
+	}
+	if len(options) != len(axes) {
+		return invalid("options must specify exactly one value for each of: %s", axisNames(axes))
+	}
+	for _, axis := range axes {
+		value, ok := options[axis.Name]
+		if !ok {
+			return invalid("options must specify exactly one value for each of: %s", axisNames(axes))
+		}
+		if !containsID(axis.Values, value) {
+			return invalid("%q is not a valid value for option %q", value, axis.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateVariantAxes ตรวจชื่อแกนและค่าของแกนไม่ให้ว่างหรือซ้ำกัน
+func ValidateVariantAxes(axes []VariantOption) error {
+	names := make(map[string]bool, len(axes))
+	for _, axis := range axes {
+		if strings.TrimSpace(axis.Name) == "" {
+			return invalid("option name is required")
+		}
+		if names[axis.Name] {
+			return invalid("duplicate option name %q", axis.Name)
+		}
+		names[axis.Name] = true
+
+		if len(axis.Values) == 0 {
+			return invalid("option %q must have at least one value", axis.Name)
+		}
+		values := make(map[string]bool, len(axis.Values))
+		for _, value := range axis.Values {
+			if strings.TrimSpace(value) == "" || values[value] {
+				return invalid("option %q has an empty or duplicate value", axis.Name)
+			}
+			values[value] = true
+		}
+	}
+	return nil
+}
+
+func axisNames(axes []VariantOption) string {
+	names := make([]string, len(axes))
+	for i, axis := range axes {
+		names[i] = axis.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+const productVariantColumns = `v.variant_id, v.product_id, v.sku, v.price, COALESCE(v.price, aps.sale_price, p.price), v.option_values,
+		       v.is_active, v.sort_order, COALESCE(vi.quantity, 0), COALESCE(vi.updated_at, v.updated_at),
+		       ARRAY(SELECT pi.image_id FROM product_images pi WHERE pi.variant_id = v.variant_id ORDER BY pi.sort_order),
+		       v.created_at, v.updated_at`
+
+const productVariantJoins = `product_variants v
+		JOIN products p ON p.product_id = v.product_id
+		LEFT JOIN variant_inventory vi ON vi.variant_id = v.variant_id
+		LEFT JOIN active_product_sales aps ON aps.product_id = p.product_id`
+
+func scanProductVariant(row rowScanner) (ProductVariant, error) {
+	var variant ProductVariant
+	var options []byte
+	err := row.Scan(
+		&variant.ID, &variant.ProductID, &variant.SKU, &variant.Price, &variant.EffectivePrice, &options,
+		&variant.IsActive, &variant.SortOrder, &variant.Inventory.Quantity, &variant.Inventory.UpdatedAt,
+		pq.Array(&variant.ImageIDs), &variant.CreatedAt, &variant.UpdatedAt)
+	if err != nil {
+		return ProductVariant{}, err
+	}
+	if err := json.Unmarshal(options, &variant.Options); err != nil {
+		return ProductVariant{}, err
+	}
+	if variant.ImageIDs == nil {
+		variant.ImageIDs = []string{}
+	}
+	return variant, nil
+}
+
+func (pdb *PostgresDatabase) GetProductVariants(ctx context.Context, productID string) (ProductVariants, error) {
+	options, err := getVariantOptions(ctx, pdb.db, productID)
+	if err != nil {
+		return ProductVariants{}, err
+	}
+
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT `+productVariantColumns+`
+		FROM `+productVariantJoins+`
+		WHERE v.product_id = $1
+		ORDER BY v.sort_order ASC, v.created_at ASC
+	`, productID)
+	if err != nil {
+		return ProductVariants{}, fmt.Errorf("failed to get product variants: %w", err)
+	}
+	defer rows.Close()
+
+	variants := []ProductVariant{}
+	for rows.Next() {
+		variant, err := scanProductVariant(rows)
+		if err != nil {
+			return ProductVariants{}, fmt.Errorf("failed to scan product variant: %w", err)
+		}
+		variants = append(variants, variant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return ProductVariants{}, fmt.Errorf("failed to iterate over product variants: %w", err)
+	}
+
+	return ProductVariants{Options: options, Variants: variants}, nil
+}
+
+func (pdb *PostgresDatabase) GetProductVariant(ctx context.Context, productID, variantID string) (ProductVariant, error) {
+	return getProductVariant(ctx, pdb.db, productID, variantID)
+}
+
+// SetVariantOptions แทนที่แกนของตัวเลือกทั้งหมด ตัวเลือกที่มีอยู่แล้วต้องยังใช้ค่าที่อยู่ในแกนใหม่ได้
+func (pdb *PostgresDatabase) SetVariantOptions(ctx context.Context, productID string, options []VariantOption) ([]VariantOption, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(ctx, tx, productID); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT sku, option_values FROM product_variants WHERE product_id = $1`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product variants: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sku string
+		var data []byte
+		var current map[string]string
+		if err := rows.Scan(&sku, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan product variant: %w", err)
+		}
+		if err := json.Unmarshal(data, &current); err != nil {
+			return nil, fmt.Errorf("failed to decode variant options: %w", err)
+		}
+		if err := ValidateVariantOptions(options, current); err != nil {
+			return nil, conflict("variant %s does not match the new options: %v", sku, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over product variants: %w", err)
+	}
+	rows.Close()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM product_option_axes WHERE product_id = $1`, productID); err != nil {
+		return nil, fmt.Errorf("failed to replace variant options: %w", err)
+	}
+	for i, option := range options {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO product_option_axes (product_id, name, option_values, position)
+			VALUES ($1, $2, $3, $4)
+		`, productID, option.Name, pq.Array(option.Values), i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add variant option: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return getVariantOptions(ctx, pdb.db, productID)
+}
+
+func (pdb *PostgresDatabase) AddProductVariant(ctx context.Context, productID string, variant NewProductVariant) (ProductVariant, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ProductVariant{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(ctx, tx, productID); err != nil {
+		return ProductVariant{}, err
+	}
+
+	axes, err := getVariantOptions(ctx, tx, productID)
+	if err != nil {
+		return ProductVariant{}, err
+	}
+	if err := ValidateVariantOptions(axes, variant.Options); err != nil {
+		return ProductVariant{}, err
+	}
+
+	options, err := json.Marshal(variant.Options)
+	if err != nil {
+		return ProductVariant{}, fmt.Errorf("failed to encode variant options: %w", err)
+	}
+	isActive := true
+	if variant.IsActive != nil {
+		isActive = *variant.IsActive
+	}
+
+	var variantID string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO product_variants (product_id, sku, price, option_values, is_active, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING variant_id
+	`, productID, variant.SKU, variant.Price, options, isActive, variant.SortOrder).Scan(&variantID)
+	if err != nil {
+		return ProductVariant{}, fmt.Errorf("failed to add product variant: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO variant_inventory (variant_id, quantity) VALUES ($1, $2)
+	`, variantID, variant.Quantity)
+	if err != nil {
+		return ProductVariant{}, fmt.Errorf("failed to add variant inventory: %w", err)
+	}
+
+	if variant.ImageIDs != nil {
+		if err := setVariantImages(ctx, tx, productID, variantID, variant.ImageIDs); err != nil {
+			return ProductVariant{}, err
+		}
+	}
+
+	created, err := getProductVariant(ctx, tx, productID, variantID)
+	if err != nil {
+		return ProductVariant{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ProductVariant{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return created, nil
+}
+
+func (pdb *PostgresDatabase) UpdateProductVariant(ctx context.Context, productID, variantID string, update UpdateProductVariant) (ProductVariant, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return ProductVariant{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockProduct(ctx, tx, productID); err != nil {
+		return ProductVariant{}, err
+	}
+	if _, err := getProductVariant(ctx, tx, productID, variantID); err != nil {
+		return ProductVariant{}, err
+	}
+
+	var options []byte
+	if update.Options != nil {
+		axes, err := getVariantOptions(ctx, tx, productID)
+		if err != nil {
+			return ProductVariant{}, err
+		}
+		if err := ValidateVariantOptions(axes, update.Options); err != nil {
+			return ProductVariant{}, err
+		}
+		if options, err = json.Marshal(update.Options); err != nil {
+			return ProductVariant{}, fmt.Errorf("failed to encode variant options: %w", err)
+		}
+	}
+
+	// COALESCE ทำให้ field ที่ไม่ได้ส่งมา (NULL) ยังคงค่าเดิม
+	_, err = tx.ExecContext(ctx, `
+		UPDATE product_variants
+		SET sku = COALESCE($1, sku),
+		    price = CASE WHEN $2 THEN NULL ELSE COALESCE($3, price) END,
+		    option_values = COALESCE($4::jsonb, option_values),
+		    is_active = COALESCE($5, is_active),
+		    sort_order = COALESCE($6, sort_order),
+		    updated_at = NOW()
+		WHERE product_id = $7 AND variant_id = $8
+	`, update.SKU, update.UseProductPrice, update.Price, nullBytes(options), update.IsActive, update.SortOrder, productID, variantID)
+	if err != nil {
+		return ProductVariant{}, fmt.Errorf("failed to update product variant: %w", err)
+	}
+
+	if update.Quantity != nil {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO variant_inventory (variant_id, quantity) VALUES ($1, $2)
+			ON CONFLICT (variant_id) DO UPDATE SET quantity = EXCLUDED.quantity, updated_at = NOW()
+		`, variantID, *update.Quantity)
+		if err != nil {
+			return ProductVariant{}, fmt.Errorf("failed to update variant inventory: %w", err)
+		}
+	}
+
+	if update.ImageIDs != nil {
+		if err := setVariantImages(ctx, tx, productID, variantID, update.ImageIDs); err != nil {
+			return ProductVariant{}, err
+		}
+	}
+
+	updated, err := getProductVariant(ctx, tx, productID, variantID)
+	if err != nil {
+		return ProductVariant{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ProductVariant{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return updated, nil
+}
+
+func (pdb *PostgresDatabase) DeleteProductVariant(ctx context.Context, productID, variantID string) error {
+	result, err := pdb.db.ExecContext(ctx, `
+		DELETE FROM product_variants WHERE product_id = $1 AND variant_id = $2
+	`, productID, variantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete product variant: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFound("product variant not found")
+	}
+
+	return nil
+}
+
+// queryer ใช้ได้ทั้ง *sql.DB และ *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func getProductVariant(ctx context.Context, q queryer, productID, variantID string) (ProductVariant, error) {
+	variant, err := scanProductVariant(q.QueryRowContext(ctx, `
+		SELECT `+productVariantColumns+`
+		FROM `+productVariantJoins+`
+		WHERE v.product_id = $1 AND v.variant_id = $2
+	`, productID, variantID))
+	if err == sql.ErrNoRows {
+		return ProductVariant{}, notFound("product variant not found")
+	}
+	if err != nil {
+		return ProductVariant{}, fmt.Errorf("failed to get product variant: %w", err)
+	}
+	return variant, nil
+}
+
+func getVariantOptions(ctx context.Context, q queryer, productID string) ([]VariantOption, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT name, option_values
+		FROM product_option_axes
+		WHERE product_id = $1
+		ORDER BY position ASC
+	`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variant options: %w", err)
+	}
+	defer rows.Close()
+
+	options := []VariantOption{}
+	for rows.Next() {
+		var option VariantOption
+		if err := rows.Scan(&option.Name, pq.Array(&option.Values)); err != nil {
+			return nil, fmt.Errorf("failed to scan variant option: %w", err)
+		}
+		options = append(options, option)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over variant options: %w", err)
+	}
+
+	return options, nil
+}
+
+// setVariantImages ผูกรูปของสินค้าเข้ากับตัวเลือก รูปที่ไม่ได้อยู่ในรายการจะถูกปลดออกจากตัวเลือกนี้
+func setVariantImages(ctx context.Context, tx *sql.Tx, productID, variantID string, imageIDs []string) error {
+	ids := make([]string, 0, len(imageIDs))
+	for _, id := range imageIDs {
+		if !containsID(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	var matched int
+	err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM product_images WHERE product_id = $1 AND image_id = ANY($2::uuid[])
+	`, productID, pq.Array(ids)).Scan(&matched)
+	if err != nil {
+		return fmt.Errorf("failed to get product images: %w", err)
+	}
+	if matched != len(ids) {
+		return invalid("image_ids must be images of this product")
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE product_images
+		SET variant_id = CASE WHEN image_id = ANY($3::uuid[]) THEN $2::uuid ELSE NULL END,
+		    updated_at = NOW()
+		WHERE product_id = $1 AND (variant_id = $2 OR image_id = ANY($3::uuid[]))
+	`, productID, variantID, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to update variant images: %w", err)
+	}
+	return nil
+}
+
+// lockProduct ล็อกแถวของสินค้าไว้จนจบ transaction เพื่อให้แก้ไขข้อมูลย่อยของสินค้าเดียวกันทีละคำขอ
+func lockProduct(ctx context.Context, tx *sql.Tx, productID string) error {
+	var exists int
+	err := tx.QueryRowContext(ctx, `SELECT 1 FROM products WHERE product_id = $1 FOR UPDATE`, productID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return notFound("product not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock product: %w", err)
+	}
+	return nil
+}
+
+func nullBytes(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
+
+func (s *Store) GetProductVariants(ctx context.Context, productID string) (ProductVariants, error) {
+	return s.db.GetProductVariants(ctx, productID)
+}
+
+func (s *Store) GetProductVariant(ctx context.Context, productID, variantID string) (ProductVariant, error) {
+	return s.db.GetProductVariant(ctx, productID, variantID)
+}
+
+func (s *Store) SetVariantOptions(ctx context.Context, productID string, options []VariantOption) ([]VariantOption, error) {
+	return s.db.SetVariantOptions(ctx, productID, options)
+}
+
+func (s *Store) AddProductVariant(ctx context.Context, productID string, variant NewProductVariant) (ProductVariant, error) {
+	return s.db.AddProductVariant(ctx, productID, variant)
+}
+
+func (s *Store) UpdateProductVariant(ctx context.Context, productID, variantID string, update UpdateProductVariant) (ProductVariant, error) {
+	return s.db.UpdateProductVariant(ctx, productID, variantID, update)
+}
+
+func (s *Store) DeleteProductVariant(ctx context.Context, productID, variantID string) error {
+	return s.db.DeleteProductVariant(ctx, productID, variantID)
+}
@@ -0,0 +1,353 @@
+// wishlist.go
+package ecommerce
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+type Wishlist struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	ShareToken string         `json:"share_token,omitempty"` // แสดงเฉพาะเจ้าของรายการ
+	Items      []WishlistItem `json:"items"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// WishlistItem แสดงราคาและสต็อกปัจจุบันของสินค้า ไม่ใช่ค่า ณ เวลาที่เพิ่มเข้ารายการ
+// ระหว่างช่วงลดราคา Price คือราคาลดและ OriginalPrice คือราคาปกติ เช่นเดียวกับ ProductItem
+type WishlistItem struct {
+	ProductID     string    `json:"product_id"`
+	Name          string    `json:"name"`
+	Price         Money     `json:"price"`
+	OriginalPrice *Money    `json:"original_price,omitempty"`
+	Availability  string    `json:"availability"`
+	Quantity      int       `json:"quantity"`
+	InStock       bool      `json:"in_stock"`
+	ImageURL      string    `json:"image_url"`
+	AddedAt       time.Time `json:"added_at"`
+}
+
+type NewWishlist struct {
+	Name string `json:"name"`
+}
+
+type NewWishlistItem struct {
+	ProductID string `json:"product_id"`
+}
+
+func (pdb *PostgresDatabase) GetWishlists(ctx context.Context, userID string) ([]Wishlist, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT wishlist_id, name, COALESCE(share_token, ''), created_at, updated_at
+		FROM wishlists
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlists: %w", err)
+	}
+	defer rows.Close()
+
+	wishlists := []Wishlist{}
+	for rows.Next() {
+		var wishlist Wishlist
+		if err := rows.Scan(&wishlist.ID, &wishlist.Name, &wishlist.ShareToken,
+			&wishlist.CreatedAt, &wishlist.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan wishlist: %w", err)
+		}
+		wishlists = append(wishlists, wishlist)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over wishlists: %w", err)
+	}
+
+	for i := range wishlists {
+		wishlists[i].Items, err = pdb.getWishlistItems(ctx, wishlists[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return wishlists, nil
+}
+
+func (pdb *PostgresDatabase) GetWishlist(ctx context.Context, userID, wishlistID string) (Wishlist, error) {
+	var wishlist Wishlist
+	err := pdb.db.QueryRowContext(ctx, `
+		SELECT wishlist_id, name, COALESCE(share_token, ''), created_at, updated_at
+		FROM wishlists
+		WHERE wishlist_id = $1 AND user_id = $2
+	`, wishlistID, userID).Scan(&wishlist.ID, &wishlist.Name, &wishlist.ShareToken,
+		&wishlist.CreatedAt, &wishlist.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Wishlist{}, notFound("wishlist not found")
+		}
+		return Wishlist{}, fmt.Errorf("failed to get wishlist: %w", err)
+	}
+
+	wishlist.Items, err = pdb.getWishlistItems(ctx, wishlist.ID)
+	if err != nil {
+		return Wishlist{}, err
+	}
+
+	return wishlist, nil
+}
+
+// GetSharedWishlist ดึงรายการที่แชร์ผ่าน token แบบอ่านอย่างเดียว
+func (pdb *PostgresDatabase) GetSharedWishlist(ctx context.Context, shareToken string) (Wishlist, error) {
+	var wishlist Wishlist
+	err := pdb.db.QueryRowContext(ctx, `
+		SELECT wishlist_id, name, created_at, updated_at
+		FROM wishlists
+		WHERE share_token = $1
+	`, shareToken).Scan(&wishlist.ID, &wishlist.Name, &wishlist.CreatedAt, &wishlist.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Wishlist{}, notFound("wishlist not found")
+		}
+		return Wishlist{}, fmt.Errorf("failed to get shared wishlist: %w", err)
+	}
+
+	wishlist.Items, err = pdb.getWishlistItems(ctx, wishlist.ID)
+	if err != nil {
+		return Wishlist{}, err
+	}
+
+	return wishlist, nil
+}
+
+func (pdb *PostgresDatabase) CreateWishlist(ctx context.Context, userID string, wishlist NewWishlist) (Wishlist, error) {
+	var created Wishlist
+	err := pdb.db.QueryRowContext(ctx, `
+		INSERT INTO wishlists (user_id, name)
+		VALUES ($1, $2)
+		RETURNING wishlist_id, name, created_at, updated_at
+	`, userID, wishlist.Name).Scan(&created.ID, &created.Name, &created.CreatedAt, &created.UpdatedAt)
+	if err != nil {
+		return Wishlist{}, fmt.Errorf("failed to create wishlist: %w", err)
+	}
+	created.Items = []WishlistItem{}
+	return created, nil
+}
+
+func (pdb *PostgresDatabase) DeleteWishlist(ctx context.Context, userID, wishlistID string) error {
+	result, err := pdb.db.ExecContext(ctx, `
+		DELETE FROM wishlists WHERE wishlist_id = $1 AND user_id = $2
+	`, wishlistID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete wishlist: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFound("wishlist not found")
+	}
+
+	return nil
+}
+
+func (pdb *PostgresDatabase) AddWishlistItem(ctx context.Context, userID, wishlistID string, item NewWishlistItem) (Wishlist, error) {
+	if err := pdb.checkWishlistOwner(ctx, userID, wishlistID); err != nil {
+		return Wishlist{}, err
+	}
+
+	// เพิ่มสินค้าซ้ำจะไม่เกิดข้อผิดพลาด
+	_, err := pdb.db.ExecContext(ctx, `
+		INSERT INTO wishlist_items (wishlist_id, product_id)
+		VALUES ($1, $2)
+		ON CONFLICT (wishlist_id, product_id) DO NOTHING
+	`, wishlistID, item.ProductID)
+	if err != nil {
+		return Wishlist{}, fmt.Errorf("failed to add wishlist item: %w", err)
+	}
+
+	return pdb.GetWishlist(ctx, userID, wishlistID)
+}
+
+func (pdb *PostgresDatabase) RemoveWishlistItem(ctx context.Context, userID, wishlistID, productID string) (Wishlist, error) {
+	if err := pdb.checkWishlistOwner(ctx, userID, wishlistID); err != nil {
+		return Wishlist{}, err
+	}
+
+	result, err := pdb.db.ExecContext(ctx, `
+		DELETE FROM wishlist_items WHERE wishlist_id = $1 AND product_id = $2
+	`, wishlistID, productID)
+	if err != nil {
+		return Wishlist{}, fmt.Errorf("failed to remove wishlist item: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return Wishlist{}, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return Wishlist{}, notFound("wishlist item not found")
+	}
+
+	return pdb.GetWishlist(ctx, userID, wishlistID)
+}
+
+// ShareWishlist สร้าง token ใหม่ทุกครั้ง ลิงก์เดิมจะใช้ไม่ได้อีก
+func (pdb *PostgresDatabase) ShareWishlist(ctx context.Context, userID, wishlistID string) (Wishlist, error) {
+	token, err := newShareToken()
+	if err != nil {
+		return Wishlist{}, err
+	}
+
+	if err := pdb.setWishlistShareToken(ctx, userID, wishlistID, sql.NullString{String: token, Valid: true}); err != nil {
+		return Wishlist{}, err
+	}
+
+	return pdb.GetWishlist(ctx, userID, wishlistID)
+}
+
+func (pdb *PostgresDatabase) UnshareWishlist(ctx context.Context, userID, wishlistID string) (Wishlist, error) {
+	if err := pdb.setWishlistShareToken(ctx, userID, wishlistID, sql.NullString{}); err != nil {
+		return Wishlist{}, err
+	}
+
+	return pdb.GetWishlist(ctx, userID, wishlistID)
+}
+
+func (pdb *PostgresDatabase) setWishlistShareToken(ctx context.Context, userID, wishlistID string, token sql.NullString) error {
+	result, err := pdb.db.ExecContext(ctx, `
+		UPDATE wishlists SET share_token = $1 WHERE wishlist_id = $2 AND user_id = $3
+	`, token, wishlistID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update wishlist sharing: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFound("wishlist not found")
+	}
+
+	return nil
+}
+
+func (pdb *PostgresDatabase) checkWishlistOwner(ctx context.Context, userID, wishlistID string) error {
+	var exists bool
+	err := pdb.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM wishlists WHERE wishlist_id = $1 AND user_id = $2)
+	`, wishlistID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to get wishlist: %w", err)
+	}
+	if !exists {
+		return notFound("wishlist not found")
+	}
+	return nil
+}
+
+func (pdb *PostgresDatabase) getWishlistItems(ctx context.Context, wishlistID string) ([]WishlistItem, error) {
+	rows, err := pdb.db.QueryContext(ctx, `
+		SELECT p.product_id, p.name, COALESCE(aps.sale_price, p.price),
+		       CASE WHEN aps.sale_price IS NOT NULL THEN p.price END,
+		       p.availability, COALESCE(i.quantity, 0),
+		       COALESCE((
+		           SELECT pi.image_url FROM product_images pi
+		           WHERE pi.product_id = p.product_id
+		           ORDER BY pi.is_primary DESC, pi.sort_order ASC
+		           LIMIT 1
+		       ), ''),
+		       w.added_at
+		FROM wishlist_items w
+		JOIN products p ON p.product_id = w.product_id
+		LEFT JOIN inventory i ON i.product_id = p.product_id`+activeSaleJoin+`
+		WHERE w.wishlist_id = $1
+		ORDER BY w.added_at DESC
+	`, wishlistID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wishlist items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []WishlistItem{}
+	for rows.Next() {
+		var item WishlistItem
+		if err := rows.Scan(&item.ProductID, &item.Name, &item.Price, &item.OriginalPrice, &item.Availability,
+			&item.Quantity, &item.ImageURL, &item.AddedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan wishlist item: %w", err)
+		}
+		item.InStock = item.Availability == "active" && item.Quantity > 0
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over wishlist items: %w", err)
+	}
+
+	productIDs := make([]string, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	names, err := pdb.localizedProductNames(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Name = translated(names[items[i].ProductID], items[i].Name)
+	}
+
+	return items, nil
+}
+
+func newShareToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate share token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *Store) GetWishlists(ctx context.Context, userID string) ([]Wishlist, error) {
+	return s.db.GetWishlists(ctx, userID)
+}
+
+func (s *Store) GetWishlist(ctx context.Context, userID, wishlistID string) (Wishlist, error) {
+	return s.db.GetWishlist(ctx, userID, wishlistID)
+}
+
+func (s *Store) GetSharedWishlist(ctx context.Context, shareToken string) (Wishlist, error) {
+	return s.db.GetSharedWishlist(ctx, shareToken)
+}
+
+func (s *Store) CreateWishlist(ctx context.Context, userID string, wishlist NewWishlist) (Wishlist, error) {
+	return s.db.CreateWishlist(ctx, userID, wishlist)
+}
+
+func (s *Store) DeleteWishlist(ctx context.Context, userID, wishlistID string) error {
+	return s.db.DeleteWishlist(ctx, userID, wishlistID)
+}
+
+func (s *Store) AddWishlistItem(ctx context.Context, userID, wishlistID string, item NewWishlistItem) (Wishlist, error) {
+	return s.db.AddWishlistItem(ctx, userID, wishlistID, item)
+}
+
+func (s *Store) RemoveWishlistItem(ctx context.Context, userID, wishlistID, productID string) (Wishlist, error) {
+	return s.db.RemoveWishlistItem(ctx, userID, wishlistID, productID)
+}
+
+func (s *Store) ShareWishlist(ctx context.Context, userID, wishlistID string) (Wishlist, error) {
+	return s.db.ShareWishlist(ctx, userID, wishlistID)
+}
+
+func (s *Store) UnshareWishlist(ctx context.Context, userID, wishlistID string) (Wishlist, error) {
+	return s.db.UnshareWishlist(ctx, userID, wishlistID)
+}
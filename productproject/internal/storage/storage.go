@@ -0,0 +1,39 @@
+// storage.go
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Storage เก็บไฟล์ที่อัปโหลดและคืน URL สาธารณะของไฟล์
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (string, error)
+	Delete(ctx context.Context, key string) error
+	// KeyFromURL แปลง URL ที่ Put คืนมากลับเป็น key (คืน false ถ้าไม่ใช่ไฟล์ของ storage นี้)
+	KeyFromURL(url string) (string, bool)
+}
+
+type Config struct {
+	Driver    string // 'local' หรือ 's3'
+	LocalDir  string
+	PublicURL string // URL ที่ใช้นำหน้า key ค่าเริ่มต้นคือ "/uploads" (local) หรือ endpoint/bucket (s3)
+
+	S3Endpoint  string // เช่น 'http://localhost:9000' สำหรับ MinIO
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStorage(cfg.LocalDir, cfg.PublicURL)
+	case "s3":
+		return NewS3Storage(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey, cfg.PublicURL)
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.Driver)
+	}
+}
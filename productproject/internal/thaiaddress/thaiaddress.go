@@ -0,0 +1,227 @@
+// thaiaddress.go
+package thaiaddress
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//go:embed data/thai_provinces.csv
+var provincesCSV []byte
+
+//go:embed data/thai_addresses.csv
+var datasetCSV []byte
+
+// Location คือตำบล/แขวงหนึ่งแห่งพร้อมรหัสไปรษณีย์ ชื่อเป็นภาษาไทยแบบไม่มีคำนำหน้า
+type Location struct {
+	Province    string `json:"province"`
+	District    string `json:"district"`
+	Subdistrict string `json:"subdistrict"`
+	Postcode    string `json:"postcode"`
+}
+
+// FieldError บอกว่าส่วนใดของที่อยู่ไม่ตรงกับชุดข้อมูล Field เป็นชื่อ json ของ field นั้น
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string { return e.Field + " " + e.Message }
+
+// ErrNotCovered หมายถึงชุดข้อมูลยังไม่มีอำเภอและตำบลของจังหวัดนี้
+// ชื่อจังหวัดและเลขนำหน้าของรหัสไปรษณีย์ถูกตรวจแล้ว แต่ตรวจอำเภอและตำบลไม่ได้
+var ErrNotCovered = errors.New("province is not covered by the address dataset")
+
+type dataset struct {
+	// postcodePrefixes[จังหวัด] คือเลขสองหลักแรกของรหัสไปรษณีย์ทุกแห่งในจังหวัดนั้น
+	postcodePrefixes map[string]string
+	// provinces[จังหวัด][อำเภอ][ตำบล] คือรหัสไปรษณีย์ของตำบลนั้น
+	provinces  map[string]map[string]map[string][]string
+	byPostcode map[string][]Location
+}
+
+var data = mustLoad(provincesCSV, datasetCSV)
+
+var postcodePattern = regexp.MustCompile(`^[1-9][0-9]{4}$`)
+
+func mustLoad(provinces, addresses []byte) *dataset {
+	d, err := load(bytes.NewReader(provinces), bytes.NewReader(addresses))
+	if err != nil {
+		panic(fmt.Sprintf("thaiaddress: invalid embedded dataset: %v", err))
+	}
+	return d
+}
+
+func load(provinces, addresses io.Reader) (*dataset, error) {
+	d := &dataset{
+		postcodePrefixes: map[string]string{},
+		provinces:        map[string]map[string]map[string][]string{},
+		byPostcode:       map[string][]Location{},
+	}
+
+	err := readCSV(provinces, "province,postcode_prefix", func(record []string) error {
+		if len(record[1]) != 2 || !postcodePattern.MatchString(record[1]+"000") {
+			return fmt.Errorf("invalid postcode prefix %q for %s", record[1], record[0])
+		}
+		d.postcodePrefixes[record[0]] = record[1]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readCSV(addresses, "province,district,subdistrict,postcode", func(record []string) error {
+		loc := Location{Province: record[0], District: record[1], Subdistrict: record[2], Postcode: record[3]}
+		prefix, ok := d.postcodePrefixes[loc.Province]
+		if !ok {
+			return fmt.Errorf("unknown province %q", loc.Province)
+		}
+		if !postcodePattern.MatchString(loc.Postcode) || !strings.HasPrefix(loc.Postcode, prefix) {
+			return fmt.Errorf("invalid postcode %q for %s", loc.Postcode, loc.Subdistrict)
+		}
+
+		districts, ok := d.provinces[loc.Province]
+		if !ok {
+			districts = map[string]map[string][]string{}
+			d.provinces[loc.Province] = districts
+		}
+		subdistricts, ok := districts[loc.District]
+		if !ok {
+			subdistricts = map[string][]string{}
+			districts[loc.District] = subdistricts
+		}
+		subdistricts[loc.Subdistrict] = append(subdistricts[loc.Subdistrict], loc.Postcode)
+		d.byPostcode[loc.Postcode] = append(d.byPostcode[loc.Postcode], loc)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// readCSV อ่านไฟล์ CSV ที่ขึ้นต้นด้วย header ตามที่กำหนด บรรทัดที่ขึ้นต้นด้วย # เป็นคำอธิบาย
+func readCSV(r io.Reader, header string, fn func(record []string) error) error {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = strings.Count(header, ",") + 1
+
+	record, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	if strings.Join(record, ",") != header {
+		return fmt.Errorf("unexpected header %v", record)
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// provinceAliases คือชื่อย่อที่ผู้ใช้มักกรอก
+var provinceAliases = map[string]string{
+	"กรุงเทพ":  "กรุงเทพมหานคร",
+	"กรุงเทพฯ": "กรุงเทพมหานคร",
+	"กทม":      "กรุงเทพมหานคร",
+	"กทม.":     "กรุงเทพมหานคร",
+}
+
+// คำนำหน้าที่ตัดออกก่อนเทียบชื่อ เรียงจากยาวไปสั้นเพื่อไม่ให้ตัดคำสั้นก่อน
+var (
+	provincePrefixes    = []string{"จังหวัด", "จ."}
+	districtPrefixes    = []string{"กิ่งอำเภอ", "อำเภอ", "เขต", "อ."}
+	subdistrictPrefixes = []string{"ตำบล", "แขวง", "ต."}
+)
+
+// normalize ตัดช่องว่างและคำนำหน้า เช่น "แขวง ลุมพินี" เป็น "ลุมพินี"
+func normalize(name string, prefixes []string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, name)
+	for _, prefix := range prefixes {
+		if trimmed := strings.TrimPrefix(name, prefix); trimmed != name && trimmed != "" {
+			return trimmed
+		}
+	}
+	return name
+}
+
+// normalizeLocation ตัดคำนำหน้าและแปลงชื่อย่อของจังหวัด โดยไม่ตรวจกับชุดข้อมูล
+func normalizeLocation(province, district, subdistrict, postcode string) Location {
+	province = normalize(province, provincePrefixes)
+	if alias, ok := provinceAliases[province]; ok {
+		province = alias
+	}
+	return Location{
+		Province:    province,
+		District:    normalize(district, districtPrefixes),
+		Subdistrict: normalize(subdistrict, subdistrictPrefixes),
+		Postcode:    strings.TrimSpace(postcode),
+	}
+}
+
+// Match ตรวจว่าจังหวัด อำเภอ/เขต ตำบล/แขวง และรหัสไปรษณีย์อยู่ด้วยกันจริง
+// และคืนชื่อตามชุดข้อมูลเพื่อใช้บันทึก ข้อผิดพลาดเป็น *FieldError ของส่วนแรกที่ไม่ตรง
+// ถ้าชุดข้อมูลยังไม่มีอำเภอของจังหวัดนี้ จะคืนชื่อที่ตัดคำนำหน้าแล้วพร้อม ErrNotCovered
+func Match(province, district, subdistrict, postcode string) (Location, error) {
+	loc := normalizeLocation(province, district, subdistrict, postcode)
+	province, district, subdistrict, postcode = loc.Province, loc.District, loc.Subdistrict, loc.Postcode
+
+	prefix, ok := data.postcodePrefixes[province]
+	if !ok {
+		return Location{}, &FieldError{Field: "province", Message: "is not a Thai province"}
+	}
+	if !strings.HasPrefix(postcode, prefix) {
+		return Location{}, &FieldError{Field: "postcode", Message: fmt.Sprintf("is not in %s, postcodes there start with %s", province, prefix)}
+	}
+	districts, ok := data.provinces[province]
+	if !ok {
+		return loc, ErrNotCovered
+	}
+	subdistricts, ok := districts[district]
+	if !ok {
+		return Location{}, &FieldError{Field: "district", Message: "is not in " + province}
+	}
+	postcodes, ok := subdistricts[subdistrict]
+	if !ok {
+		return Location{}, &FieldError{Field: "subdistrict", Message: "is not in " + district}
+	}
+	for _, p := range postcodes {
+		if p == postcode {
+			return Location{Province: province, District: district, Subdistrict: subdistrict, Postcode: postcode}, nil
+		}
+	}
+	return Location{}, &FieldError{Field: "postcode", Message: fmt.Sprintf("does not match %s, expected %s", subdistrict, strings.Join(postcodes, " or "))}
+}
+
+// ByPostcode คืนตำบล/แขวงทั้งหมดที่ใช้รหัสไปรษณีย์นี้ ใช้เติมที่อยู่อัตโนมัติในหน้าเว็บ
+func ByPostcode(postcode string) []Location {
+	locations := append([]Location{}, data.byPostcode[strings.TrimSpace(postcode)]...)
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].District != locations[j].District {
+			return locations[i].District < locations[j].District
+		}
+		return locations[i].Subdistrict < locations[j].Subdistrict
+	})
+	return locations
+}
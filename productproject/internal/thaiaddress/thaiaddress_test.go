@@ -0,0 +1,88 @@
+package thaiaddress
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name                                      string
+		province, district, subdistrict, postcode string
+		want                                      Location
+		wantField                                 string
+		wantNotCovered                            bool
+	}{
+		{
+			name:     "exact names",
+			province: "กรุงเทพมหานคร", district: "ปทุมวัน", subdistrict: "ลุมพินี", postcode: "10330",
+			want: Location{Province: "กรุงเทพมหานคร", District: "ปทุมวัน", Subdistrict: "ลุมพินี", Postcode: "10330"},
+		},
+		{
+			name:     "prefixes and province alias",
+			province: "กทม.", district: "เขต ปทุมวัน", subdistrict: "แขวงลุมพินี", postcode: " 10330 ",
+			want: Location{Province: "กรุงเทพมหานคร", District: "ปทุมวัน", Subdistrict: "ลุมพินี", Postcode: "10330"},
+		},
+		{
+			name:     "postcode of another subdistrict",
+			province: "กรุงเทพมหานคร", district: "ปทุมวัน", subdistrict: "ลุมพินี", postcode: "10500",
+			wantField: "postcode",
+		},
+		{
+			name:     "district in another province",
+			province: "กรุงเทพมหานคร", district: "เมืองเชียงใหม่", subdistrict: "ศรีภูมิ", postcode: "10200",
+			wantField: "district",
+		},
+		{
+			name:     "subdistrict in another district",
+			province: "กรุงเทพมหานคร", district: "ปทุมวัน", subdistrict: "สีลม", postcode: "10330",
+			wantField: "subdistrict",
+		},
+		{
+			name:     "misspelled province",
+			province: "กรุงเทพมหานะคร", district: "ปทุมวัน", subdistrict: "ลุมพินี", postcode: "10330",
+			wantField: "province",
+		},
+		{
+			name:     "postcode outside the province",
+			province: "จังหวัดเชียงใหม่", district: "เมืองเชียงใหม่", subdistrict: "ศรีภูมิ", postcode: "10200",
+			wantField: "postcode",
+		},
+		{
+			name:     "province without district data",
+			province: "จ.เชียงใหม่", district: "อำเภอเมืองเชียงใหม่", subdistrict: "ตำบลศรีภูมิ", postcode: "50200",
+			want:           Location{Province: "เชียงใหม่", District: "เมืองเชียงใหม่", Subdistrict: "ศรีภูมิ", Postcode: "50200"},
+			wantNotCovered: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Match(tt.province, tt.district, tt.subdistrict, tt.postcode)
+
+			var fieldErr *FieldError
+			switch {
+			case tt.wantField != "":
+				if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+					t.Fatalf("Match() error = %v, want a %s field error", err, tt.wantField)
+				}
+				return
+			case tt.wantNotCovered:
+				if !errors.Is(err, ErrNotCovered) {
+					t.Fatalf("Match() error = %v, want ErrNotCovered", err)
+				}
+			case err != nil:
+				t.Fatalf("Match() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvincePostcodePrefixes(t *testing.T) {
+	if len(data.postcodePrefixes) != 77 {
+		t.Errorf("dataset has %d provinces, want 77", len(data.postcodePrefixes))
+	}
+}
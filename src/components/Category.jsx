@@ -64,7 +64,7 @@ const Category = () => {
                       <Card.Body>
                         <Card.Title>{product.name}</Card.Title>
                         <Card.Text>
-                          <strong>ราคา:</strong> ฿{product.price}<br />
+                          <strong>ราคา:</strong> ฿{product.price?.amount}<br />
                           <strong>รายละเอียด:</strong> {product.description}<br />
                           <strong>แบรนด์:</strong> {product.brand}<br />
                           <strong>สต็อก:</strong> {product.inventory.quantity}
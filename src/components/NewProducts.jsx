@@ -47,7 +47,7 @@ const NewProducts = () => {
                     <Card.Title>{product.name}</Card.Title>
                     <Card.Text>{product.description}</Card.Text>
                     <Card.Text>
-                      <strong>Price: ฿{product.price}</strong>
+                      <strong>Price: ฿{product.price?.amount}</strong>
                     </Card.Text>
                   </Card.Body>
                   </Link>
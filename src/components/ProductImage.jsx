@@ -0,0 +1,37 @@
+import React, { useState } from 'react';
+
+// แสดงรูปสินค้าตามขนาดที่ต้องการ ('thumbnail', 'card', 'zoom') โดยเลือก WebP ก่อนถ้าเบราว์เซอร์รองรับ
+// ถ้ารูปยังประมวลผลไม่เสร็จ (ยังไม่มี variants) จะใช้รูปต้นฉบับแทน
+const ProductImage = ({ image, size, fallback, alt, className, style }) => {
+  const [failed, setFailed] = useState(false);
+
+  const variant = image?.variants?.[size];
+  const src = variant?.url || image?.image_url || fallback;
+
+  if (failed || !image) {
+    return <img src={fallback} alt={alt} className={className} style={style} />;
+  }
+
+  // รูปเบลอขนาดเล็กแสดงเป็นพื้นหลังระหว่างรอโหลดรูปจริง
+  const placeholderStyle = image.placeholder
+    ? { backgroundImage: `url(${image.placeholder})`, backgroundSize: 'cover', backgroundPosition: 'center' }
+    : {};
+
+  return (
+    <picture>
+      {variant?.webp_url && <source type="image/webp" srcSet={variant.webp_url} />}
+      <img
+        src={src}
+        alt={alt || image.alt_text}
+        width={variant?.width}
+        height={variant?.height}
+        loading="lazy"
+        className={className}
+        style={{ ...placeholderStyle, ...style }}
+        onError={() => setFailed(true)}
+      />
+    </picture>
+  );
+};
+
+export default ProductImage;
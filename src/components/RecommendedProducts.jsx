@@ -48,7 +48,7 @@ const RecommendedProducts = () => {
                       <Card.Title>{product.name}</Card.Title>
                       <Card.Text>{product.description}</Card.Text>
                       <Card.Text>
-                        <strong>Price: ฿{product.price}</strong>
+                        <strong>Price: ฿{product.price?.amount}</strong>
                       </Card.Text>
                     </Card.Body>
                   </Link>
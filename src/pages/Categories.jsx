@@ -95,7 +95,7 @@ const Categories = () => {
                             <Card.Body style={{ textAlign: 'left' }}>
                               <Card.Title>{product.name}</Card.Title>
                               <Card.Text>
-                                <strong>ราคา:</strong> ฿{product.price}<br />
+                                <strong>ราคา:</strong> ฿{product.price?.amount}<br />
                                 <strong>รายละเอียด:</strong> {product.description}<br />
                                 <strong>แบรนด์:</strong> {product.brand}<br />
                                 <strong>สต็อก:</strong> {product.inventory.quantity}
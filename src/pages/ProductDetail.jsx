@@ -1,10 +1,11 @@
 import React, { useState, useEffect } from 'react';
 import axios from 'axios';
 import { useParams } from 'react-router-dom';
-import { Container, Row, Col, Card, Button } from 'react-bootstrap';
+import { Container, Row, Col, Button } from 'react-bootstrap';
 import TopNav from '../components/TopNav';
 import TopMenu from '../components/TopMenu';
 import Footer from '../components/Footer';
+import ProductImage from '../components/ProductImage';
 
 const placeholderImage = '../assets/images/placeholder.jpg';
 
@@ -41,18 +42,18 @@ const ProductDetail = () => {
         <Row>
           <Col md={6}>
             {/* แสดงรูปภาพสินค้า */}
-            <Card.Img
-              src={product.images.find((img) => img.is_primary)?.image_url || placeholderImage}
-              onError={(e) => {
-                e.target.onerror = null;
-                e.target.src = placeholderImage;
-              }}
-              style={{ maxHeight: '400px', objectFit: 'contain' }}
+            <ProductImage
+              image={product.images?.find((img) => img.is_primary)}
+              size="zoom"
+              fallback={placeholderImage}
+              alt={product.name}
+              className="card-img"
+              style={{ maxHeight: '400px', objectFit: 'contain', height: 'auto' }}
             />
           </Col>
           <Col md={6}>
             <h2>{product.name}</h2>
-            <p><strong>ราคา:</strong> {product.price ? `฿${product.price}` : 'ราคาไม่ระบุ'}</p>
+            <p><strong>ราคา:</strong> {product.price ? `฿${product.price.amount}` : 'ราคาไม่ระบุ'}</p>
             <p><strong>รายละเอียดสินค้า:</strong> {product.description}</p>
             <p><strong>แบรนด์:</strong> {product.brand}</p>
             <p><strong>สต็อก:</strong> {product.inventory.quantity}</p>
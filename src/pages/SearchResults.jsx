@@ -76,7 +76,7 @@ const SearchResults = () => {
                             {product.name}
                           </Card.Title>
                           <div style={{ fontSize: '1.2rem', color: '#ff6600' }}>
-                            <strong>{product.price ? `฿${product.price}` : 'ราคาไม่ระบุ'}</strong>
+                            <strong>{product.price ? `฿${product.price.amount}` : 'ราคาไม่ระบุ'}</strong>
                           </div>
                           <Card.Text>
                             {product.description ? product.description : 'ไม่มีคำอธิบายสินค้า'}
@@ -5,6 +5,7 @@ import { Container, Row, Col, Card, Button } from 'react-bootstrap';
 import TopNav from '../components/TopNav';
 import TopMenu from '../components/TopMenu';
 import Footer from '../components/Footer';
+import ProductImage from '../components/ProductImage';
 
 const ShopDetail2 = () => {
   const { shopId } = useParams();
@@ -93,19 +94,17 @@ const ShopDetail2 = () => {
             products.map((product) => (
               <Col md={4} key={product.id} className="mb-4 d-flex">
                 <Card style={{ boxShadow: '0px 4px 10px rgba(0, 0, 0, 0.1)', borderRadius: '10px', width: '100%', display: 'flex', flexDirection: 'column', justifyContent: 'space-between', minHeight: '100%' }}>
-                  <Card.Img
-                    variant="top"
-                    src={product.images.find((img) => img.is_primary)?.image_url || placeholderImage}
+                  <ProductImage
+                    image={product.images?.find((img) => img.is_primary)}
+                    size="card"
+                    fallback={placeholderImage}
                     alt={product.name}
-                    style={{ borderRadius: '10px 10px 0 0', objectFit: 'cover', maxHeight: '300px' }}
-                    onError={(e) => {
-                      e.target.onerror = null;
-                      e.target.src = placeholderImage;
-                    }}
+                    className="card-img-top"
+                    style={{ borderRadius: '10px 10px 0 0', objectFit: 'cover', maxHeight: '300px', height: 'auto' }}
                   />
                   <Card.Body style={{ flex: '1' }}>
                     <Card.Title style={{ fontWeight: 'bold' }}>{product.name}</Card.Title>
-                    <Card.Text><strong>ราคา:</strong> {product.price ? `฿${product.price}` : 'ราคาไม่ระบุ'}</Card.Text>
+                    <Card.Text><strong>ราคา:</strong> {product.price ? `฿${product.price.amount}` : 'ราคาไม่ระบุ'}</Card.Text>
                     <Card.Text style={{ fontSize: '14px', color: '#555' }}><strong>รายละเอียด:</strong> {product.description}</Card.Text>
                     <Card.Text><strong>แบรนด์:</strong> {product.brand}</Card.Text>
                     <Card.Text><strong>สต็อก:</strong> {product.inventory?.quantity || 'ไม่ระบุ'}</Card.Text>